@@ -21,10 +21,14 @@ import (
 	"os"
 	"os/exec"
 	"path"
+	"path/filepath"
+	"strings"
 
 	"github.com/tomogoma/go-typed-errors"
+	"github.com/tomogoma/seedms/pkg/apidoc"
 	"github.com/tomogoma/seedms/pkg/config"
 	"github.com/tomogoma/seedms/pkg/fileutils"
+	"github.com/tomogoma/seedms/pkg/openapi"
 )
 
 func main() {
@@ -121,44 +125,86 @@ func compileDocs(docsDir string) error {
 
 	subjDir := path.Join("pkg", "handler", "http")
 	headerFile := path.Join(subjDir, "apidoc_header.md")
-	APIDocConfFile := path.Join(subjDir, "apidoc.json")
-
-	apiDoc := struct {
-		Name        string      `json:"name"`
-		Version     string      `json:"version"`
-		Description string      `json:"description"`
-		Title       string      `json:"title"`
-		Header      interface{} `json:"header"`
-	}{
-		Name:        config.Name,
-		Version:     config.VersionFull,
-		Description: config.Description,
-		Title:       config.CanonicalName(),
-		Header: struct {
-			Title    string `json:"title"`
-			FileName string `json:"filename"`
-		}{
-			Title:    "Introduction",
-			FileName: headerFile,
-		},
+
+	eps, err := parseAPIDocs(subjDir)
+	if err != nil {
+		return err
 	}
 
-	apiDocB, err := json.Marshal(apiDoc)
+	header, err := ioutil.ReadFile(headerFile)
 	if err != nil {
-		return errors.Newf("Marshal API doc config: %v", err)
+		return errors.Newf("read API doc header: %v", err)
 	}
 
-	err = ioutil.WriteFile(APIDocConfFile, apiDocB, 0655)
+	if err := os.MkdirAll(docsDir, 0755); err != nil {
+		return errors.Newf("create docs dir: %v", err)
+	}
+
+	var page bytes.Buffer
+	err = apidoc.Render(&page, apidoc.Project{
+		Title:       config.CanonicalName(),
+		Version:     config.VersionFull,
+		Description: config.Description,
+		HeaderTitle: "Introduction",
+		Header:      string(header),
+	}, eps)
 	if err != nil {
-		return errors.Newf("Write API doc file: %v", err)
+		return errors.Newf("render http docs: %v", err)
+	}
+	if err := ioutil.WriteFile(path.Join(docsDir, "index.html"), page.Bytes(), 0644); err != nil {
+		return errors.Newf("write http docs: %v", err)
 	}
 
-	args := []string{"-i", subjDir, "-c", subjDir, "-o", docsDir}
-	cmd := exec.Command("apidoc", args...)
-	if out, err := cmd.CombinedOutput(); err != nil {
-		return errors.Newf("generate http docs: %v: %s", err, out)
+	return compileOpenAPI(eps, docsDir)
+}
+
+// parseAPIDocs parses the apidoc comment blocks in the go files (and
+// _apidoc.js history file) in subjDir, dropping superseded versions.
+func parseAPIDocs(subjDir string) ([]apidoc.Endpoint, error) {
+	srcFiles, err := filepath.Glob(path.Join(subjDir, "*.go"))
+	if err != nil {
+		return nil, errors.Newf("list http handler files: %v", err)
+	}
+	srcFiles = append(srcFiles, path.Join(subjDir, "_apidoc.js"))
+	var eps []apidoc.Endpoint
+	for _, srcFile := range srcFiles {
+		if strings.HasSuffix(srcFile, "_test.go") {
+			continue
+		}
+		src, err := ioutil.ReadFile(srcFile)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, errors.Newf("read %s: %v", srcFile, err)
+		}
+		fileEPs, err := apidoc.Parse(src)
+		if err != nil {
+			return nil, errors.Newf("parse API docs in %s: %v", srcFile, err)
+		}
+		eps = append(eps, fileEPs...)
+	}
+	return apidoc.Latest(eps), nil
+}
+
+// compileOpenAPI generates an OpenAPI 3 document from the documented
+// endpoints eps and writes it to docsDir/openapi.json.
+func compileOpenAPI(eps []apidoc.Endpoint, docsDir string) error {
+	doc := openapi.New(openapi.Info{
+		Title:       config.CanonicalName(),
+		Description: config.Description,
+		Version:     config.VersionFull,
+	}, config.WebRootPath())
+	for _, ep := range eps {
+		if err := doc.AddEndpoint(ep); err != nil {
+			return errors.Newf("generate OpenAPI doc: %v", err)
+		}
 	}
-	return nil
+	docB, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return errors.Newf("marshal OpenAPI doc: %v", err)
+	}
+	return ioutil.WriteFile(path.Join(docsDir, "openapi.json"), docB, 0644)
 }
 
 func cleanGCloudConfFile() error {
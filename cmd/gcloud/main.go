@@ -6,6 +6,7 @@ import (
 	"github.com/tomogoma/seedms/pkg/bootstrap"
 	"github.com/tomogoma/seedms/pkg/config"
 	httpInternal "github.com/tomogoma/seedms/pkg/handler/http"
+	"github.com/tomogoma/seedms/pkg/handler/rpc"
 	"github.com/tomogoma/seedms/pkg/logging"
 	"github.com/tomogoma/seedms/pkg/logging/logrus"
 	"google.golang.org/appengine"
@@ -17,8 +18,20 @@ func main() {
 	log := &logrus.Wrapper{}
 	deps := bootstrap.Instantiate(config.DefaultConfPath(), log)
 
-	httpHandler, err := httpInternal.NewHandler(deps.Guard, log, config.WebRootPath(),
-		deps.Config.Service.DocsDir, deps.Config.Service.AllowedOrigins)
+	statusSrv, err := rpc.NewStatusHandler(deps.Health, log)
+	logging.LogFatalOnError(log, err, "Instantiate status handler")
+
+	opts := []httpInternal.Option{httpInternal.WithTracerProvider(deps.Tracing),
+		httpInternal.WithHealthChecker(deps.Health),
+		httpInternal.WithRequestIDHeader(deps.Config.Service.RequestIDHeader),
+		httpInternal.WithMaxBodyBytes(deps.Config.Service.MaxBodyBytes),
+		httpInternal.WithRPCService(statusSrv, httpInternal.StatusRPCRoutes, rpc.StatusPublicMethods...)}
+	if deps.Metrics != nil {
+		opts = append(opts, httpInternal.WithMetrics(deps.Metrics,
+			deps.Config.Service.Metrics.Guarded))
+	}
+	httpHandler, err := httpInternal.NewHandler(deps.Guard, deps.JWTEr, deps.KeyCache, deps.RateLimiter, log, config.WebRootPath(),
+		deps.Config.Service.DocsDir, deps.Config.Service.AllowedOrigins, opts...)
 	logging.LogFatalOnError(log, err, "Instantiate http Handler")
 
 	http.Handle("/", httpHandler)
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"flag"
+	"os"
+
+	"github.com/tomogoma/seedms/pkg/bootstrap"
+	"github.com/tomogoma/seedms/pkg/config"
+	"github.com/tomogoma/seedms/pkg/db/roach"
+	"github.com/tomogoma/seedms/pkg/logging"
+)
+
+const (
+	cmdBackup  = "backup"
+	cmdRestore = "restore"
+)
+
+// runBackup dumps the micro-service's DB into the file provided through
+// the -out flag (stdout if none).
+//     app backup -conf /path/to/conf.yml -format jsonl -out backup.jsonl
+func runBackup(args []string, log logging.Logger) {
+
+	fs := flag.NewFlagSet(cmdBackup, flag.ExitOnError)
+	confFile := fs.String("conf", config.DefaultConfPath(), "location of config file")
+	format := fs.String("format", string(roach.FormatJSONLines), "archive format: jsonl or csv")
+	out := fs.String("out", "", "file to write the archive to, defaults to stdout")
+	fs.Parse(args)
+
+	rdb := instantiateRoach(*confFile, log)
+
+	archFormat := roach.ArchiveFormat(*format)
+	if *out == "" {
+		err := rdb.Backup(os.Stdout, archFormat)
+		logging.LogFatalOnError(log, err, "Backup DB")
+		return
+	}
+
+	f, err := os.Create(*out)
+	logging.LogFatalOnError(log, err, "Create backup file")
+	err = rdb.Backup(f, archFormat)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		// do not leave a partial archive that might later be restored.
+		logging.LogWarnOnError(log, os.Remove(*out), "Remove partial backup file")
+	}
+	logging.LogFatalOnError(log, err, "Backup DB")
+}
+
+// runRestore imports an archive produced by runBackup from the file provided
+// through the -in flag (stdin if none) into an empty DB.
+//     app restore -conf /path/to/conf.yml -format jsonl -in backup.jsonl
+func runRestore(args []string, log logging.Logger) {
+
+	fs := flag.NewFlagSet(cmdRestore, flag.ExitOnError)
+	confFile := fs.String("conf", config.DefaultConfPath(), "location of config file")
+	format := fs.String("format", string(roach.FormatJSONLines), "archive format: jsonl or csv")
+	in := fs.String("in", "", "file to read the archive from, defaults to stdin")
+	fs.Parse(args)
+
+	rdb := instantiateRoach(*confFile, log)
+
+	r := os.Stdin
+	if *in != "" {
+		f, err := os.Open(*in)
+		logging.LogFatalOnError(log, err, "Open backup file")
+		defer f.Close()
+		r = f
+	}
+
+	err := rdb.Restore(r, roach.ArchiveFormat(*format))
+	logging.LogFatalOnError(log, err, "Restore DB")
+}
+
+func instantiateRoach(confFile string, log logging.Logger) *roach.Roach {
+	conf, err := config.ReadFile(confFile)
+	logging.LogFatalOnError(log, err, "Read config file")
+	rdb := bootstrap.InstantiateRoach(log, conf.Database)
+	logging.LogFatalOnError(log, rdb.InitDBIfNot(), "Initiate Cockroach DB connection")
+	return rdb
+}
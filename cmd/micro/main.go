@@ -1,60 +1,285 @@
 package main
 
 import (
+	"context"
 	"flag"
+	"net"
 	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/micro/go-micro"
+	"github.com/micro/go-micro/registry"
+	"github.com/micro/go-micro/server"
 	"github.com/micro/go-web"
+	"github.com/tomogoma/go-typed-errors"
 	"github.com/tomogoma/seedms/pkg/api"
 	"github.com/tomogoma/seedms/pkg/bootstrap"
+	"github.com/tomogoma/seedms/pkg/certs"
 	"github.com/tomogoma/seedms/pkg/config"
+	"github.com/tomogoma/seedms/pkg/db/roach"
+	"github.com/tomogoma/seedms/pkg/discovery"
+	grpcIntl "github.com/tomogoma/seedms/pkg/handler/grpc"
 	httpIntl "github.com/tomogoma/seedms/pkg/handler/http"
 	"github.com/tomogoma/seedms/pkg/handler/rpc"
+	"github.com/tomogoma/seedms/pkg/health"
 	"github.com/tomogoma/seedms/pkg/logging"
 	"github.com/tomogoma/seedms/pkg/logging/logrus"
 	_ "github.com/tomogoma/seedms/pkg/logging/standard"
+	"github.com/tomogoma/seedms/pkg/tracing"
+	"google.golang.org/grpc"
 )
 
+// defaultShutdownTimeout is used if the config has no shutdownTimeout.
+const defaultShutdownTimeout = 15 * time.Second
+
+// defaultGRPCAddress is used if the config has no rpc.address.
+const defaultGRPCAddress = ":9090"
+
+// defaultHTTPAddress is used if the config has no http.address.
+const defaultHTTPAddress = ":8080"
+
 func main() {
 
+	log := &logrus.Wrapper{}
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case cmdBackup:
+			runBackup(os.Args[2:], log)
+			return
+		case cmdRestore:
+			runRestore(os.Args[2:], log)
+			return
+		case cmdSeed:
+			runSeed(os.Args[2:], log)
+			return
+		}
+	}
+
 	confFile := flag.String("conf", config.DefaultConfPath(), "location of config file")
 	flag.Parse()
-	log := &logrus.Wrapper{}
 	deps := bootstrap.Instantiate(*confFile, log)
+	err := validateTLSConfig(deps.Config.Service)
+	logging.LogFatalOnError(log, err, "Validate TLS config")
+
+	// Both go-micro services deregister and stop accepting requests on
+	// their own when the process receives SIGTERM/SIGINT; this is used to
+	// stop the gRPC and standalone HTTP servers (if any) and time draining
+	// in-flight requests from then.
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGTERM, syscall.SIGINT)
+
+	reg, err := discovery.New(deps.Config.Service.Registry)
+	logging.LogFatalOnError(log, err, "Instantiate service registry")
 
 	serverRPCQuitCh := make(chan error)
-	rpcSrv, err := rpc.NewStatusHandler(deps.Guard, log)
+	rpcSrv, err := rpc.NewStatusHandler(deps.Health, log)
 	logging.LogFatalOnError(log, err, "Instantate RPC handler")
-	go serveRPC(deps.Config.Service, rpcSrv, serverRPCQuitCh)
+	authWrappers := []server.HandlerWrapper{
+		rpc.NewAPIKeyWrapper(deps.Guard, log, rpc.StatusKeylessMethods...),
+		rpc.NewAuthWrapper(deps.JWTEr, log, rpc.StatusPublicMethods...)}
+	rpcInFlight := &rpc.InFlight{}
+	rpcWrappers := []server.HandlerWrapper{rpcInFlight.Wrapper(),
+		rpc.NewTraceWrapper(deps.Tracing),
+		rpc.NewRequestIDWrapper(deps.Config.Service.RequestIDHeader)}
+	httpOpts := []httpIntl.Option{httpIntl.WithTracerProvider(deps.Tracing),
+		httpIntl.WithHealthChecker(deps.Health),
+		httpIntl.WithRequestIDHeader(deps.Config.Service.RequestIDHeader),
+		httpIntl.WithMaxBodyBytes(deps.Config.Service.MaxBodyBytes),
+		httpIntl.WithRPCService(rpcSrv, httpIntl.StatusRPCRoutes, rpc.StatusPublicMethods...)}
+	if deps.Metrics != nil {
+		rpcWrappers = append(rpcWrappers, rpc.NewMetricsWrapper(deps.Metrics))
+		httpOpts = append(httpOpts, httpIntl.WithMetrics(deps.Metrics,
+			deps.Config.Service.Metrics.Guarded))
+	}
+	// Panics are recovered within the metrics wrapper so that they are
+	// observed as internal errors.
+	rpcWrappers = append(rpcWrappers, rpc.NewRecoveryWrapper(log))
+	rpcWrappers = append(rpcWrappers, authWrappers...)
+	var grpcSrv *grpc.Server
+	switch deps.Config.Service.RPC.Server {
+	case config.RPCServerGRPC:
+		grpcSrv, err = newGRPCServer(deps.Config.Service.RPC, rpcSrv, deps.Health, log, rpcWrappers...)
+		logging.LogFatalOnError(log, err, "Instantiate gRPC server")
+		go serveGRPC(deps.Config.Service.RPC, grpcSrv, serverRPCQuitCh)
+	case "", config.RPCServerMicro:
+		rpcService := newRPCService(deps.Config.Service, reg, rpcSrv, rpcWrappers...)
+		deps.Health.Register(health.CheckRegistry, registryCheck(rpcService))
+		go serveRPC(rpcService, serverRPCQuitCh)
+	default:
+		log.Fatalf("Unknown rpc.server '%s'", deps.Config.Service.RPC.Server)
+	}
 
 	serverHttpQuitCh := make(chan error)
-	httpHandler, err := httpIntl.NewHandler(deps.Guard, log, config.WebRootPath(),
-		deps.Config.Service.DocsDir, deps.Config.Service.AllowedOrigins)
+	httpHandler, err := httpIntl.NewHandler(deps.Guard, deps.JWTEr, deps.KeyCache, deps.RateLimiter, log, config.WebRootPath(),
+		deps.Config.Service.DocsDir, deps.Config.Service.AllowedOrigins, httpOpts...)
 	logging.LogFatalOnError(log, err, "Instantiate HTTP handler")
-	go serveHttp(deps.Config.Service, httpHandler, serverHttpQuitCh)
+	httpSrv := &http.Server{}
+	standaloneHTTP := false
+	switch deps.Config.Service.HTTP.Server {
+	case config.HTTPServerStandalone:
+		httpSrv, err = newHTTPServer(deps.Config.Service.HTTP, httpHandler, log)
+		logging.LogFatalOnError(log, err, "Instantiate HTTP server")
+		standaloneHTTP = true
+		go serveStandaloneHTTP(deps.Config.Service, reg, httpSrv, log, serverHttpQuitCh)
+	case "", config.HTTPServerMicro:
+		go serveHttp(deps.Config.Service, reg, httpHandler, httpSrv, serverHttpQuitCh)
+	default:
+		log.Fatalf("Unknown http.server '%s'", deps.Config.Service.HTTP.Server)
+	}
+
+	shutdownTimeout := deps.Config.Service.ShutdownTimeout
+	if shutdownTimeout <= 0 {
+		shutdownTimeout = defaultShutdownTimeout
+	}
+	// The shutdown deadline starts with the signal, or once both servers
+	// have quit if there is none.
+	var ctx context.Context
+	cancel := func() {}
+	var httpDrained <-chan error
+	for serverHttpQuitCh != nil || serverRPCQuitCh != nil {
+		select {
+		case sig := <-sigCh:
+			log.Infof("received %s, shutting down", sig)
+			sigCh = nil // repeated signals do not extend the deadline
+			ctx, cancel = context.WithTimeout(context.Background(), shutdownTimeout)
+			if grpcSrv != nil {
+				go grpcSrv.GracefulStop()
+			}
+			if standaloneHTTP {
+				httpDrained = shutdownHTTP(ctx, httpSrv)
+			}
+		case err = <-serverHttpQuitCh:
+			logging.LogFatalOnError(log, err, "Serve HTTP")
+			serverHttpQuitCh = nil
+		case err = <-serverRPCQuitCh:
+			logging.LogFatalOnError(log, err, "Serve RPC")
+			serverRPCQuitCh = nil
+		}
+	}
+	if ctx == nil {
+		ctx, cancel = context.WithTimeout(context.Background(), shutdownTimeout)
+	}
+	defer cancel()
+	if httpDrained == nil {
+		httpDrained = shutdownHTTP(ctx, httpSrv)
+	}
+	shutdown(ctx, log, httpDrained, rpcInFlight, deps.Roach, deps.Tracing)
+}
+
+// shutdownHTTP stops srv from accepting requests at once and returns a
+// channel receiving the result of waiting (until ctx is done) for its
+// in-flight requests to complete.
+func shutdownHTTP(ctx context.Context, srv *http.Server) <-chan error {
+	drained := make(chan error, 1)
+	go func() { drained <- srv.Shutdown(ctx) }()
+	return drained
+}
 
-	select {
-	case err = <-serverHttpQuitCh:
-		logging.LogFatalOnError(log, err, "Serve HTTP")
-	case err = <-serverRPCQuitCh:
-		logging.LogFatalOnError(log, err, "Serve RPC")
+// shutdown waits for in-flight requests to complete on the already stopped
+// services, HTTP ones through httpDrained (see shutdownHTTP) and RPC ones
+// until ctx is done, then closes DB connections and exports pending spans.
+func shutdown(ctx context.Context, log logging.Logger, httpDrained <-chan error, rpcInFlight *rpc.InFlight, rdb *roach.Roach, tp *tracing.Provider) {
+	logging.LogWarnOnError(log, <-httpDrained, "Drain HTTP requests")
+	logging.LogWarnOnError(log, rpcInFlight.Wait(ctx), "Drain RPC requests")
+	logging.LogWarnOnError(log, rdb.Close(), "Close DB connections")
+	logging.LogWarnOnError(log, tp.Shutdown(ctx), "Export pending spans")
+	log.Info("shutdown complete")
+}
+
+// validateTLSConfig returns an error if tls is configured under rpc or http
+// while the respective server is a go-micro one, which would otherwise
+// ignore it and serve without TLS.
+func validateTLSConfig(conf config.Service) error {
+	if isTLSConfigured(conf.RPC.TLS) && (conf.RPC.Server == "" || conf.RPC.Server == config.RPCServerMicro) {
+		return errors.Newf("rpc.tls is only supported by the '%s' rpc.server",
+			config.RPCServerGRPC)
+	}
+	if isTLSConfigured(conf.HTTP.TLS) && (conf.HTTP.Server == "" || conf.HTTP.Server == config.HTTPServerMicro) {
+		return errors.Newf("http.tls is only supported by the '%s' http.server",
+			config.HTTPServerStandalone)
 	}
+	return nil
 }
 
-func serveRPC(conf config.Service, rpcSrv *rpc.StatusHandler, quitCh chan error) {
+func isTLSConfigured(conf config.TLS) bool {
+	return conf.CertFile != "" || conf.KeyFile != "" ||
+		conf.ClientCAFile != "" || conf.RequireClientCert
+}
+
+func newRPCService(conf config.Service, reg registry.Registry, rpcSrv *rpc.StatusHandler, wrappers ...server.HandlerWrapper) micro.Service {
 	service := micro.NewService(
 		micro.Name(config.CanonicalRPCName()),
+		micro.Registry(reg),
 		micro.Version(conf.LoadBalanceVersion),
 		micro.RegisterInterval(conf.RegisterInterval),
+		micro.WrapHandler(wrappers...),
 	)
 	api.RegisterStatusHandler(service.Server(), rpcSrv)
-	err := service.Run()
-	quitCh <- err
+	return service
 }
 
-func serveHttp(conf config.Service, h http.Handler, quitCh chan error) {
+func serveRPC(service micro.Service, quitCh chan error) {
+	quitCh <- service.Run()
+}
+
+func newGRPCServer(conf config.RPC, rpcSrv *rpc.StatusHandler, hc grpcIntl.HealthChecker, log logging.Logger, wrappers ...server.HandlerWrapper) (*grpc.Server, error) {
+	opts := []grpcIntl.Option{grpcIntl.WithHandlerWrappers(wrappers...)}
+	if conf.TLS.CertFile != "" || conf.TLS.KeyFile != "" {
+		tlsConf, err := certs.NewServerConfig(conf.TLS, log)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, grpcIntl.WithTLSConfig(tlsConf))
+	}
+	if conf.Reflection {
+		opts = append(opts, grpcIntl.WithReflection())
+	}
+	return grpcIntl.NewServer(rpcSrv, hc, opts...)
+}
+
+// serveGRPC serves srv on conf.Address until srv is stopped. Unlike the
+// go-micro service, srv is not registered with the service registry.
+func serveGRPC(conf config.RPC, srv *grpc.Server, quitCh chan error) {
+	addr := conf.Address
+	if addr == "" {
+		addr = defaultGRPCAddress
+	}
+	lis, err := net.Listen("tcp", addr)
+	if err != nil {
+		quitCh <- errors.Newf("listen on %s: %v", addr, err)
+		return
+	}
+	quitCh <- srv.Serve(lis)
+}
+
+// registryCheck returns a health.Check which fails if service's server
+// (node) is not registered in service's registry.
+func registryCheck(service micro.Service) health.Check {
+	return func(context.Context) error {
+		srvOpts := service.Server().Options()
+		nodeID := srvOpts.Name + "-" + srvOpts.Id
+		services, err := service.Options().Registry.GetService(srvOpts.Name)
+		if err != nil {
+			return errors.Newf("get service from registry: %v", err)
+		}
+		for _, s := range services {
+			for _, n := range s.Nodes {
+				if n.Id == nodeID {
+					return nil
+				}
+			}
+		}
+		return errors.Newf("node %s not registered", nodeID)
+	}
+}
+
+func serveHttp(conf config.Service, reg registry.Registry, h http.Handler, srv *http.Server, quitCh chan error) {
 	srvc := web.NewService(
+		web.Server(srv),
+		web.Registry(reg),
 		web.Handler(h),
 		web.Name(config.CanonicalWebName()),
 		web.Version(conf.LoadBalanceVersion),
@@ -62,3 +287,58 @@ func serveHttp(conf config.Service, h http.Handler, quitCh chan error) {
 	)
 	quitCh <- srvc.Run()
 }
+
+func newHTTPServer(conf config.HTTP, h http.Handler, log logging.Logger) (*http.Server, error) {
+	srv := &http.Server{
+		Handler:        h,
+		ReadTimeout:    conf.ReadTimeout,
+		WriteTimeout:   conf.WriteTimeout,
+		IdleTimeout:    conf.IdleTimeout,
+		MaxHeaderBytes: conf.MaxHeaderBytes,
+	}
+	if conf.TLS.CertFile != "" || conf.TLS.KeyFile != "" {
+		tlsConf, err := certs.NewServerConfig(conf.TLS, log)
+		if err != nil {
+			return nil, err
+		}
+		srv.TLSConfig = tlsConf
+	}
+	return srv, nil
+}
+
+// serveStandaloneHTTP serves srv on conf.HTTP.Address until srv is shut
+// down. srv is registered with reg, as the go-micro web service would be,
+// only if conf.HTTP.Register is set.
+func serveStandaloneHTTP(conf config.Service, reg registry.Registry, srv *http.Server, log logging.Logger, quitCh chan error) {
+	addr := conf.HTTP.Address
+	if addr == "" {
+		addr = defaultHTTPAddress
+	}
+	lis, err := net.Listen("tcp", addr)
+	if err != nil {
+		quitCh <- errors.Newf("listen on %s: %v", addr, err)
+		return
+	}
+	deregister := func() error { return nil }
+	if conf.HTTP.Register {
+		deregister, err = discovery.Announce(reg, config.CanonicalWebName(), conf.LoadBalanceVersion,
+			lis.Addr(), conf.RegisterInterval, func(err error) {
+				logging.LogWarnOnError(log, err, "Register HTTP server")
+			})
+		if err != nil {
+			lis.Close()
+			quitCh <- err
+			return
+		}
+	}
+	if srv.TLSConfig != nil {
+		err = srv.ServeTLS(lis, "", "")
+	} else {
+		err = srv.Serve(lis)
+	}
+	if err == http.ErrServerClosed {
+		err = nil
+	}
+	logging.LogWarnOnError(log, deregister(), "Deregister HTTP server")
+	quitCh <- err
+}
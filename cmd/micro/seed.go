@@ -0,0 +1,32 @@
+package main
+
+import (
+	"flag"
+
+	"github.com/tomogoma/seedms/pkg/config"
+	"github.com/tomogoma/seedms/pkg/db/roach"
+	"github.com/tomogoma/seedms/pkg/logging"
+)
+
+const cmdSeed = "seed"
+
+// runSeed loads YAML/JSON fixture files provided as arguments into the DB
+// e.g. for demo environments:
+//     app seed -conf /path/to/conf.yml -truncate demo.yml more-demo.json
+func runSeed(args []string, log logging.Logger) {
+
+	fs := flag.NewFlagSet(cmdSeed, flag.ExitOnError)
+	confFile := fs.String("conf", config.DefaultConfPath(), "location of config file")
+	truncate := fs.Bool("truncate", false, "delete existing data before seeding")
+	fs.Parse(args)
+
+	fixtures, err := roach.ReadFixtures(fs.Args()...)
+	logging.LogFatalOnError(log, err, "Read fixture files")
+
+	rdb := instantiateRoach(*confFile, log)
+
+	if *truncate {
+		logging.LogFatalOnError(log, rdb.Truncate(), "Truncate DB")
+	}
+	logging.LogFatalOnError(log, rdb.LoadFixtures(fixtures), "Load fixtures")
+}
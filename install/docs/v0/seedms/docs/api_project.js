@@ -1,19 +0,0 @@
-define({
-  "name": "seedms",
-  "version": "0.1.0",
-  "description": "seedmsDescription",
-  "title": "seedmsv0",
-  "header": {
-    "title": "Introduction",
-    "content": "<h2>Usage</h2>\n<p>All endpoints should be prefixed by the parent URL path to this doc\ne.g. if this doc is at <code>http://localhost/gw/v0/foo/docs</code> then all\nendpoint URLs should be prefixed with <code>http://localhost/gw/v0/foo</code></p>\n"
-  },
-  "sampleUrl": false,
-  "defaultVersion": "0.0.0",
-  "apidoc": "0.3.0",
-  "generator": {
-    "name": "apidoc",
-    "time": "2018-03-31T22:57:05.801Z",
-    "url": "http://apidocjs.com",
-    "version": "0.17.6"
-  }
-});
@@ -0,0 +1,39 @@
+package api
+
+import (
+	"context"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+type claimsKey struct{}
+
+// Claims are the JWT claims of an authenticated user as issued by the
+// authentication micro-service.
+type Claims struct {
+	UserID string   `json:"userID"`
+	Roles  []string `json:"roles,omitempty"`
+	jwt.StandardClaims
+}
+
+// HasRole returns true if role is one of the claims' Roles.
+func (c Claims) HasRole(role string) bool {
+	for _, r := range c.Roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+// ContextWithClaims returns a copy of ctx carrying claims. Handlers on
+// either transport read them using ClaimsFromContext.
+func ContextWithClaims(ctx context.Context, claims Claims) context.Context {
+	return context.WithValue(ctx, claimsKey{}, claims)
+}
+
+// ClaimsFromContext returns the claims added to ctx by ContextWithClaims.
+func ClaimsFromContext(ctx context.Context) (Claims, bool) {
+	claims, ok := ctx.Value(claimsKey{}).(Claims)
+	return claims, ok
+}
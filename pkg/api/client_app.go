@@ -0,0 +1,19 @@
+package api
+
+import "context"
+
+type clientAppUserIDKey struct{}
+
+// ContextWithClientAppUserID returns a copy of ctx carrying the user ID of
+// the client application whose API key authenticated a request. Handlers
+// on either transport read it using ClientAppUserIDFromContext.
+func ContextWithClientAppUserID(ctx context.Context, userID string) context.Context {
+	return context.WithValue(ctx, clientAppUserIDKey{}, userID)
+}
+
+// ClientAppUserIDFromContext returns the user ID added to ctx by
+// ContextWithClientAppUserID.
+func ClientAppUserIDFromContext(ctx context.Context) (string, bool) {
+	userID, ok := ctx.Value(clientAppUserIDKey{}).(string)
+	return userID, ok
+}
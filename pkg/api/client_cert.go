@@ -0,0 +1,21 @@
+package api
+
+import "context"
+
+type clientCertSubjectKey struct{}
+
+// ContextWithClientCertSubject returns a copy of ctx carrying the subject
+// (e.g. "CN=billing,O=Acme") of the verified TLS client certificate of a
+// request. Handlers on either transport read it using
+// ClientCertSubjectFromContext.
+func ContextWithClientCertSubject(ctx context.Context, subject string) context.Context {
+	return context.WithValue(ctx, clientCertSubjectKey{}, subject)
+}
+
+// ClientCertSubjectFromContext returns the subject added to ctx by
+// ContextWithClientCertSubject. It is false for requests without a
+// verified client certificate.
+func ClientCertSubjectFromContext(ctx context.Context) (string, bool) {
+	subject, ok := ctx.Value(clientCertSubjectKey{}).(string)
+	return subject, ok
+}
@@ -10,6 +10,9 @@ It is generated from these files:
 It has these top-level messages:
 	Request
 	Response
+	HealthRequest
+	HealthCheck
+	HealthResponse
 */
 package api
 
@@ -90,9 +93,76 @@ func (m *Response) GetCanonicalName() string {
 	return ""
 }
 
+type HealthRequest struct {
+}
+
+func (m *HealthRequest) Reset()                    { *m = HealthRequest{} }
+func (m *HealthRequest) String() string            { return proto.CompactTextString(m) }
+func (*HealthRequest) ProtoMessage()               {}
+func (*HealthRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{2} }
+
+type HealthCheck struct {
+	Name  string `protobuf:"bytes,1,opt,name=name" json:"name,omitempty"`
+	Ready bool   `protobuf:"varint,2,opt,name=ready" json:"ready,omitempty"`
+	Error string `protobuf:"bytes,3,opt,name=error" json:"error,omitempty"`
+}
+
+func (m *HealthCheck) Reset()                    { *m = HealthCheck{} }
+func (m *HealthCheck) String() string            { return proto.CompactTextString(m) }
+func (*HealthCheck) ProtoMessage()               {}
+func (*HealthCheck) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{3} }
+
+func (m *HealthCheck) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+func (m *HealthCheck) GetReady() bool {
+	if m != nil {
+		return m.Ready
+	}
+	return false
+}
+
+func (m *HealthCheck) GetError() string {
+	if m != nil {
+		return m.Error
+	}
+	return ""
+}
+
+type HealthResponse struct {
+	Ready  bool           `protobuf:"varint,1,opt,name=ready" json:"ready,omitempty"`
+	Checks []*HealthCheck `protobuf:"bytes,2,rep,name=checks" json:"checks,omitempty"`
+}
+
+func (m *HealthResponse) Reset()                    { *m = HealthResponse{} }
+func (m *HealthResponse) String() string            { return proto.CompactTextString(m) }
+func (*HealthResponse) ProtoMessage()               {}
+func (*HealthResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{4} }
+
+func (m *HealthResponse) GetReady() bool {
+	if m != nil {
+		return m.Ready
+	}
+	return false
+}
+
+func (m *HealthResponse) GetChecks() []*HealthCheck {
+	if m != nil {
+		return m.Checks
+	}
+	return nil
+}
+
 func init() {
 	proto.RegisterType((*Request)(nil), "api.Request")
 	proto.RegisterType((*Response)(nil), "api.Response")
+	proto.RegisterType((*HealthRequest)(nil), "api.HealthRequest")
+	proto.RegisterType((*HealthCheck)(nil), "api.HealthCheck")
+	proto.RegisterType((*HealthResponse)(nil), "api.HealthResponse")
 }
 
 // Reference imports to suppress errors if they are not otherwise used.
@@ -104,6 +174,7 @@ var _ server.Option
 
 type StatusClient interface {
 	Check(ctx context.Context, in *Request, opts ...client.CallOption) (*Response, error)
+	Health(ctx context.Context, in *HealthRequest, opts ...client.CallOption) (*HealthResponse, error)
 }
 
 type statusClient struct {
@@ -134,10 +205,21 @@ func (c *statusClient) Check(ctx context.Context, in *Request, opts ...client.Ca
 	return out, nil
 }
 
+func (c *statusClient) Health(ctx context.Context, in *HealthRequest, opts ...client.CallOption) (*HealthResponse, error) {
+	req := c.c.NewRequest(c.serviceName, "Status.Health", in)
+	out := new(HealthResponse)
+	err := c.c.Call(ctx, req, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // Server API for Status service
 
 type StatusHandler interface {
 	Check(context.Context, *Request, *Response) error
+	Health(context.Context, *HealthRequest, *HealthResponse) error
 }
 
 func RegisterStatusHandler(s server.Server, hdlr StatusHandler, opts ...server.HandlerOption) {
@@ -152,22 +234,31 @@ func (h *Status) Check(ctx context.Context, in *Request, out *Response) error {
 	return h.StatusHandler.Check(ctx, in, out)
 }
 
+func (h *Status) Health(ctx context.Context, in *HealthRequest, out *HealthResponse) error {
+	return h.StatusHandler.Health(ctx, in, out)
+}
+
 func init() { proto.RegisterFile("github.com/tomogoma/seedms/pkg/api/status.proto", fileDescriptor0) }
 
 var fileDescriptor0 = []byte{
-	// 221 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x54, 0x8f, 0xc1, 0x4a, 0xc3, 0x40,
-	0x10, 0x86, 0x8d, 0xad, 0xa9, 0x8e, 0xf6, 0x32, 0x07, 0x09, 0x9e, 0x6a, 0x10, 0xf1, 0x94, 0x15,
-	0x7d, 0x02, 0xf1, 0x24, 0x82, 0x48, 0x7c, 0x82, 0xe9, 0x76, 0x48, 0x97, 0xba, 0x3b, 0x6b, 0x66,
-	0x23, 0x78, 0xf3, 0xd1, 0x25, 0x6b, 0x04, 0x7b, 0xdb, 0xff, 0xfb, 0x7f, 0x96, 0x6f, 0xc0, 0x74,
-	0x2e, 0x6d, 0x87, 0x75, 0x63, 0xc5, 0x9b, 0x24, 0x5e, 0x3a, 0xf1, 0x64, 0x94, 0x79, 0xe3, 0xd5,
-	0xc4, 0x5d, 0x67, 0x28, 0x3a, 0xa3, 0x89, 0xd2, 0xa0, 0x4d, 0xec, 0x25, 0x09, 0xce, 0x28, 0xba,
-	0xfa, 0x12, 0x16, 0x2d, 0x7f, 0x0c, 0xac, 0x09, 0xcf, 0xa1, 0x7c, 0x78, 0x7d, 0x7a, 0xe6, 0xaf,
-	0xaa, 0x58, 0x15, 0x37, 0x27, 0xed, 0x94, 0xea, 0xef, 0x02, 0x8e, 0x5b, 0xd6, 0x28, 0x41, 0x19,
-	0x11, 0xe6, 0x81, 0x3c, 0x4f, 0x93, 0xfc, 0xc6, 0x0a, 0x16, 0x9f, 0xdc, 0xab, 0x93, 0x50, 0x1d,
-	0x66, 0xfc, 0x17, 0x71, 0x05, 0xa7, 0x1b, 0x56, 0xdb, 0xbb, 0x98, 0xc6, 0x76, 0x96, 0xdb, 0xff,
-	0x08, 0xaf, 0x60, 0x69, 0x29, 0x48, 0x70, 0x96, 0xde, 0x5f, 0xc6, 0x8f, 0xe7, 0x79, 0xb3, 0x0f,
-	0xef, 0x6e, 0xa1, 0x7c, 0xcb, 0xea, 0x78, 0x0d, 0x47, 0x8f, 0x5b, 0xb6, 0x3b, 0x3c, 0x6b, 0x28,
-	0xba, 0x66, 0x72, 0xbf, 0x58, 0x4e, 0xe9, 0xd7, 0xb2, 0x3e, 0x58, 0x97, 0xf9, 0xc6, 0xfb, 0x9f,
-	0x00, 0x00, 0x00, 0xff, 0xff, 0xc8, 0x0c, 0xbf, 0x4b, 0x16, 0x01, 0x00, 0x00,
+	// 303 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x6c, 0x51, 0x5f, 0x4b, 0xfb, 0x30,
+	0x14, 0xfd, 0x75, 0x7f, 0xba, 0xfd, 0xee, 0x9c, 0x4a, 0x14, 0x29, 0x3e, 0xcd, 0x20, 0xb2, 0xa7,
+	0x06, 0xb6, 0x4f, 0x20, 0xbe, 0x28, 0xa2, 0x8c, 0xfa, 0x09, 0xb2, 0xec, 0xb2, 0x85, 0xad, 0x4d,
+	0x4c, 0x52, 0x61, 0x6f, 0x7e, 0x74, 0x69, 0x92, 0x4a, 0x07, 0xbe, 0xf5, 0x9c, 0x7b, 0xce, 0xb9,
+	0xe7, 0x36, 0xc0, 0xb6, 0xd2, 0xed, 0xea, 0x75, 0x2e, 0x54, 0xc9, 0x9c, 0x2a, 0xd5, 0x56, 0x95,
+	0x9c, 0x59, 0xc4, 0x4d, 0x69, 0x99, 0xde, 0x6f, 0x19, 0xd7, 0x92, 0x59, 0xc7, 0x5d, 0x6d, 0x73,
+	0x6d, 0x94, 0x53, 0xa4, 0xcf, 0xb5, 0xa4, 0x77, 0x30, 0x2a, 0xf0, 0xb3, 0x46, 0xeb, 0xc8, 0x0d,
+	0xa4, 0x8f, 0xab, 0x97, 0x57, 0x3c, 0x66, 0xc9, 0x2c, 0x99, 0xff, 0x2f, 0x22, 0xa2, 0xdf, 0x09,
+	0x8c, 0x0b, 0xb4, 0x5a, 0x55, 0x16, 0x09, 0x81, 0x41, 0xc5, 0x4b, 0x8c, 0x12, 0xff, 0x4d, 0x32,
+	0x18, 0x7d, 0xa1, 0xb1, 0x52, 0x55, 0x59, 0xcf, 0xd3, 0x2d, 0x24, 0x33, 0x98, 0x6c, 0xd0, 0x0a,
+	0x23, 0xb5, 0x6b, 0xa6, 0x7d, 0x3f, 0xed, 0x52, 0xe4, 0x1e, 0xa6, 0x82, 0x57, 0xaa, 0x92, 0x82,
+	0x1f, 0xde, 0x9b, 0xe0, 0x81, 0xd7, 0x9c, 0x92, 0xf4, 0x02, 0xa6, 0xcf, 0xc8, 0x0f, 0x6e, 0x17,
+	0xbb, 0xd2, 0x37, 0x98, 0x04, 0xe2, 0x69, 0x87, 0x62, 0xff, 0x67, 0xab, 0x6b, 0x18, 0x1a, 0xe4,
+	0x9b, 0xa3, 0xef, 0x34, 0x2e, 0x02, 0x68, 0x58, 0x34, 0x46, 0x99, 0xd8, 0x25, 0x00, 0xba, 0x82,
+	0xf3, 0x36, 0x3f, 0xde, 0xf9, 0xeb, 0x4e, 0xba, 0xee, 0x39, 0xa4, 0xa2, 0x59, 0x68, 0xb3, 0xde,
+	0xac, 0x3f, 0x9f, 0x2c, 0x2e, 0x73, 0xae, 0x65, 0xde, 0x69, 0x52, 0xc4, 0xf9, 0x02, 0x21, 0xfd,
+	0xf0, 0x3f, 0x9b, 0x3c, 0xc0, 0x30, 0x94, 0x3c, 0xf3, 0xe2, 0x78, 0xc1, 0xed, 0x34, 0xa2, 0xb0,
+	0x8f, 0xfe, 0x23, 0x4b, 0x48, 0x43, 0x10, 0x21, 0x9d, 0xd4, 0x56, 0x7e, 0x75, 0xc2, 0xb5, 0xa6,
+	0x75, 0xea, 0x9f, 0x72, 0xf9, 0x33, 0x00, 0xee, 0x84, 0x6a, 0x9e, 0xfd, 0x01, 0x00, 0x00,
 }
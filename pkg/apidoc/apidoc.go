@@ -0,0 +1,209 @@
+// Package apidoc parses the apidoc (http://apidocjs.com) comment blocks
+// documenting the micro-service's HTTP routes and renders them as a
+// self-contained HTML docs site, so that docs build without the Node apidoc
+// tool.
+package apidoc
+
+import (
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/tomogoma/go-typed-errors"
+)
+
+var (
+	blockRegex = regexp.MustCompile(`(?s)/\*\*(.*?)\*/`)
+	// e.g. @api {get} /users/:userID/apikeys?cursor=:cursor List API Keys
+	apiRegex = regexp.MustCompile(`^\{(\w+)\}\s+(\S+)\s*(.*)$`)
+	// e.g. (200) {Number{1-100}} [limit=20] Maximum number of API keys.
+	fieldRegex = regexp.MustCompile(`^(?:\((\w+)\)\s+)?(?:\{((?:[^{}]|\{[^{}]*\})*)\}\s+)?(\[[^\]]+\]|\S+)\s*(.*)$`)
+)
+
+// Field is an @apiParam, @apiHeader, @apiSuccess or @apiError line. For
+// instance "(200) {Number{1-100}} [limit=20] Page size." has Group "200",
+// Type "Number{1-100}", Name "limit", Default "20" and is Optional.
+type Field struct {
+	Group       string
+	Type        string
+	Name        string
+	Optional    bool
+	Default     string
+	Description string
+}
+
+// Endpoint is an apidoc comment block documenting a route.
+type Endpoint struct {
+	Method      string // lower case e.g. get
+	Path        string // as documented e.g. /users/:userID?cursor=:cursor
+	Title       string
+	Name        string
+	Group       string
+	Version     string
+	Description string
+	Params      []Field
+	Headers     []Field
+	Success     []Field
+	Errors      []Field
+}
+
+// Parse returns the routes documented by the apidoc comment blocks in src
+// (e.g. the contents of a Go file) in the order they appear. Comment blocks
+// without an @api line are ignored. Supported tags are @api, @apiName,
+// @apiGroup, @apiVersion, @apiDescription, @apiHeader, @apiParam,
+// @apiSuccess and @apiError; untagged lines continue the description of
+// the preceding tag.
+func Parse(src []byte) ([]Endpoint, error) {
+	var eps []Endpoint
+	for _, m := range blockRegex.FindAllSubmatch(src, -1) {
+		ep, err := parseBlock(string(m[1]))
+		if err != nil {
+			return nil, err
+		}
+		if ep != nil {
+			eps = append(eps, *ep)
+		}
+	}
+	return eps, nil
+}
+
+// parseBlock parses the apidoc tags in the comment block, returning nil if
+// the block documents no route.
+func parseBlock(block string) (*Endpoint, error) {
+	var ep *Endpoint
+	var last *string // description continued by untagged lines.
+	for _, line := range strings.Split(block, "\n") {
+		line = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(line), "*"))
+		if line == "" {
+			continue
+		}
+		if !strings.HasPrefix(line, "@") {
+			if last != nil {
+				*last = strings.TrimSpace(*last + " " + line)
+			}
+			continue
+		}
+		tag, val := line, ""
+		if i := strings.IndexAny(line, " \t"); i >= 0 {
+			tag, val = line[:i], strings.TrimSpace(line[i+1:])
+		}
+		if tag == "@api" {
+			m := apiRegex.FindStringSubmatch(val)
+			if m == nil {
+				return nil, errors.Newf("malformed @api line '%s'", line)
+			}
+			ep = &Endpoint{Method: strings.ToLower(m[1]), Path: m[2], Title: m[3]}
+			last = nil
+			continue
+		}
+		if ep == nil {
+			continue
+		}
+		last = nil
+		var fields *[]Field
+		switch tag {
+		case "@apiName":
+			ep.Name = val
+		case "@apiGroup":
+			ep.Group = val
+		case "@apiVersion":
+			ep.Version = val
+		case "@apiDescription":
+			ep.Description = val
+			last = &ep.Description
+		case "@apiParam":
+			fields = &ep.Params
+		case "@apiHeader":
+			fields = &ep.Headers
+		case "@apiSuccess":
+			fields = &ep.Success
+		case "@apiError":
+			fields = &ep.Errors
+		}
+		if fields == nil {
+			continue
+		}
+		f, err := parseField(val)
+		if err != nil {
+			return nil, errors.Newf("%s: %v", line, err)
+		}
+		*fields = append(*fields, f)
+		last = &(*fields)[len(*fields)-1].Description
+	}
+	return ep, nil
+}
+
+func parseField(val string) (Field, error) {
+	m := fieldRegex.FindStringSubmatch(val)
+	if m == nil {
+		return Field{}, errors.New("malformed field")
+	}
+	f := Field{Group: m[1], Type: m[2], Name: m[3], Description: m[4]}
+	if strings.HasPrefix(f.Name, "[") {
+		f.Optional = true
+		f.Name = strings.TrimSuffix(strings.TrimPrefix(f.Name, "["), "]")
+	}
+	if i := strings.Index(f.Name, "="); i >= 0 {
+		f.Name, f.Default = f.Name[:i], f.Name[i+1:]
+	}
+	return f, nil
+}
+
+// Latest drops the older versions of endpoints that share an @apiName
+// (e.g. those archived in _apidoc.js), keeping the order of the rest.
+// Endpoints without a name are all kept.
+func Latest(eps []Endpoint) []Endpoint {
+	latest := make(map[string]int) // name => index in eps
+	for i, ep := range eps {
+		if ep.Name == "" {
+			continue
+		}
+		if j, ok := latest[ep.Name]; !ok || compareVersions(ep.Version, eps[j].Version) > 0 {
+			latest[ep.Name] = i
+		}
+	}
+	var res []Endpoint
+	for i, ep := range eps {
+		if j, ok := latest[ep.Name]; ep.Name == "" || (ok && i == j) {
+			res = append(res, ep)
+		}
+	}
+	return res
+}
+
+// Groups returns the names of the groups of eps sorted alphabetically.
+func Groups(eps []Endpoint) []string {
+	seen := make(map[string]bool)
+	var groups []string
+	for _, ep := range eps {
+		if !seen[ep.Group] {
+			seen[ep.Group] = true
+			groups = append(groups, ep.Group)
+		}
+	}
+	sort.Strings(groups)
+	return groups
+}
+
+// compareVersions compares the dot separated versions a and b numerically
+// e.g. 0.10.0 > 0.9.1, returning -1, 0 or 1.
+func compareVersions(a, b string) int {
+	as, bs := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(as) || i < len(bs); i++ {
+		var an, bn int
+		if i < len(as) {
+			an, _ = strconv.Atoi(as[i])
+		}
+		if i < len(bs) {
+			bn, _ = strconv.Atoi(bs[i])
+		}
+		switch {
+		case an < bn:
+			return -1
+		case an > bn:
+			return 1
+		}
+	}
+	return 0
+}
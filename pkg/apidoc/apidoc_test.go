@@ -0,0 +1,92 @@
+package apidoc_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/tomogoma/seedms/pkg/apidoc"
+)
+
+func TestParse(t *testing.T) {
+	src := `package http
+
+/**
+ * A block without an @api line is ignored.
+ */
+
+/**
+ * @api {GET} /users/:userID/items?cursor=:cursor List Items
+ * @apiName ListItems
+ * @apiVersion 0.1.0
+ * @apiGroup Items
+ * @apiDescription Pages through the items
+ * of a user.
+ *
+ * @apiHeader Authorization Bearer token
+ * @apiParam {Number{1-100}} [limit=20] Maximum number
+ *     of items.
+ * @apiSuccess (200) {Object[]} items Items in this page.
+ * @apiError (404) {String} reason Why.
+ */
+func handleListItems() {}
+`
+	expEPs := []apidoc.Endpoint{{
+		Method:      "get",
+		Path:        "/users/:userID/items?cursor=:cursor",
+		Title:       "List Items",
+		Name:        "ListItems",
+		Group:       "Items",
+		Version:     "0.1.0",
+		Description: "Pages through the items of a user.",
+		Headers:     []apidoc.Field{{Name: "Authorization", Description: "Bearer token"}},
+		Params: []apidoc.Field{{Type: "Number{1-100}", Name: "limit", Optional: true,
+			Default: "20", Description: "Maximum number of items."}},
+		Success: []apidoc.Field{{Group: "200", Type: "Object[]", Name: "items",
+			Description: "Items in this page."}},
+		Errors: []apidoc.Field{{Group: "404", Type: "String", Name: "reason", Description: "Why."}},
+	}}
+	eps, err := apidoc.Parse([]byte(src))
+	if err != nil {
+		t.Fatalf("Got error: %v", err)
+	}
+	if !reflect.DeepEqual(eps, expEPs) {
+		t.Errorf("Expected:\n%+v\ngot:\n%+v", expEPs, eps)
+	}
+}
+
+func TestParse_errors(t *testing.T) {
+	tt := []struct {
+		name string
+		src  string
+	}{
+		{name: "malformed @api", src: "/**\n * @api get /status\n */"},
+		{name: "empty field", src: "/**\n * @api {get} /status Status\n * @apiParam\n */"},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := apidoc.Parse([]byte(tc.src)); err == nil {
+				t.Errorf("Expected an error, got nil")
+			}
+		})
+	}
+}
+
+func TestLatest(t *testing.T) {
+	eps := []apidoc.Endpoint{
+		{Name: "A", Version: "0.9.1"},
+		{Name: "B", Version: "0.1.0"},
+		{Name: "A", Version: "0.10.0"},
+		{Version: "0.1.0"},
+		{Name: "A", Version: "0.2.0"},
+		{Version: "0.2.0"},
+	}
+	exp := []apidoc.Endpoint{
+		{Name: "B", Version: "0.1.0"},
+		{Name: "A", Version: "0.10.0"},
+		{Version: "0.1.0"},
+		{Version: "0.2.0"},
+	}
+	if act := apidoc.Latest(eps); !reflect.DeepEqual(act, exp) {
+		t.Errorf("Expected %+v, got %+v", exp, act)
+	}
+}
@@ -0,0 +1,193 @@
+package apidoc
+
+import (
+	"html/template"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// Project describes the API the docs site is about.
+type Project struct {
+	Title       string
+	Version     string
+	Description string
+	// Header is markdown introducing the API, rendered above the routes
+	// under HeaderTitle.
+	HeaderTitle string
+	Header      string
+}
+
+// fieldTable is a titled table of fields e.g. "Success 200" fields.
+type fieldTable struct {
+	Title  string
+	Fields []Field
+}
+
+type endpointView struct {
+	Endpoint
+	Anchor string
+	Tables []fieldTable
+}
+
+type groupView struct {
+	Name      string
+	Anchor    string
+	Endpoints []endpointView
+}
+
+var (
+	anchorRegex = regexp.MustCompile(`[^A-Za-z0-9_-]+`)
+	pageTmpl    = template.Must(template.New("page").Funcs(template.FuncMap{
+		"upper": strings.ToUpper,
+	}).Parse(pageHTML))
+)
+
+// Render writes a self-contained HTML page (e.g. the docs dir's
+// index.html) documenting eps, grouped by their @apiGroup, to w.
+func Render(w io.Writer, p Project, eps []Endpoint) error {
+	var groups []groupView
+	for _, g := range Groups(eps) {
+		gv := groupView{Name: g, Anchor: anchor("api", g)}
+		for _, ep := range eps {
+			if ep.Group != g {
+				continue
+			}
+			gv.Endpoints = append(gv.Endpoints, endpointView{
+				Endpoint: ep,
+				Anchor:   anchor("api", g, ep.Name, ep.Method, ep.Path),
+				Tables:   fieldTables(ep),
+			})
+		}
+		groups = append(groups, gv)
+	}
+	return pageTmpl.Execute(w, struct {
+		Project
+		HeaderHTML template.HTML
+		Groups     []groupView
+	}{Project: p, HeaderHTML: markdownToHTML(p.Header), Groups: groups})
+}
+
+// fieldTables groups the fields of ep into tables titled the way apidoc
+// titles them e.g. "Parameter", "Success 200".
+func fieldTables(ep Endpoint) []fieldTable {
+	var tables []fieldTable
+	for _, fs := range []struct {
+		prefix, def string
+		fields      []Field
+	}{
+		{prefix: "", def: "Header", fields: ep.Headers},
+		{prefix: "", def: "Parameter", fields: ep.Params},
+		{prefix: "Success ", def: "Success 200", fields: ep.Success},
+		{prefix: "Error ", def: "Error 4xx", fields: ep.Errors},
+	} {
+		index := make(map[string]int)
+		for _, f := range fs.fields {
+			title := fs.def
+			if f.Group != "" {
+				title = fs.prefix + f.Group
+			}
+			i, ok := index[title]
+			if !ok {
+				i = len(tables)
+				index[title] = i
+				tables = append(tables, fieldTable{Title: title})
+			}
+			tables[i].Fields = append(tables[i].Fields, f)
+		}
+	}
+	return tables
+}
+
+func anchor(parts ...string) string {
+	for i, p := range parts {
+		parts[i] = strings.Trim(anchorRegex.ReplaceAllString(p, "_"), "_")
+	}
+	return strings.Join(parts, "-")
+}
+
+const pageHTML = `<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Title}}</title>
+<style>
+body{margin:0;font-family:-apple-system,"Segoe UI",Helvetica,Arial,sans-serif;color:#333;line-height:1.5}
+nav{position:fixed;top:0;bottom:0;left:0;width:260px;overflow-y:auto;background:#f5f5f5;border-right:1px solid #ddd;padding:1em}
+nav h2{font-size:1em;margin:1em 0 .3em}
+nav ul{list-style:none;margin:0;padding:0}
+nav a{color:#333;text-decoration:none;display:block;padding:.1em 0}
+nav a:hover{color:#0a6ebd}
+main{margin-left:292px;padding:1em 2em;max-width:960px}
+article{border-top:1px solid #ddd;padding:1em 0}
+.method{display:inline-block;min-width:4em;text-align:center;color:#fff;font-weight:bold;border-radius:3px;padding:0 .4em;margin-right:.5em;background:#777}
+.method-get{background:#0a6ebd}.method-post{background:#49a032}.method-put{background:#c67f0a}.method-patch{background:#8a50b5}.method-delete{background:#c3342a}
+pre,code{font-family:Menlo,Consolas,monospace;font-size:.9em;background:#f5f5f5}
+pre{padding:.8em;overflow-x:auto}
+table{border-collapse:collapse;width:100%;margin-bottom:1em}
+th,td{border:1px solid #ddd;padding:.3em .6em;text-align:left;vertical-align:top}
+th{background:#f5f5f5}
+.optional{color:#888;font-size:.85em}
+.type{color:#555;font-family:Menlo,Consolas,monospace;font-size:.85em}
+</style>
+</head>
+<body>
+<nav>
+<strong>{{.Title}}</strong> <small>{{.Version}}</small>
+{{- if .Header}}
+<h2><a href="#api-intro">{{.HeaderTitle}}</a></h2>
+{{- end}}
+{{- range .Groups}}
+<h2><a href="#{{.Anchor}}">{{.Name}}</a></h2>
+<ul>
+{{- range .Endpoints}}
+<li><a href="#{{.Anchor}}">{{.Title}}</a></li>
+{{- end}}
+</ul>
+{{- end}}
+</nav>
+<main>
+<h1>{{.Title}} <small>{{.Version}}</small></h1>
+{{- if .Description}}
+<p>{{.Description}}</p>
+{{- end}}
+{{- if .Header}}
+<section id="api-intro">
+<h1>{{.HeaderTitle}}</h1>
+{{.HeaderHTML}}
+</section>
+{{- end}}
+{{- range .Groups}}
+<section id="{{.Anchor}}">
+<h1>{{.Name}}</h1>
+{{- range .Endpoints}}
+<article id="{{.Anchor}}">
+<h2>{{.Title}}</h2>
+{{- if .Description}}
+<p>{{.Description}}</p>
+{{- end}}
+<pre><span class="method method-{{.Method}}">{{upper .Method}}</span>{{.Path}}</pre>
+{{- range .Tables}}
+<h3>{{.Title}}</h3>
+<table>
+<thead><tr><th>Field</th><th>Type</th><th>Description</th></tr></thead>
+<tbody>
+{{- range .Fields}}
+<tr>
+<td>{{.Name}}{{if .Optional}} <span class="optional">optional</span>{{end}}</td>
+<td class="type">{{.Type}}</td>
+<td>{{.Description}}{{if .Default}}<br>Default value: <code>{{.Default}}</code>{{end}}</td>
+</tr>
+{{- end}}
+</tbody>
+</table>
+{{- end}}
+</article>
+{{- end}}
+</section>
+{{- end}}
+</main>
+</body>
+</html>
+`
@@ -0,0 +1,50 @@
+package apidoc_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/tomogoma/seedms/pkg/apidoc"
+)
+
+func TestRender(t *testing.T) {
+	p := apidoc.Project{
+		Title:       "seedmsv0",
+		Version:     "0.1.0",
+		HeaderTitle: "Introduction",
+		Header:      "## Usage\n\nPrefix `/v0/seedms` to\nall paths:\n```\n<b>curl</b>\n```\n- one\n- two",
+	}
+	eps := []apidoc.Endpoint{
+		{Method: "get", Path: "/status", Title: "Status", Name: "Status", Group: "Service",
+			Success: []apidoc.Field{{Group: "200", Type: "String", Name: "name", Description: "<i>Name</i>."}}},
+		{Method: "delete", Path: "/items/:itemID", Title: "Delete Item", Name: "DeleteItem", Group: "Items",
+			Params: []apidoc.Field{{Type: "String", Name: "itemID", Optional: true, Default: "x"}}},
+	}
+	var buf bytes.Buffer
+	if err := apidoc.Render(&buf, p, eps); err != nil {
+		t.Fatalf("Got error: %v", err)
+	}
+	page := buf.String()
+	for _, exp := range []string{
+		"<title>seedmsv0</title>",
+		"<h2>Usage</h2>",
+		"<p>Prefix <code>/v0/seedms</code> to all paths:</p>",
+		"<pre><code>&lt;b&gt;curl&lt;/b&gt;\n</code></pre>",
+		"<ul>\n<li>one</li>\n<li>two</li>\n</ul>",
+		`<a href="#api-Service-Status-get-status">Status</a>`,
+		`<span class="method method-delete">DELETE</span>/items/:itemID`,
+		"<h3>Success 200</h3>",
+		"<h3>Parameter</h3>",
+		"&lt;i&gt;Name&lt;/i&gt;.",
+		`<span class="optional">optional</span>`,
+		"Default value: <code>x</code>",
+	} {
+		if !strings.Contains(page, exp) {
+			t.Errorf("Expected page to contain %q, got:\n%s", exp, page)
+		}
+	}
+	if strings.Index(page, `id="api-Items"`) > strings.Index(page, `id="api-Service"`) {
+		t.Errorf("Expected groups sorted by name")
+	}
+}
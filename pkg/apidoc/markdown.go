@@ -0,0 +1,86 @@
+package apidoc
+
+import (
+	"html/template"
+	"regexp"
+	"strings"
+)
+
+var (
+	headingRegex  = regexp.MustCompile(`^(#{1,6})\s+(.*)$`)
+	listItemRegex = regexp.MustCompile(`^(?:[-*+]|\d+\.)\s+(.*)$`)
+	codeSpanRegex = regexp.MustCompile("`([^`]+)`")
+)
+
+// markdownToHTML renders the subset of markdown used in doc headers:
+// headings, paragraphs, lists, fenced code blocks and code spans. Other
+// markup is escaped and rendered as text.
+func markdownToHTML(md string) template.HTML {
+	var b strings.Builder
+	var para []string
+	inList, inCode := false, false
+	flushPara := func() {
+		if len(para) > 0 {
+			b.WriteString("<p>" + inline(strings.Join(para, " ")) + "</p>\n")
+			para = nil
+		}
+	}
+	closeList := func() {
+		if inList {
+			b.WriteString("</ul>\n")
+			inList = false
+		}
+	}
+	for _, line := range strings.Split(md, "\n") {
+		if inCode {
+			if strings.HasPrefix(strings.TrimSpace(line), "```") {
+				b.WriteString("</code></pre>\n")
+				inCode = false
+				continue
+			}
+			b.WriteString(template.HTMLEscapeString(line) + "\n")
+			continue
+		}
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "```") {
+			flushPara()
+			closeList()
+			b.WriteString("<pre><code>")
+			inCode = true
+			continue
+		}
+		if trimmed == "" {
+			flushPara()
+			closeList()
+			continue
+		}
+		if m := headingRegex.FindStringSubmatch(trimmed); m != nil {
+			flushPara()
+			closeList()
+			lvl := string(rune('0' + len(m[1])))
+			b.WriteString("<h" + lvl + ">" + inline(m[2]) + "</h" + lvl + ">\n")
+			continue
+		}
+		if m := listItemRegex.FindStringSubmatch(trimmed); m != nil {
+			flushPara()
+			if !inList {
+				b.WriteString("<ul>\n")
+				inList = true
+			}
+			b.WriteString("<li>" + inline(m[1]) + "</li>\n")
+			continue
+		}
+		para = append(para, trimmed)
+	}
+	if inCode {
+		b.WriteString("</code></pre>\n")
+	}
+	flushPara()
+	closeList()
+	return template.HTML(b.String())
+}
+
+// inline escapes text and renders its code spans.
+func inline(text string) string {
+	return codeSpanRegex.ReplaceAllString(template.HTMLEscapeString(text), "<code>$1</code>")
+}
@@ -1,25 +1,40 @@
 package bootstrap
 
 import (
+	"context"
 	"io/ioutil"
 
 	"github.com/tomogoma/go-api-guard"
-	"github.com/tomogoma/jwt"
+	"github.com/tomogoma/go-typed-errors"
 	"github.com/tomogoma/seedms/pkg/config"
 	"github.com/tomogoma/seedms/pkg/db/roach"
+	"github.com/tomogoma/seedms/pkg/health"
+	"github.com/tomogoma/seedms/pkg/keycache"
 	"github.com/tomogoma/seedms/pkg/logging"
+	"github.com/tomogoma/seedms/pkg/metrics"
+	"github.com/tomogoma/seedms/pkg/ratelimit"
+	"github.com/tomogoma/seedms/pkg/tracing"
+	"github.com/tomogoma/seedms/pkg/verifier"
 	"github.com/tomogoma/crdb"
 )
 
 type Deps struct {
-	Config config.General
-	Guard  *api.Guard
-	Roach  *roach.Roach
-	JWTEr  *jwt.Handler
+	Config      config.General
+	Guard       *api.Guard
+	Roach       *roach.Roach
+	KeyCache    *keycache.Cache
+	JWTEr       *verifier.Verifier
+	RateLimiter *ratelimit.Limiter
+	// Metrics is nil if metrics are disabled in the config.
+	Metrics *metrics.Metrics
+	Tracing *tracing.Provider
+	// Health checks the DB and JWT key; callers register any further
+	// checks e.g. health.CheckRegistry.
+	Health *health.Checker
 }
 
-func InstantiateRoach(lg logging.Logger, conf crdb.Config) *roach.Roach {
-	var opts []roach.Option
+func InstantiateRoach(lg logging.Logger, conf crdb.Config, opts ...roach.Option) *roach.Roach {
+	opts = append(opts, roach.WithLogger(lg))
 	if dsn := conf.FormatDSN(); dsn != "" {
 		opts = append(opts, roach.WithDSN(dsn))
 	}
@@ -32,12 +47,107 @@ func InstantiateRoach(lg logging.Logger, conf crdb.Config) *roach.Roach {
 	return rdb
 }
 
-func InstantiateJWTHandler(lg logging.Logger, tknKyF string) *jwt.Handler {
-	JWTKey, err := ioutil.ReadFile(tknKyF)
-	logging.LogFatalOnError(lg, err, "Read JWT key file")
-	jwter, err := jwt.NewHandler(JWTKey)
-	logging.LogFatalOnError(lg, err, "Instantiate JWT handler")
-	return jwter
+// CheckSchemaDrift logs differences between the DB tables and the tables
+// expected by rdb. It exits the program if refuseDrift is true and
+// differences are found or the check itself fails.
+func CheckSchemaDrift(lg logging.Logger, rdb *roach.Roach, refuseDrift bool) {
+	const action = "Check DB schema drift"
+	diffs, err := rdb.SchemaDrift()
+	if err != nil {
+		if refuseDrift {
+			logging.LogFatalOnError(lg, err, action)
+		}
+		logging.LogWarnOnError(lg, err, action)
+		return
+	}
+	for _, diff := range diffs {
+		lg.WithField(logging.FieldAction, action).Warn(diff)
+	}
+	if refuseDrift && len(diffs) > 0 {
+		logging.LogFatalOnError(lg, errors.Newf("found %d schema"+
+			" differences", len(diffs)), action)
+	}
+}
+
+// InstantiateJWTVerifier creates a verifier for the JWTs produced by the
+// authentication micro-service using the algorithm and keys in conf.
+func InstantiateJWTVerifier(lg logging.Logger, conf config.Service) *verifier.Verifier {
+	alg := conf.AuthTokenAlg
+	if alg == "" {
+		alg = verifier.AlgHS256
+	}
+	var v *verifier.Verifier
+	var err error
+	switch {
+	case conf.AuthTokenJWKS != "":
+		var opts []verifier.JWKSOption
+		if conf.AuthTokenJWKSRefresh > 0 {
+			opts = append(opts, verifier.WithJWKSRefreshInterval(conf.AuthTokenJWKSRefresh))
+		}
+		v, err = verifier.NewJWKSVerifier(alg, conf.AuthTokenJWKS, opts...)
+	case alg == verifier.AlgHS256:
+		var key []byte
+		key, err = ioutil.ReadFile(conf.AuthTokenKeyFile)
+		logging.LogFatalOnError(lg, err, "Read JWT key file")
+		v, err = verifier.NewHMAC(key)
+	default:
+		var pemData []byte
+		pemData, err = ioutil.ReadFile(conf.AuthTokenKeyFile)
+		logging.LogFatalOnError(lg, err, "Read JWT PEM key file")
+		v, err = verifier.NewPEM(alg, pemData)
+	}
+	logging.LogFatalOnError(lg, err, "Instantiate JWT verifier")
+	return v
+}
+
+// InstantiateRateLimiter creates an in-memory rate limiter applying the
+// per route limits in conf.
+func InstantiateRateLimiter(lg logging.Logger, conf config.Service) *ratelimit.Limiter {
+	limits := make(map[string]ratelimit.Limit)
+	for route, rl := range conf.RateLimits {
+		if rl.Requests < 1 || rl.Per <= 0 {
+			logging.LogFatalOnError(lg, errors.Newf("rate limit for route"+
+				" '%s' needs requests and per values", route), "Read rate limits")
+		}
+		limits[route] = ratelimit.PerDuration(rl.Requests, rl.Per, rl.Burst)
+	}
+	rl, err := ratelimit.NewLimiter(ratelimit.NewMemoryStore(), limits)
+	logging.LogFatalOnError(lg, err, "Instantiate rate limiter")
+	return rl
+}
+
+// InstantiateKeyCache creates a cache of rdb's API key validation results.
+func InstantiateKeyCache(lg logging.Logger, rdb *roach.Roach, conf config.APIKeyCache) *keycache.Cache {
+	var opts []keycache.Option
+	if conf.Size > 0 {
+		opts = append(opts, keycache.WithSize(conf.Size))
+	}
+	if conf.TTL > 0 {
+		opts = append(opts, keycache.WithTTL(conf.TTL))
+	}
+	if conf.NegativeTTL > 0 {
+		opts = append(opts, keycache.WithNegativeTTL(conf.NegativeTTL))
+	}
+	kc, err := keycache.NewCache(rdb, opts...)
+	logging.LogFatalOnError(lg, err, "Instantiate API key cache")
+	return kc
+}
+
+// InstantiateTracing creates the trace provider configured in conf.
+func InstantiateTracing(lg logging.Logger, conf config.Tracing) *tracing.Provider {
+	tp, err := tracing.New(conf)
+	logging.LogFatalOnError(lg, err, "Instantiate tracing")
+	return tp
+}
+
+// InstantiateHealth creates a readiness checker of rdb's connectivity and
+// schema version and of v's keys.
+func InstantiateHealth(lg logging.Logger, rdb *roach.Roach, v *verifier.Verifier) *health.Checker {
+	hc, err := health.NewChecker()
+	logging.LogFatalOnError(lg, err, "Instantiate health checker")
+	hc.Register(health.CheckDB, rdb.Ready)
+	hc.Register(health.CheckJWTKey, func(context.Context) error { return v.Ready() })
+	return hc
 }
 
 func Instantiate(confFile string, lg logging.Logger) Deps {
@@ -45,11 +155,28 @@ func Instantiate(confFile string, lg logging.Logger) Deps {
 	conf, err := config.ReadFile(confFile)
 	logging.LogFatalOnError(lg, err, "Read config file")
 
-	rdb := InstantiateRoach(lg, conf.Database)
-	tg := InstantiateJWTHandler(lg, conf.Service.AuthTokenKeyFile)
+	tp := InstantiateTracing(lg, conf.Service.Tracing)
+	rOpts := []roach.Option{roach.WithTracerProvider(tp)}
+	var m *metrics.Metrics
+	if conf.Service.Metrics.Enabled {
+		m = metrics.New()
+		rOpts = append(rOpts, roach.WithQueryObserver(m.ObserveDBQuery))
+	}
+
+	rdb := InstantiateRoach(lg, conf.Database, rOpts...)
+	CheckSchemaDrift(lg, rdb, conf.Service.RefuseSchemaDrift)
+	tg := InstantiateJWTVerifier(lg, conf.Service)
 
-	g, err := api.NewGuard(rdb, api.WithMasterKey(conf.Service.MasterAPIKey))
+	kc := InstantiateKeyCache(lg, rdb, conf.Service.APIKeyCache)
+	if m != nil {
+		m.RegisterKeyCache(kc)
+	}
+	g, err := api.NewGuard(kc, api.WithMasterKey(conf.Service.MasterAPIKey))
 	logging.LogFatalOnError(lg, err, "Instantate API access guard")
 
-	return Deps{Config: conf, Guard: g, Roach: rdb, JWTEr: tg}
+	rl := InstantiateRateLimiter(lg, conf.Service)
+	hc := InstantiateHealth(lg, rdb, tg)
+
+	return Deps{Config: conf, Guard: g, Roach: rdb, KeyCache: kc, JWTEr: tg,
+		RateLimiter: rl, Metrics: m, Tracing: tp, Health: hc}
 }
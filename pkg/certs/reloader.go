@@ -0,0 +1,131 @@
+// Package certs loads the TLS certificates the micro-service's servers
+// present to clients.
+package certs
+
+import (
+	"crypto/tls"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/tomogoma/go-typed-errors"
+	"github.com/tomogoma/seedms/pkg/logging"
+)
+
+// DefaultCheckInterval is how often a Reloader checks its files for
+// changes by default.
+const DefaultCheckInterval = 10 * time.Second
+
+// Reloader serves a certificate and private key loaded from PEM files and
+// reloads them when either file changes, e.g. on certificate renewal,
+// without restarting the server. Files are checked for changes during TLS
+// handshakes at most once every check interval. If reloading fails, e.g.
+// when only one of the files has been replaced so far, the previous
+// certificate is served until the next check.
+// Use NewReloader() to instantiate.
+type Reloader struct {
+	certFile string
+	keyFile  string
+	interval time.Duration
+	logger   logging.Logger
+	now      func() time.Time
+
+	mu        sync.Mutex
+	cert      *tls.Certificate
+	certMod   time.Time
+	keyMod    time.Time
+	lastCheck time.Time
+}
+
+// ReloaderOption allows extra configuration for NewReloader. Use the
+// With... functions to set options.
+type ReloaderOption func(*Reloader)
+
+// WithCheckInterval sets how often files are checked for changes.
+// Defaults to DefaultCheckInterval.
+func WithCheckInterval(d time.Duration) ReloaderOption {
+	return func(r *Reloader) {
+		r.interval = d
+	}
+}
+
+// NewReloader loads the certificate in certFile and key in keyFile.
+func NewReloader(certFile, keyFile string, lg logging.Logger, opts ...ReloaderOption) (*Reloader, error) {
+	if certFile == "" {
+		return nil, errors.New("certificate file was empty")
+	}
+	if keyFile == "" {
+		return nil, errors.New("key file was empty")
+	}
+	if lg == nil {
+		return nil, errors.New("Logger was nil")
+	}
+	r := &Reloader{
+		certFile: certFile,
+		keyFile:  keyFile,
+		interval: DefaultCheckInterval,
+		logger:   lg,
+		now:      time.Now,
+	}
+	for _, f := range opts {
+		f(r)
+	}
+	if err := r.reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// GetCertificate returns the current certificate. It is meant for use as
+// tls.Config.GetCertificate.
+func (r *Reloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if now := r.now(); now.Sub(r.lastCheck) >= r.interval {
+		r.lastCheck = now
+		r.reloadIfChanged()
+	}
+	return r.cert, nil
+}
+
+func (r *Reloader) reloadIfChanged() {
+	log := r.logger.WithField(logging.FieldAction, "Reload TLS certificate")
+	certMod, keyMod, err := r.modTimes()
+	if err == nil && certMod.Equal(r.certMod) && keyMod.Equal(r.keyMod) {
+		return
+	}
+	if err == nil {
+		err = r.reload()
+	}
+	if err != nil {
+		log.Warnf("keep serving the previous certificate: %v", err)
+		return
+	}
+	log.Infof("reloaded %s", r.certFile)
+}
+
+func (r *Reloader) reload() error {
+	certMod, keyMod, err := r.modTimes()
+	if err != nil {
+		return err
+	}
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return errors.Newf("load TLS certificate: %v", err)
+	}
+	r.cert = &cert
+	r.certMod, r.keyMod = certMod, keyMod
+	return nil
+}
+
+func (r *Reloader) modTimes() (time.Time, time.Time, error) {
+	certInfo, err := os.Stat(r.certFile)
+	if err != nil {
+		return time.Time{}, time.Time{}, errors.Newf("stat certificate file: %v", err)
+	}
+	keyInfo, err := os.Stat(r.keyFile)
+	if err != nil {
+		return time.Time{}, time.Time{}, errors.Newf("stat key file: %v", err)
+	}
+	return certInfo.ModTime(), keyInfo.ModTime(), nil
+}
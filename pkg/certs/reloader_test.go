@@ -0,0 +1,158 @@
+package certs
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/tomogoma/seedms/pkg/logging"
+	"github.com/tomogoma/seedms/pkg/mocks"
+)
+
+func TestNewReloader(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := writeCert(t, dir, "valid")
+	tt := []struct {
+		name     string
+		certFile string
+		keyFile  string
+		logger   logging.Logger
+		expErr   bool
+	}{
+		{name: "valid", certFile: certFile, keyFile: keyFile, logger: &mocks.Logger{}},
+		{name: "empty cert file", keyFile: keyFile, logger: &mocks.Logger{}, expErr: true},
+		{name: "empty key file", certFile: certFile, logger: &mocks.Logger{}, expErr: true},
+		{name: "nil logger", certFile: certFile, keyFile: keyFile, expErr: true},
+		{name: "missing file", certFile: filepath.Join(dir, "none.pem"), keyFile: keyFile,
+			logger: &mocks.Logger{}, expErr: true},
+		{name: "mismatched files", certFile: keyFile, keyFile: certFile, logger: &mocks.Logger{}, expErr: true},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			r, err := NewReloader(tc.certFile, tc.keyFile, tc.logger)
+			if tc.expErr {
+				if err == nil {
+					t.Fatal("Expected an error but got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Got error: %v", err)
+			}
+			if r == nil {
+				t.Fatal("Got nil *Reloader")
+			}
+		})
+	}
+}
+
+func TestReloader_GetCertificate(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := writeCert(t, dir, "first")
+	now := time.Now()
+	r, err := NewReloader(certFile, keyFile, &mocks.Logger{}, WithCheckInterval(time.Minute))
+	if err != nil {
+		t.Fatalf("NewReloader(): %v", err)
+	}
+	r.now = func() time.Time { return now }
+	r.lastCheck = now
+
+	steps := []struct {
+		name      string
+		change    func()
+		advance   time.Duration
+		expCommon string
+	}{
+		{name: "unchanged", expCommon: "first"},
+		{name: "changed before check interval", change: func() {
+			writeCert(t, dir, "second")
+			touch(t, now.Add(time.Second), certFile, keyFile)
+		}, advance: time.Second, expCommon: "first"},
+		{name: "changed after check interval", advance: time.Minute, expCommon: "second"},
+		{name: "invalid change keeps previous", change: func() {
+			if err := ioutil.WriteFile(keyFile, []byte("not a key"), 0600); err != nil {
+				t.Fatalf("Error setting up: write key: %v", err)
+			}
+			touch(t, now.Add(time.Hour), keyFile)
+		}, advance: time.Minute, expCommon: "second"},
+		{name: "fixed change", change: func() {
+			writeCert(t, dir, "third")
+			touch(t, now.Add(2*time.Hour), certFile, keyFile)
+		}, advance: time.Minute, expCommon: "third"},
+	}
+	for _, step := range steps {
+		if step.change != nil {
+			step.change()
+		}
+		now = now.Add(step.advance)
+		cert, err := r.GetCertificate(nil)
+		if err != nil {
+			t.Fatalf("%s: GetCertificate(): %v", step.name, err)
+		}
+		leaf, err := x509.ParseCertificate(cert.Certificate[0])
+		if err != nil {
+			t.Fatalf("%s: parse certificate: %v", step.name, err)
+		}
+		if leaf.Subject.CommonName != step.expCommon {
+			t.Errorf("%s: expected certificate %s, got %s",
+				step.name, step.expCommon, leaf.Subject.CommonName)
+		}
+	}
+}
+
+// writeCert writes a self-signed certificate for commonName and its key to
+// cert.pem and key.pem in dir.
+func writeCert(t *testing.T, dir, commonName string) (string, string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Error setting up: generate key: %v", err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("Error setting up: create certificate: %v", err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("Error setting up: marshal key: %v", err)
+	}
+	certFile, keyFile := filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	err = ioutil.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0644)
+	if err != nil {
+		t.Fatalf("Error setting up: write certificate: %v", err)
+	}
+	err = ioutil.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600)
+	if err != nil {
+		t.Fatalf("Error setting up: write key: %v", err)
+	}
+	if _, err := tls.LoadX509KeyPair(certFile, keyFile); err != nil {
+		t.Fatalf("Error setting up: load key pair: %v", err)
+	}
+	return certFile, keyFile
+}
+
+// touch sets the modification time of files to mod so that changes are
+// detected regardless of the file system's timestamp resolution.
+func touch(t *testing.T, mod time.Time, files ...string) {
+	for _, f := range files {
+		if err := os.Chtimes(f, mod, mod); err != nil {
+			t.Fatalf("Error setting up: touch %s: %v", f, err)
+		}
+	}
+}
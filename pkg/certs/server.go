@@ -0,0 +1,53 @@
+package certs
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"io/ioutil"
+
+	"github.com/tomogoma/go-typed-errors"
+	"github.com/tomogoma/seedms/pkg/config"
+	"github.com/tomogoma/seedms/pkg/logging"
+)
+
+// NewServerConfig returns the tls.Config of a server presenting the
+// certificate in conf, reloaded when its files change (see Reloader).
+// If conf.ClientCAFile is set, client certificates are verified against
+// the CAs in it (mutual TLS) and, if conf.RequireClientCert is set,
+// clients without one are rejected.
+func NewServerConfig(conf config.TLS, lg logging.Logger) (*tls.Config, error) {
+	r, err := NewReloader(conf.CertFile, conf.KeyFile, lg)
+	if err != nil {
+		return nil, err
+	}
+	tlsConf := &tls.Config{GetCertificate: r.GetCertificate, MinVersion: tls.VersionTLS12}
+	if conf.ClientCAFile == "" {
+		if conf.RequireClientCert {
+			return nil, errors.New("a client CA file is required to require client certificates")
+		}
+		return tlsConf, nil
+	}
+	pemCerts, err := ioutil.ReadFile(conf.ClientCAFile)
+	if err != nil {
+		return nil, errors.Newf("read client CA file: %v", err)
+	}
+	tlsConf.ClientCAs = x509.NewCertPool()
+	if !tlsConf.ClientCAs.AppendCertsFromPEM(pemCerts) {
+		return nil, errors.Newf("no PEM certificates found in client CA file (%s)", conf.ClientCAFile)
+	}
+	tlsConf.ClientAuth = tls.VerifyClientCertIfGiven
+	if conf.RequireClientCert {
+		tlsConf.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	return tlsConf, nil
+}
+
+// VerifiedSubject returns the subject of the client certificate verified
+// during the TLS handshake of cs. It is false if cs is nil (no TLS) or the
+// client presented no certificate.
+func VerifiedSubject(cs *tls.ConnectionState) (string, bool) {
+	if cs == nil || len(cs.VerifiedChains) == 0 || len(cs.VerifiedChains[0]) == 0 {
+		return "", false
+	}
+	return cs.VerifiedChains[0][0].Subject.String(), true
+}
@@ -0,0 +1,117 @@
+package certs
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"io/ioutil"
+	"net"
+	"path/filepath"
+	"testing"
+
+	"github.com/tomogoma/seedms/pkg/config"
+	"github.com/tomogoma/seedms/pkg/mocks"
+)
+
+func TestNewServerConfig(t *testing.T) {
+	dir := t.TempDir()
+	files := mocks.WriteTLSFiles(t, dir, "billing")
+	noCerts := filepath.Join(dir, "empty.pem")
+	if err := ioutil.WriteFile(noCerts, []byte("no certs here"), 0644); err != nil {
+		t.Fatalf("Error setting up: write file: %v", err)
+	}
+	tt := []struct {
+		name         string
+		conf         config.TLS
+		sendCert     bool
+		expErr       bool
+		expHandshake bool
+		expSubject   string
+	}{
+		{name: "server TLS only", conf: config.TLS{CertFile: files.ServerCertFile, KeyFile: files.ServerKeyFile},
+			sendCert: true, expHandshake: true},
+		{name: "client cert verified", conf: config.TLS{CertFile: files.ServerCertFile, KeyFile: files.ServerKeyFile,
+			ClientCAFile: files.CAFile}, sendCert: true, expHandshake: true, expSubject: "CN=billing,O=seedms"},
+		{name: "client cert optional", conf: config.TLS{CertFile: files.ServerCertFile, KeyFile: files.ServerKeyFile,
+			ClientCAFile: files.CAFile}, expHandshake: true},
+		{name: "client cert required", conf: config.TLS{CertFile: files.ServerCertFile, KeyFile: files.ServerKeyFile,
+			ClientCAFile: files.CAFile, RequireClientCert: true}, sendCert: true, expHandshake: true,
+			expSubject: "CN=billing,O=seedms"},
+		{name: "client cert missing", conf: config.TLS{CertFile: files.ServerCertFile, KeyFile: files.ServerKeyFile,
+			ClientCAFile: files.CAFile, RequireClientCert: true}},
+		{name: "required without CA", conf: config.TLS{CertFile: files.ServerCertFile, KeyFile: files.ServerKeyFile,
+			RequireClientCert: true}, expErr: true},
+		{name: "missing CA file", conf: config.TLS{CertFile: files.ServerCertFile, KeyFile: files.ServerKeyFile,
+			ClientCAFile: filepath.Join(dir, "none.pem")}, expErr: true},
+		{name: "CA file without certs", conf: config.TLS{CertFile: files.ServerCertFile, KeyFile: files.ServerKeyFile,
+			ClientCAFile: noCerts}, expErr: true},
+		{name: "missing cert file", conf: config.TLS{KeyFile: files.ServerKeyFile}, expErr: true},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			srvConf, err := NewServerConfig(tc.conf, &mocks.Logger{})
+			if tc.expErr {
+				if err == nil {
+					t.Fatal("Expected an error but got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Got error: %v", err)
+			}
+
+			clConf := &tls.Config{RootCAs: x509.NewCertPool(), ServerName: "localhost"}
+			caPEM, err := ioutil.ReadFile(files.CAFile)
+			if err != nil {
+				t.Fatalf("Error setting up: read CA file: %v", err)
+			}
+			clConf.RootCAs.AppendCertsFromPEM(caPEM)
+			if tc.sendCert {
+				clCert, err := tls.LoadX509KeyPair(files.ClientCertFile, files.ClientKeyFile)
+				if err != nil {
+					t.Fatalf("Error setting up: load client cert: %v", err)
+				}
+				clConf.Certificates = []tls.Certificate{clCert}
+			}
+
+			srvConn, clConn := net.Pipe()
+			defer srvConn.Close()
+			defer clConn.Close()
+			srv, cl := tls.Server(srvConn, srvConf), tls.Client(clConn, clConf)
+			clErrCh := make(chan error, 1)
+			go func() {
+				err := cl.Handshake()
+				if err == nil {
+					// TLS 1.3 clients learn of rejected certificates on read.
+					_, err = cl.Read(make([]byte, 1))
+				}
+				clErrCh <- err
+			}()
+			srvErr := srv.Handshake()
+			if srvErr == nil {
+				_, srvErr = srv.Write([]byte("k"))
+			}
+			clErr := <-clErrCh
+
+			if !tc.expHandshake {
+				if srvErr == nil {
+					t.Fatal("Expected the handshake to fail but it succeeded")
+				}
+				return
+			}
+			if srvErr != nil || clErr != nil {
+				t.Fatalf("Handshake errors: server: %v, client: %v", srvErr, clErr)
+			}
+			cs := srv.ConnectionState()
+			subject, ok := VerifiedSubject(&cs)
+			if ok != (tc.expSubject != "") || subject != tc.expSubject {
+				t.Errorf("Expected subject '%s', got '%s' (%t)", tc.expSubject, subject, ok)
+			}
+		})
+	}
+}
+
+func TestVerifiedSubject_noTLS(t *testing.T) {
+	if subject, ok := VerifiedSubject(nil); ok || subject != "" {
+		t.Errorf("Expected no subject, got '%s' (%t)", subject, ok)
+	}
+}
@@ -20,7 +20,10 @@ const (
 
 	RPCNamePrefix = ""
 
-	DocsPath = "docs"
+	DocsPath    = "docs"
+	MetricsPath = "metrics"
+	HealthzPath = "healthz"
+	ReadyzPath  = "readyz"
 )
 
 var (
@@ -10,12 +10,110 @@ import (
 )
 
 type Service struct {
-	RegisterInterval   time.Duration `json:"registerInterval,omitempty" yaml:"registerInterval"`
-	LoadBalanceVersion string        `json:"loadBalanceVersion,omitempty" yaml:"loadBalanceVersion"`
-	MasterAPIKey       string        `json:"masterAPIKey,omitempty" yaml:"masterAPIKey"`
-	AllowedOrigins     []string      `json:"allowedOrigins" yaml:"allowedOrigins"`
-	AuthTokenKeyFile   string        `json:"authTokenKeyFile" yaml:"authTokenKeyFile"`
-	DocsDir            string        `json:"docsDir" yaml:"docsDir"`
+	RegisterInterval     time.Duration        `json:"registerInterval,omitempty" yaml:"registerInterval"`
+	ShutdownTimeout      time.Duration        `json:"shutdownTimeout,omitempty" yaml:"shutdownTimeout"`
+	LoadBalanceVersion   string               `json:"loadBalanceVersion,omitempty" yaml:"loadBalanceVersion"`
+	MasterAPIKey         string               `json:"masterAPIKey,omitempty" yaml:"masterAPIKey"`
+	AllowedOrigins       []string             `json:"allowedOrigins" yaml:"allowedOrigins"`
+	AuthTokenKeyFile     string               `json:"authTokenKeyFile" yaml:"authTokenKeyFile"`
+	AuthTokenAlg         string               `json:"authTokenAlgorithm" yaml:"authTokenAlgorithm"`
+	AuthTokenJWKS        string               `json:"authTokenJWKS" yaml:"authTokenJWKS"`
+	AuthTokenJWKSRefresh time.Duration        `json:"authTokenJWKSRefresh" yaml:"authTokenJWKSRefresh"`
+	DocsDir              string               `json:"docsDir" yaml:"docsDir"`
+	RefuseSchemaDrift    bool                 `json:"refuseSchemaDrift" yaml:"refuseSchemaDrift"`
+	RateLimits           map[string]RateLimit `json:"rateLimits" yaml:"rateLimits"`
+	APIKeyCache          APIKeyCache          `json:"apiKeyCache" yaml:"apiKeyCache"`
+	Metrics              Metrics              `json:"metrics" yaml:"metrics"`
+	Tracing              Tracing              `json:"tracing" yaml:"tracing"`
+	RequestIDHeader      string               `json:"requestIDHeader" yaml:"requestIDHeader"`
+	MaxBodyBytes         int64                `json:"maxBodyBytes" yaml:"maxBodyBytes"`
+	RPC                  RPC                  `json:"rpc" yaml:"rpc"`
+	Registry             Registry             `json:"registry" yaml:"registry"`
+	HTTP                 HTTP                 `json:"http" yaml:"http"`
+}
+
+// HTTP servers the HTTP API can be served on.
+const (
+	HTTPServerMicro      = "micro"
+	HTTPServerStandalone = "standalone"
+)
+
+// HTTP configures the server the HTTP API is served on.
+type HTTP struct {
+	Server         string        `json:"server" yaml:"server"`
+	Address        string        `json:"address" yaml:"address"`
+	ReadTimeout    time.Duration `json:"readTimeout" yaml:"readTimeout"`
+	WriteTimeout   time.Duration `json:"writeTimeout" yaml:"writeTimeout"`
+	IdleTimeout    time.Duration `json:"idleTimeout" yaml:"idleTimeout"`
+	MaxHeaderBytes int           `json:"maxHeaderBytes" yaml:"maxHeaderBytes"`
+	TLS            TLS           `json:"tls" yaml:"tls"`
+	Register       bool          `json:"register" yaml:"register"`
+}
+
+// Registry configures the registry the go-micro services are registered
+// with and discovered through.
+type Registry struct {
+	Backend      string        `json:"backend" yaml:"backend"`
+	Addresses    []string      `json:"addresses" yaml:"addresses"`
+	File         string        `json:"file" yaml:"file"`
+	PollInterval time.Duration `json:"pollInterval" yaml:"pollInterval"`
+}
+
+// RPC servers the RPC services can be served on.
+const (
+	RPCServerMicro = "micro"
+	RPCServerGRPC  = "grpc"
+)
+
+// RPC configures the server the RPC services are served on.
+type RPC struct {
+	Server     string `json:"server" yaml:"server"`
+	Address    string `json:"address" yaml:"address"`
+	TLS        TLS    `json:"tls" yaml:"tls"`
+	Reflection bool   `json:"reflection" yaml:"reflection"`
+}
+
+// TLS locates the PEM encoded certificate (chain) and private key a server
+// presents to clients and, for mutual TLS, the CA certificates client
+// certificates are verified against.
+type TLS struct {
+	CertFile          string `json:"certFile" yaml:"certFile"`
+	KeyFile           string `json:"keyFile" yaml:"keyFile"`
+	ClientCAFile      string `json:"clientCAFile" yaml:"clientCAFile"`
+	RequireClientCert bool   `json:"requireClientCert" yaml:"requireClientCert"`
+}
+
+// Tracing configures the export of OpenTelemetry spans.
+type Tracing struct {
+	Exporter    string  `json:"exporter" yaml:"exporter"`
+	Endpoint    string  `json:"endpoint" yaml:"endpoint"`
+	Insecure    bool    `json:"insecure" yaml:"insecure"`
+	File        string  `json:"file" yaml:"file"`
+	// SampleRatio is nil if unset, so that it can default to 1 while 0
+	// samples no new traces.
+	SampleRatio *float64 `json:"sampleRatio" yaml:"sampleRatio"`
+}
+
+// Metrics configures the Prometheus metrics endpoint.
+type Metrics struct {
+	Enabled bool `json:"enabled" yaml:"enabled"`
+	Guarded bool `json:"guarded" yaml:"guarded"`
+}
+
+// APIKeyCache configures the in-memory cache of API key validation
+// results. Zero values select the defaults.
+type APIKeyCache struct {
+	Size        int           `json:"size" yaml:"size"`
+	TTL         time.Duration `json:"ttl" yaml:"ttl"`
+	NegativeTTL time.Duration `json:"negativeTTL" yaml:"negativeTTL"`
+}
+
+// RateLimit allows Requests requests every Per duration with bursts of up
+// to Burst requests.
+type RateLimit struct {
+	Requests int           `json:"requests" yaml:"requests"`
+	Per      time.Duration `json:"per" yaml:"per"`
+	Burst    int           `json:"burst" yaml:"burst"`
 }
 
 type General struct {
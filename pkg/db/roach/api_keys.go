@@ -1,6 +1,7 @@
 package roach
 
 import (
+	"context"
 	"database/sql"
 
 	apiG "github.com/tomogoma/go-api-guard"
@@ -9,6 +10,7 @@ import (
 )
 
 // InsertAPIKey inserts an API key for the userID.
+// A client error is returned if the userID or key are invalid.
 func (r *Roach) InsertAPIKey(userID string, key []byte) (apiG.Key, error) {
 	if err := r.InitDBIfNot(); err != nil {
 		return nil, err
@@ -20,14 +22,17 @@ func (r *Roach) InsertAPIKey(userID string, key []byte) (apiG.Key, error) {
 		INSERT INTO ` + TblAPIKeys + ` (` + insCols + `)
 			VALUES ($1, $2, CURRENT_TIMESTAMP)
 			RETURNING ` + retCols
-	err := r.db.QueryRow(q, userID, key).Scan(&k.ID, &k.Created, &k.LastUpdated)
+	ctx, done := r.instrument(context.Background(), "InsertAPIKey")
+	err := r.db.QueryRowContext(ctx, q, userID, key).Scan(&k.ID, &k.Created, &k.LastUpdated)
+	done(err)
 	if err != nil {
-		return nil, err
+		return nil, translateError(err)
 	}
 	return k, nil
 }
 
 // APIKeyByUserIDVal returns API keys for the provided userID/key combination.
+// Deleted API keys are not returned.
 func (r *Roach) APIKeyByUserIDVal(userID string, key []byte) (apiG.Key, error) {
 	if err := r.InitDBIfNot(); err != nil {
 		return nil, err
@@ -36,15 +41,90 @@ func (r *Roach) APIKeyByUserIDVal(userID string, key []byte) (apiG.Key, error) {
 	q := `
 	SELECT ` + cols + `
 		FROM ` + TblAPIKeys + `
-		WHERE ` + ColUserID + `=$1 AND ` + ColKey + `=$2`
+		WHERE ` + ColUserID + `=$1 AND ` + ColKey + `=$2
+			AND ` + ColDeleteDate + ` IS NULL`
 	k := api.Key{}
-	err := r.db.QueryRow(q, userID, key).
+	ctx, done := r.instrument(context.Background(), "APIKeyByUserIDVal")
+	err := r.db.QueryRowContext(ctx, q, userID, key).
 		Scan(&k.ID, &k.UserID, &k.Val, &k.Created, &k.LastUpdated)
+	done(err)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, errors.NewNotFound("API key not found")
 		}
-		return nil, err
+		return nil, translateError(err)
 	}
 	return k, nil
 }
+
+// APIKeysByUserID returns a page of at most limit (none deleted) API keys
+// belonging to userID in order of ID, starting after cursor.
+// The returned cursor fetches the next page and is empty on the last page.
+// A user without (further) API keys gets an empty page.
+func (r *Roach) APIKeysByUserID(ctx context.Context, userID, cursor string, limit int) ([]api.Key, string, error) {
+	if err := r.InitDBIfNot(); err != nil {
+		return nil, "", err
+	}
+	lq := ListQuery{
+		Table:         TblAPIKeys,
+		Cols:          []string{ColID, ColUserID, ColKey, ColCreateDate, ColUpdateDate},
+		KeyCols:       []string{ColID},
+		Filters:       []Filter{{Col: ColUserID, Op: OpEq, Val: userID}},
+		SoftDeleteCol: ColDeleteDate,
+		Cursor:        cursor,
+		Limit:         limit,
+	}
+	q, args, err := lq.Build()
+	if err != nil {
+		return nil, "", err
+	}
+	ctx, done := r.instrument(ctx, "APIKeysByUserID")
+	ks, numRows, err := r.queryAPIKeys(ctx, lq.PageLimit(), q, args...)
+	done(err)
+	if err != nil {
+		return nil, "", translateError(err)
+	}
+	if len(ks) == 0 {
+		return ks, "", nil
+	}
+	return ks, lq.NextCursor(numRows, ks[len(ks)-1].ID), nil
+}
+
+// queryAPIKeys scans the first pageLimit API keys returned by q. numRows is
+// the number of rows q returned, including those beyond pageLimit.
+func (r *Roach) queryAPIKeys(ctx context.Context, pageLimit int, q string, args ...interface{}) ([]api.Key, int, error) {
+	rows, err := r.db.QueryContext(ctx, q, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+	ks := make([]api.Key, 0)
+	numRows := 0
+	for rows.Next() {
+		numRows++
+		if numRows > pageLimit {
+			continue
+		}
+		k := api.Key{}
+		if err := rows.Scan(&k.ID, &k.UserID, &k.Val, &k.Created, &k.LastUpdated); err != nil {
+			return nil, 0, err
+		}
+		ks = append(ks, k)
+	}
+	return ks, numRows, rows.Err()
+}
+
+// DeleteAPIKey soft-deletes the API key with keyID belonging to userID.
+func (r *Roach) DeleteAPIKey(ctx context.Context, userID, keyID string) error {
+	if err := r.InitDBIfNot(); err != nil {
+		return err
+	}
+	q := `
+	UPDATE ` + TblAPIKeys + `
+		SET (` + ColDesc(ColDeleteDate, ColUpdateDate) + `) = (CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)
+		WHERE ` + ColUserID + `=$1 AND ` + ColID + `=$2 AND ` + ColDeleteDate + ` IS NULL`
+	ctx, done := r.instrument(ctx, "DeleteAPIKey")
+	res, err := r.db.ExecContext(ctx, q, userID, keyID)
+	done(err)
+	return checkRowsAffected(res, translateError(err), 1)
+}
@@ -2,6 +2,7 @@ package roach_test
 
 import (
 	"bytes"
+	"context"
 	"reflect"
 	"strings"
 	"testing"
@@ -23,18 +24,18 @@ func TestRoach_InsertAPIKey(t *testing.T) {
 		testName string
 		key      []byte
 		usrID    string
-		expErr   bool
+		expClErr bool
 	}{
-		{testName: "valid", key: validKey, usrID: usrID, expErr: false},
-		{testName: "bad user ID", key: validKey, usrID: "bad id", expErr: true},
-		{testName: "empty key", key: []byte{}, usrID: usrID, expErr: true},
+		{testName: "valid", key: validKey, usrID: usrID, expClErr: false},
+		{testName: "bad user ID", key: validKey, usrID: "bad id", expClErr: true},
+		{testName: "empty key", key: []byte{}, usrID: usrID, expClErr: true},
 	}
 	for _, tc := range tt {
 		t.Run(tc.testName, func(t *testing.T) {
 			retI, err := r.InsertAPIKey(tc.usrID, tc.key)
-			if tc.expErr {
-				if err == nil {
-					t.Fatalf("Expected an error, got nil")
+			if tc.expClErr {
+				if !r.IsClientError(err) {
+					t.Fatalf("Expected a client error, got %v", err)
 				}
 				return
 			}
@@ -115,3 +116,80 @@ func insertAPIKey(t *testing.T, r *roach.Roach, usrID string) apiH.Key {
 	}
 	return k
 }
+
+func TestRoach_APIKeysByUserID(t *testing.T) {
+	conf, tearDown := setup(t)
+	defer tearDown()
+	r := newRoach(t, conf)
+	usrID := "123"
+	var expKeys []apiH.Key
+	for i := 0; i < 5; i++ {
+		expKeys = append(expKeys, insertAPIKey(t, r, usrID))
+	}
+	insertAPIKey(t, r, "345")
+	deleted := insertAPIKey(t, r, usrID)
+	if err := r.DeleteAPIKey(context.Background(), usrID, deleted.(api.Key).ID); err != nil {
+		t.Fatalf("Error setting up: delete API key: %v", err)
+	}
+
+	var actKeys []api.Key
+	cursor := ""
+	for pages := 1; ; pages++ {
+		ks, next, err := r.APIKeysByUserID(context.Background(), usrID, cursor, 2)
+		if err != nil {
+			t.Fatalf("Got error on page %d: %v", pages, err)
+		}
+		if len(ks) > 2 {
+			t.Fatalf("Expected at most 2 keys on page %d, got %d", pages, len(ks))
+		}
+		actKeys = append(actKeys, ks...)
+		if next == "" {
+			break
+		}
+		if pages > len(expKeys) {
+			t.Fatalf("Pagination did not terminate")
+		}
+		cursor = next
+	}
+
+	if len(actKeys) != len(expKeys) {
+		t.Fatalf("Expected %d keys, got %d", len(expKeys), len(actKeys))
+	}
+	for i := range expKeys {
+		if !reflect.DeepEqual(expKeys[i], actKeys[i]) {
+			t.Errorf("API Key %d mismatch:\nExpect:\t%+v\nGot:\t%+v",
+				i, expKeys[i], actKeys[i])
+		}
+	}
+
+	ks, next, err := r.APIKeysByUserID(context.Background(), "678", "", 0)
+	if err != nil || len(ks) != 0 || next != "" {
+		t.Errorf("Expected an empty last page for user without keys, got %d keys, cursor '%s', error %v",
+			len(ks), next, err)
+	}
+	if _, _, err := r.APIKeysByUserID(context.Background(), usrID, "not a cursor", 0); err == nil {
+		t.Errorf("Expected an error for an invalid cursor, got nil")
+	}
+}
+
+func TestRoach_DeleteAPIKey(t *testing.T) {
+	conf, tearDown := setup(t)
+	defer tearDown()
+	r := newRoach(t, conf)
+	usrID := "123"
+	k := insertAPIKey(t, r, usrID)
+	keyID := k.(api.Key).ID
+
+	if err := r.DeleteAPIKey(context.Background(), "345", keyID); !r.IsNotFoundError(err) {
+		t.Errorf("Expected not found error deleting another user's key, got %v", err)
+	}
+	if err := r.DeleteAPIKey(context.Background(), usrID, keyID); err != nil {
+		t.Fatalf("Got error: %v", err)
+	}
+	if _, err := r.APIKeyByUserIDVal(usrID, k.Value()); !r.IsNotFoundError(err) {
+		t.Errorf("Expected not found error fetching deleted key, got %v", err)
+	}
+	if err := r.DeleteAPIKey(context.Background(), usrID, keyID); !r.IsNotFoundError(err) {
+		t.Errorf("Expected not found error deleting a deleted key, got %v", err)
+	}
+}
@@ -0,0 +1,516 @@
+package roach
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/base64"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+	"time"
+
+	"github.com/cockroachdb/cockroach-go/crdb"
+	"github.com/tomogoma/go-typed-errors"
+	"github.com/tomogoma/seedms/pkg/config"
+)
+
+// ArchiveFormat is the encoding of a backup archive.
+type ArchiveFormat string
+
+const (
+	// FormatJSONLines archives the DB as a single newline delimited JSON
+	// stream: the ArchiveHeader on the first line followed by one line per row.
+	FormatJSONLines ArchiveFormat = "jsonl"
+	// FormatCSV archives the DB as a zip file containing the ArchiveHeader
+	// (header.json) and one <table name>.csv file per table.
+	FormatCSV ArchiveFormat = "csv"
+
+	archiveHeaderFile = "header.json"
+
+	// nullValue represents SQL NULL in CSV archives. Backslashes in other
+	// values are escaped (see escapeCSVValue) to tell them apart.
+	nullValue = `\N`
+
+	typeBytes = "BYTEA"
+)
+
+// ArchiveHeader describes the contents of a backup archive.
+type ArchiveHeader struct {
+	Name      string         `json:"name"`
+	DBVersion int            `json:"dbVersion"`
+	Created   time.Time      `json:"created"`
+	Tables    []ArchiveTable `json:"tables"`
+}
+
+// ArchiveTable describes the columns of a table in a backup archive.
+type ArchiveTable struct {
+	Name    string          `json:"name"`
+	Columns []ArchiveColumn `json:"columns"`
+}
+
+// ArchiveColumn describes a single column of a table in a backup archive.
+type ArchiveColumn struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+type archiveRow struct {
+	Table  string    `json:"table"`
+	Values []*string `json:"values"`
+}
+
+type archive struct {
+	header ArchiveHeader
+	rows   map[string][][]*string
+}
+
+// ValidArchiveFormat returns true if f is a supported ArchiveFormat.
+func ValidArchiveFormat(f ArchiveFormat) bool {
+	return f == FormatJSONLines || f == FormatCSV
+}
+
+// Backup writes every table in AllTableNames to w encoded as f.
+func (r *Roach) Backup(w io.Writer, f ArchiveFormat) error {
+	if !ValidArchiveFormat(f) {
+		return errors.NewClientf("unsupported archive format '%s'", f)
+	}
+	if err := r.InitDBIfNot(); err != nil {
+		return err
+	}
+	a, err := r.readArchive()
+	if err != nil {
+		return err
+	}
+	if f == FormatCSV {
+		return writeCSVArchive(w, a)
+	}
+	return writeJSONLinesArchive(w, a)
+}
+
+// Restore reads an archive encoded as f from rd and inserts its rows into
+// the DB. The DB is initialized (and migrated) to the current Version before
+// any rows are imported. Restore fails if any of the tables already contain
+// data or if the archive was produced by a different DB Version: the
+// archive's columns are only guaranteed to match the schema of its own
+// version, so restore it using a release on that version and upgrade from
+// there. Archive columns must exist in the DB's tables. CSV archives are
+// read into memory in their entirety.
+func (r *Roach) Restore(rd io.Reader, f ArchiveFormat) error {
+	if !ValidArchiveFormat(f) {
+		return errors.NewClientf("unsupported archive format '%s'", f)
+	}
+	if err := r.InitDBIfNot(); err != nil {
+		return err
+	}
+
+	var a *archive
+	var err error
+	if f == FormatCSV {
+		a, err = readCSVArchive(rd)
+	} else {
+		a, err = readJSONLinesArchive(rd)
+	}
+	if err != nil {
+		return err
+	}
+	if a.header.DBVersion != Version {
+		return errors.NewClientf("archive db version '%d' differs from"+
+			" the db version '%d'", a.header.DBVersion, Version)
+	}
+
+	if err := r.validateEmpty(); err != nil {
+		return err
+	}
+	for _, tblName := range AllTableNames {
+		tbl, ok := a.header.table(tblName)
+		if !ok {
+			continue
+		}
+		cols := make([]string, len(tbl.Columns))
+		for i, col := range tbl.Columns {
+			cols[i] = col.Name
+		}
+		if err := r.validateColumns(tblName, cols); err != nil {
+			return err
+		}
+	}
+
+	// DB errors are returned as is for ExecuteTx to retry and translate
+	// them; they are then annotated with the table being restored.
+	var tblName string
+	err = r.ExecuteTx(func(tx *sql.Tx) error {
+		for _, tblName = range AllTableNames {
+			tbl, ok := a.header.table(tblName)
+			if !ok {
+				continue
+			}
+			if err := insertArchiveRows(tx, tbl, a.rows[tblName]); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return annotateError("restore "+tblName, err)
+	}
+	return nil
+}
+
+// readArchive reads all tables in a single read-only transaction for the
+// archive to be a consistent snapshot of the DB.
+func (r *Roach) readArchive() (*archive, error) {
+	var a *archive
+	var tblName string
+	ctx, done := r.instrument(context.Background(), "Backup")
+	err := crdb.ExecuteTx(ctx, r.db, &sql.TxOptions{ReadOnly: true}, func(tx *sql.Tx) error {
+		a = &archive{
+			header: ArchiveHeader{
+				Name:      config.CanonicalName(),
+				DBVersion: Version,
+				Created:   time.Now(),
+			},
+			rows: make(map[string][][]*string),
+		}
+		for _, tblName = range AllTableNames {
+			tbl, rows, err := readTable(tx, tblName)
+			if err != nil {
+				return err
+			}
+			a.header.Tables = append(a.header.Tables, tbl)
+			a.rows[tblName] = rows
+		}
+		return nil
+	})
+	done(err)
+	if err != nil {
+		return nil, annotateError("read "+tblName, translateError(err))
+	}
+	return a, nil
+}
+
+func readTable(tx *sql.Tx, tblName string) (ArchiveTable, [][]*string, error) {
+	tbl := ArchiveTable{Name: tblName}
+	rows, err := tx.Query(`SELECT * FROM ` + tblName)
+	if err != nil {
+		return tbl, nil, err
+	}
+	defer rows.Close()
+
+	colTypes, err := rows.ColumnTypes()
+	if err != nil {
+		return tbl, nil, errors.Newf("get column types: %v", err)
+	}
+	for _, ct := range colTypes {
+		tbl.Columns = append(tbl.Columns,
+			ArchiveColumn{Name: ct.Name(), Type: ct.DatabaseTypeName()})
+	}
+
+	var vals [][]*string
+	for rows.Next() {
+		row := make([]interface{}, len(colTypes))
+		for i := range row {
+			row[i] = new(interface{})
+		}
+		if err := rows.Scan(row...); err != nil {
+			return tbl, nil, errors.Newf("scan: %v", err)
+		}
+		encRow := make([]*string, len(row))
+		for i, v := range row {
+			encRow[i] = encodeValue(*(v.(*interface{})), tbl.Columns[i].Type)
+		}
+		vals = append(vals, encRow)
+	}
+	if err := rows.Err(); err != nil {
+		return tbl, nil, errors.Newf("iterate rows: %v", err)
+	}
+	return tbl, vals, nil
+}
+
+// validateEmpty ensures none of the tables contain data. The db version
+// configuration written by InitDBIfNot() is not considered data.
+func (r *Roach) validateEmpty() error {
+	for _, tblName := range AllTableNames {
+		q := `SELECT COUNT(*) FROM ` + tblName
+		var args []interface{}
+		if tblName == TblConfigurations {
+			q = q + ` WHERE ` + ColKey + ` != $1`
+			args = append(args, keyDBVersion)
+		}
+		var count int64
+		if err := r.db.QueryRow(q, args...).Scan(&count); err != nil {
+			return errors.Newf("count %s rows: %v", tblName, err)
+		}
+		if count > 0 {
+			return errors.NewClientf("table %s is not empty", tblName)
+		}
+	}
+	return nil
+}
+
+func insertArchiveRows(tx *sql.Tx, tbl ArchiveTable, rows [][]*string) error {
+	if len(rows) == 0 {
+		return nil
+	}
+	cols := make([]string, len(tbl.Columns))
+	placeholders := make([]string, len(tbl.Columns))
+	keyIdx := -1
+	for i, col := range tbl.Columns {
+		cols[i] = col.Name
+		placeholders[i] = fmt.Sprintf("$%d", i+1)
+		if strings.EqualFold(col.Name, ColKey) {
+			keyIdx = i
+		}
+	}
+	q := `INSERT INTO ` + tbl.Name + ` (` + ColDesc(cols...) + `)
+		VALUES (` + strings.Join(placeholders, ", ") + `)`
+	stmt, err := tx.Prepare(q)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+	for i, row := range rows {
+		if len(row) != len(tbl.Columns) {
+			return errors.NewClientf("row %d has %d values, expected %d",
+				i, len(row), len(tbl.Columns))
+		}
+		// the db version is managed by InitDBIfNot()
+		if tbl.Name == TblConfigurations && keyIdx != -1 &&
+			row[keyIdx] != nil && *row[keyIdx] == keyDBVersion {
+			continue
+		}
+		args := make([]interface{}, len(row))
+		for j, val := range row {
+			args[j], err = decodeArchiveValue(val, tbl.Columns[j].Type)
+			if err != nil {
+				return errors.NewClientf("row %d column %s: %v",
+					i, tbl.Columns[j].Name, err)
+			}
+		}
+		if _, err := stmt.Exec(args...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// encodeValue converts a value scanned from a column of type colType into
+// its portable string representation. BYTEA values are base64 encoded and
+// time values are formatted as RFC3339. Other []byte values e.g. of
+// NUMERIC, UUID or JSONB columns are already text. nil is returned for NULL.
+func encodeValue(v interface{}, colType string) *string {
+	var s string
+	switch val := v.(type) {
+	case nil:
+		return nil
+	case []byte:
+		if strings.EqualFold(colType, typeBytes) {
+			s = base64.StdEncoding.EncodeToString(val)
+			break
+		}
+		s = string(val)
+	case time.Time:
+		s = val.Format(time.RFC3339Nano)
+	default:
+		s = fmt.Sprint(val)
+	}
+	return &s
+}
+
+func decodeArchiveValue(v *string, colType string) (interface{}, error) {
+	if v == nil {
+		return nil, nil
+	}
+	if strings.EqualFold(colType, typeBytes) {
+		return base64.StdEncoding.DecodeString(*v)
+	}
+	return *v, nil
+}
+
+func (h ArchiveHeader) table(name string) (ArchiveTable, bool) {
+	for _, tbl := range h.Tables {
+		if tbl.Name == name {
+			return tbl, true
+		}
+	}
+	return ArchiveTable{}, false
+}
+
+func writeJSONLinesArchive(w io.Writer, a *archive) error {
+	enc := json.NewEncoder(w)
+	if err := enc.Encode(a.header); err != nil {
+		return errors.Newf("write header: %v", err)
+	}
+	for _, tbl := range a.header.Tables {
+		for _, row := range a.rows[tbl.Name] {
+			if err := enc.Encode(archiveRow{Table: tbl.Name, Values: row}); err != nil {
+				return errors.Newf("write %s row: %v", tbl.Name, err)
+			}
+		}
+	}
+	return nil
+}
+
+func readJSONLinesArchive(rd io.Reader) (*archive, error) {
+	a := &archive{rows: make(map[string][][]*string)}
+	scanner := bufio.NewScanner(rd)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	if !scanner.Scan() {
+		if err := scanner.Err(); err != nil {
+			return nil, errors.Newf("read header: %v", err)
+		}
+		return nil, errors.NewClient("archive is empty")
+	}
+	if err := json.Unmarshal(scanner.Bytes(), &a.header); err != nil {
+		return nil, errors.NewClientf("unmarshal header: %v", err)
+	}
+	for line := 2; scanner.Scan(); line++ {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		var row archiveRow
+		if err := json.Unmarshal(scanner.Bytes(), &row); err != nil {
+			return nil, errors.NewClientf("unmarshal line %d: %v", line, err)
+		}
+		if _, ok := a.header.table(row.Table); !ok {
+			return nil, errors.NewClientf("line %d: table %s not in"+
+				" archive header", line, row.Table)
+		}
+		a.rows[row.Table] = append(a.rows[row.Table], row.Values)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, errors.Newf("read archive: %v", err)
+	}
+	return a, nil
+}
+
+func writeCSVArchive(w io.Writer, a *archive) error {
+	zw := zip.NewWriter(w)
+
+	hw, err := zw.Create(archiveHeaderFile)
+	if err != nil {
+		return errors.Newf("create header file: %v", err)
+	}
+	if err := json.NewEncoder(hw).Encode(a.header); err != nil {
+		return errors.Newf("write header: %v", err)
+	}
+
+	for _, tbl := range a.header.Tables {
+		fw, err := zw.Create(tbl.Name + ".csv")
+		if err != nil {
+			return errors.Newf("create %s file: %v", tbl.Name, err)
+		}
+		cw := csv.NewWriter(fw)
+		cols := make([]string, len(tbl.Columns))
+		for i, col := range tbl.Columns {
+			cols[i] = col.Name
+		}
+		if err := cw.Write(cols); err != nil {
+			return errors.Newf("write %s columns: %v", tbl.Name, err)
+		}
+		for _, row := range a.rows[tbl.Name] {
+			rec := make([]string, len(row))
+			for i, val := range row {
+				if val == nil {
+					rec[i] = nullValue
+					continue
+				}
+				rec[i] = escapeCSVValue(*val)
+			}
+			if err := cw.Write(rec); err != nil {
+				return errors.Newf("write %s row: %v", tbl.Name, err)
+			}
+		}
+		cw.Flush()
+		if err := cw.Error(); err != nil {
+			return errors.Newf("flush %s rows: %v", tbl.Name, err)
+		}
+	}
+
+	if err := zw.Close(); err != nil {
+		return errors.Newf("close archive: %v", err)
+	}
+	return nil
+}
+
+func readCSVArchive(rd io.Reader) (*archive, error) {
+	archiveB, err := ioutil.ReadAll(rd)
+	if err != nil {
+		return nil, errors.Newf("read archive: %v", err)
+	}
+	zr, err := zip.NewReader(bytes.NewReader(archiveB), int64(len(archiveB)))
+	if err != nil {
+		return nil, errors.NewClientf("open zip archive: %v", err)
+	}
+
+	files := make(map[string]*zip.File)
+	for _, f := range zr.File {
+		files[f.Name] = f
+	}
+
+	a := &archive{rows: make(map[string][][]*string)}
+	hf, ok := files[archiveHeaderFile]
+	if !ok {
+		return nil, errors.NewClientf("archive has no %s", archiveHeaderFile)
+	}
+	if err := readZipFile(hf, func(rc io.Reader) error {
+		return json.NewDecoder(rc).Decode(&a.header)
+	}); err != nil {
+		return nil, errors.NewClientf("read header: %v", err)
+	}
+
+	for _, tbl := range a.header.Tables {
+		tf, ok := files[tbl.Name+".csv"]
+		if !ok {
+			continue
+		}
+		err := readZipFile(tf, func(rc io.Reader) error {
+			recs, err := csv.NewReader(rc).ReadAll()
+			if err != nil {
+				return err
+			}
+			// first record contains column names
+			for i := 1; i < len(recs); i++ {
+				row := make([]*string, len(recs[i]))
+				for j := range recs[i] {
+					if recs[i][j] == nullValue {
+						continue
+					}
+					val := unescapeCSVValue(recs[i][j])
+					row[j] = &val
+				}
+				a.rows[tbl.Name] = append(a.rows[tbl.Name], row)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, errors.NewClientf("read %s: %v", tf.Name, err)
+		}
+	}
+	return a, nil
+}
+
+// escapeCSVValue doubles the backslashes in v so that no value is written
+// as nullValue.
+func escapeCSVValue(v string) string {
+	return strings.Replace(v, `\`, `\\`, -1)
+}
+
+// unescapeCSVValue reverses escapeCSVValue.
+func unescapeCSVValue(v string) string {
+	return strings.Replace(v, `\\`, `\`, -1)
+}
+
+func readZipFile(f *zip.File, readFunc func(io.Reader) error) error {
+	rc, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	return readFunc(rc)
+}
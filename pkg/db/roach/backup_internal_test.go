@@ -0,0 +1,101 @@
+package roach
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestEncodeValue(t *testing.T) {
+	created := time.Date(2017, 11, 3, 10, 4, 5, 6, time.UTC)
+	tt := []struct {
+		name    string
+		val     interface{}
+		colType string
+		exp     *string
+	}{
+		{name: "null", val: nil, colType: "STRING", exp: nil},
+		{name: "bytea", val: []byte("key"), colType: "BYTEA", exp: strPtr("a2V5")},
+		{name: "numeric", val: []byte("12.50"), colType: "NUMERIC", exp: strPtr("12.50")},
+		{name: "uuid", val: []byte("0b8e0ef4-4c41-4b1e-9a8c-3ce3b9a4a5d1"), colType: "UUID",
+			exp: strPtr("0b8e0ef4-4c41-4b1e-9a8c-3ce3b9a4a5d1")},
+		{name: "jsonb", val: []byte(`{"a":1}`), colType: "JSONB", exp: strPtr(`{"a":1}`)},
+		{name: "time", val: created, colType: "TIMESTAMP", exp: strPtr("2017-11-03T10:04:05.000000006Z")},
+		{name: "int", val: int64(42), colType: "INT", exp: strPtr("42")},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			act := encodeValue(tc.val, tc.colType)
+			if !reflect.DeepEqual(act, tc.exp) {
+				t.Errorf("Expected %s, got %s", fmtStrPtr(tc.exp), fmtStrPtr(act))
+			}
+			if act == nil {
+				return
+			}
+			dec, err := decodeArchiveValue(act, tc.colType)
+			if err != nil {
+				t.Fatalf("decodeArchiveValue(): %v", err)
+			}
+			if bytesVal, ok := tc.val.([]byte); ok && tc.colType == typeBytes {
+				if !bytes.Equal(dec.([]byte), bytesVal) {
+					t.Errorf("Expected decoded value '%s', got '%s'", bytesVal, dec)
+				}
+			}
+		})
+	}
+}
+
+func TestCSVArchive_roundTrip(t *testing.T) {
+	a := &archive{
+		header: ArchiveHeader{
+			Name:      "seedms",
+			DBVersion: Version,
+			Tables: []ArchiveTable{{Name: TblConfigurations, Columns: []ArchiveColumn{
+				{Name: ColKey, Type: "STRING"},
+				{Name: ColValue, Type: "BYTEA"},
+			}}},
+		},
+		rows: map[string][][]*string{TblConfigurations: {
+			{strPtr("null"), nil},
+			{strPtr("literal null marker"), strPtr(`\N`)},
+			{strPtr("backslashes"), strPtr(`C:\\dir\N\`)},
+			{strPtr("empty"), strPtr("")},
+		}},
+	}
+	buf := new(bytes.Buffer)
+	if err := writeCSVArchive(buf, a); err != nil {
+		t.Fatalf("writeCSVArchive(): %v", err)
+	}
+	act, err := readCSVArchive(buf)
+	if err != nil {
+		t.Fatalf("readCSVArchive(): %v", err)
+	}
+	if !reflect.DeepEqual(act.rows, a.rows) {
+		t.Errorf("Rows mismatch:\nExpect:\t%s\nGot:\t%s",
+			fmtRows(a.rows[TblConfigurations]), fmtRows(act.rows[TblConfigurations]))
+	}
+}
+
+func strPtr(s string) *string {
+	return &s
+}
+
+func fmtStrPtr(s *string) string {
+	if s == nil {
+		return "<nil>"
+	}
+	return "'" + *s + "'"
+}
+
+func fmtRows(rows [][]*string) string {
+	out := ""
+	for _, row := range rows {
+		out = out + "["
+		for _, val := range row {
+			out = out + fmtStrPtr(val) + " "
+		}
+		out = out + "] "
+	}
+	return out
+}
@@ -0,0 +1,67 @@
+package roach_test
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/tomogoma/seedms/pkg/db/roach"
+)
+
+func TestRoach_BackupRestore(t *testing.T) {
+	tt := []struct {
+		name   string
+		format roach.ArchiveFormat
+	}{
+		{name: "json lines", format: roach.FormatJSONLines},
+		{name: "csv", format: roach.FormatCSV},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			srcConf, tearDownSrc := setup(t)
+			defer tearDownSrc()
+			src := newRoach(t, srcConf)
+			expKey := insertAPIKey(t, src, "123")
+
+			archive := new(bytes.Buffer)
+			if err := src.Backup(archive, tc.format); err != nil {
+				t.Fatalf("Backup error: %v", err)
+			}
+
+			destConf := srcConf
+			destConf.DBName = srcConf.DBName + "_restore"
+			defer func() {
+				rdb := getDB(t, destConf)
+				defer rdb.Close()
+				if _, err := rdb.Exec("DROP DATABASE " + destConf.DBName); err != nil {
+					t.Fatalf("Error dropping test db: %v", err)
+				}
+			}()
+			dest := newRoach(t, destConf)
+			if err := dest.Restore(bytes.NewReader(archive.Bytes()), tc.format); err != nil {
+				t.Fatalf("Restore error: %v", err)
+			}
+
+			actKey, err := dest.APIKeyByUserIDVal("123", expKey.Value())
+			if err != nil {
+				t.Fatalf("Get restored API key: %v", err)
+			}
+			if !reflect.DeepEqual(expKey, actKey) {
+				t.Errorf("API Key mismatch:\nExpect:\t%+v\nGot:\t%+v",
+					expKey, actKey)
+			}
+
+			err = dest.Restore(bytes.NewReader(archive.Bytes()), tc.format)
+			if err == nil {
+				t.Errorf("Expected an error restoring into a non-empty DB, got nil")
+			}
+		})
+	}
+}
+
+func TestRoach_Restore_invalidFormat(t *testing.T) {
+	r := roach.NewRoach()
+	if err := r.Restore(new(bytes.Buffer), "xml"); err == nil {
+		t.Fatalf("Expected an error, got nil")
+	}
+}
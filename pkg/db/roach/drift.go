@@ -0,0 +1,296 @@
+package roach
+
+import (
+	"database/sql"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/tomogoma/go-typed-errors"
+)
+
+// SchemaDiff describes a difference between a column as described in
+// AllTableDescs and as found in the DB.
+type SchemaDiff struct {
+	Table  string
+	Column string
+	// Expected is the type (with width and NOT NULL if any) described in
+	// AllTableDescs, empty if the column is not expected.
+	Expected string
+	// Actual is the type (with width and NOT NULL if any) found in the DB,
+	// empty if the column is missing.
+	Actual string
+}
+
+type columnDesc struct {
+	name string
+	typ  string
+	// width is the type's width e.g. "56" for VARCHAR(56) or "10,2" for
+	// DECIMAL(10,2), empty if unspecified.
+	width   string
+	notNull bool
+}
+
+var (
+	tableNameRe  = regexp.MustCompile(`(?i)CREATE\s+TABLE\s+(?:IF\s+NOT\s+EXISTS\s+)?(\w+)`)
+	columnRe     = regexp.MustCompile(`(?i)^\s*(\w+)\s+([A-Za-z]+(?:\s+WITH(?:OUT)?\s+TIME\s+ZONE)?)(?:\s*\(([\d\s,]+)\))?`)
+	primaryKeyRe = regexp.MustCompile(`(?i)^\s*(?:CONSTRAINT\s+\w+\s+)?PRIMARY\s+KEY\s*\(([^)]*)\)`)
+
+	// keywords starting lines of table descriptions that do not define columns.
+	nonColumnKeywords = []string{"PRIMARY", "UNIQUE", "FOREIGN", "CONSTRAINT",
+		"INDEX", "CHECK", "FAMILY", "CREATE"}
+
+	// typeFamilies maps the column types used in table descriptions and
+	// reported by information_schema to comparable type families.
+	typeFamilies = map[string]string{
+		"SERIAL": "INT", "BIGSERIAL": "INT", "SMALLSERIAL": "INT",
+		"INT": "INT", "INTEGER": "INT", "INT2": "INT", "INT4": "INT",
+		"INT8": "INT", "SMALLINT": "INT", "BIGINT": "INT",
+		"VARCHAR": "STRING", "CHARACTER VARYING": "STRING", "CHAR": "STRING",
+		"CHARACTER": "STRING", "TEXT": "STRING", "STRING": "STRING",
+		"BYTEA": "BYTES", "BYTES": "BYTES", "BLOB": "BYTES",
+		"TIMESTAMPTZ": "TIMESTAMPTZ", "TIMESTAMP WITH TIME ZONE": "TIMESTAMPTZ",
+		"TIMESTAMP": "TIMESTAMP", "TIMESTAMP WITHOUT TIME ZONE": "TIMESTAMP",
+		"BOOL": "BOOL", "BOOLEAN": "BOOL",
+		"FLOAT": "FLOAT", "FLOAT4": "FLOAT", "FLOAT8": "FLOAT", "REAL": "FLOAT",
+		"DOUBLE PRECISION": "FLOAT", "DECIMAL": "DECIMAL", "NUMERIC": "DECIMAL",
+		"JSON": "JSONB", "JSONB": "JSONB",
+		"UUID": "UUID", "DATE": "DATE", "INTERVAL": "INTERVAL",
+	}
+)
+
+func (d SchemaDiff) String() string {
+	switch {
+	case d.Column == "":
+		return fmt.Sprintf("table %s is missing", d.Table)
+	case d.Actual == "":
+		return fmt.Sprintf("column %s.%s (%s) is missing",
+			d.Table, d.Column, d.Expected)
+	case d.Expected == "":
+		return fmt.Sprintf("column %s.%s (%s) is not expected",
+			d.Table, d.Column, d.Actual)
+	default:
+		return fmt.Sprintf("column %s.%s has type %s, expected %s",
+			d.Table, d.Column, d.Actual, d.Expected)
+	}
+}
+
+func (c columnDesc) String() string {
+	desc := c.typ
+	if c.width != "" {
+		desc = desc + "(" + c.width + ")"
+	}
+	if c.notNull {
+		desc = desc + " NOT NULL"
+	}
+	return desc
+}
+
+// SchemaDrift compares the columns of the tables in AllTableNames as
+// reported by the DB's information_schema with those described in
+// AllTableDescs. Column names are compared case insensitively, types by
+// family e.g. SERIAL and INT8 are equivalent, and by width (string lengths
+// and decimal precision) and nullability.
+// An empty slice is returned if no differences are found.
+func (r *Roach) SchemaDrift() ([]SchemaDiff, error) {
+	if err := r.InitDBIfNot(); err != nil {
+		return nil, err
+	}
+	expected := make(map[string][]columnDesc)
+	for _, desc := range AllTableDescs {
+		tbl, cols, err := parseTableDesc(desc)
+		if err != nil {
+			return nil, err
+		}
+		expected[strings.ToLower(tbl)] = cols
+	}
+
+	diffs := make([]SchemaDiff, 0)
+	for _, tbl := range AllTableNames {
+		actual, err := r.actualColumns(tbl)
+		if err != nil {
+			return nil, errors.Newf("get %s columns: %v", tbl, err)
+		}
+		diffs = append(diffs, diffColumns(tbl, expected[strings.ToLower(tbl)], actual)...)
+	}
+	return diffs, nil
+}
+
+func (r *Roach) actualColumns(tbl string) ([]columnDesc, error) {
+	q := `
+	SELECT column_name, data_type, is_nullable, character_maximum_length,
+			numeric_precision, numeric_scale
+		FROM information_schema.columns
+		WHERE table_catalog = $1 AND LOWER(table_name) = LOWER($2)
+		ORDER BY ordinal_position`
+	rows, err := r.db.Query(q, r.dbName, tbl)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var cols []columnDesc
+	for rows.Next() {
+		var name, typ, isNullable string
+		var charLen, numPrecision, numScale sql.NullInt64
+		err := rows.Scan(&name, &typ, &isNullable, &charLen, &numPrecision, &numScale)
+		if err != nil {
+			return nil, err
+		}
+		// CockroachDB's implicit primary key for tables without one.
+		if name == "rowid" {
+			continue
+		}
+		cols = append(cols, newActualColumn(name, typ, isNullable, charLen, numPrecision, numScale))
+	}
+	return cols, rows.Err()
+}
+
+// validateColumns returns a client error if any of cols is not a column of
+// tbl as reported by the DB's information_schema. Column names are compared
+// case insensitively.
+func (r *Roach) validateColumns(tbl string, cols []string) error {
+	actual, err := r.actualColumns(tbl)
+	if err != nil {
+		return errors.Newf("get %s columns: %v", tbl, err)
+	}
+	if col, ok := unknownColumn(actual, cols); ok {
+		return errors.NewClientf("table %s has no column '%s'", tbl, col)
+	}
+	return nil
+}
+
+// unknownColumn returns the first of cols not found in actual.
+func unknownColumn(actual []columnDesc, cols []string) (string, bool) {
+	known := make(map[string]bool, len(actual))
+	for _, col := range actual {
+		known[strings.ToLower(col.name)] = true
+	}
+	for _, col := range cols {
+		if !known[strings.ToLower(col)] {
+			return col, true
+		}
+	}
+	return "", false
+}
+
+// newActualColumn describes a column from its information_schema.columns
+// values. Widths are taken from the character length of string types and
+// the precision (and none zero scale) of decimal types.
+func newActualColumn(name, typ, isNullable string, charLen, numPrecision, numScale sql.NullInt64) columnDesc {
+	col := columnDesc{name: name, typ: typ, notNull: strings.EqualFold(isNullable, "NO")}
+	switch {
+	case charLen.Valid:
+		col.width = strconv.FormatInt(charLen.Int64, 10)
+	case typeFamily(typ) == "DECIMAL" && numPrecision.Valid:
+		col.width = strconv.FormatInt(numPrecision.Int64, 10)
+		if numScale.Valid && numScale.Int64 != 0 {
+			col.width = col.width + "," + strconv.FormatInt(numScale.Int64, 10)
+		}
+	}
+	return col
+}
+
+func diffColumns(tbl string, expected, actual []columnDesc) []SchemaDiff {
+	if len(actual) == 0 {
+		return []SchemaDiff{{Table: tbl}}
+	}
+	var diffs []SchemaDiff
+	actualByName := make(map[string]columnDesc)
+	for _, col := range actual {
+		actualByName[strings.ToLower(col.name)] = col
+	}
+	for _, exp := range expected {
+		act, ok := actualByName[strings.ToLower(exp.name)]
+		if !ok {
+			diffs = append(diffs, SchemaDiff{Table: tbl, Column: exp.name, Expected: exp.String()})
+			continue
+		}
+		delete(actualByName, strings.ToLower(exp.name))
+		if typeFamily(exp.typ) != typeFamily(act.typ) || exp.width != act.width ||
+			exp.notNull != act.notNull {
+			diffs = append(diffs, SchemaDiff{Table: tbl, Column: exp.name,
+				Expected: exp.String(), Actual: act.String()})
+		}
+	}
+	for _, act := range actual {
+		if _, ok := actualByName[strings.ToLower(act.name)]; ok {
+			diffs = append(diffs, SchemaDiff{Table: tbl, Column: act.name, Actual: act.String()})
+		}
+	}
+	return diffs
+}
+
+// parseTableDesc extracts the table name and column definitions from a
+// CREATE TABLE description. Each column definition is expected on its own
+// line as is the case in AllTableDescs. Columns declared NOT NULL or
+// PRIMARY KEY, inline or in a table PRIMARY KEY constraint, are not null.
+func parseTableDesc(desc string) (string, []columnDesc, error) {
+	tblMatch := tableNameRe.FindStringSubmatch(desc)
+	if tblMatch == nil {
+		return "", nil, errors.Newf("no table name found in description: %s", desc)
+	}
+	var cols []columnDesc
+	primaryKeys := make(map[string]bool)
+	for _, line := range strings.Split(desc, "\n") {
+		if m := primaryKeyRe.FindStringSubmatch(line); m != nil {
+			for _, col := range strings.Split(m[1], ",") {
+				primaryKeys[strings.ToLower(strings.TrimSpace(col))] = true
+			}
+			continue
+		}
+		upperLine := strings.ToUpper(line)
+		fields := strings.Fields(upperLine)
+		if len(fields) == 0 || strings.HasPrefix(fields[0], ")") ||
+			isAnyOf(fields[0], nonColumnKeywords...) {
+			continue
+		}
+		m := columnRe.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		typ := strings.ToUpper(m[2])
+		cols = append(cols, columnDesc{
+			name:    m[1],
+			typ:     typ,
+			width:   normalizeWidth(typ, m[3]),
+			notNull: strings.Contains(upperLine, "NOT NULL") || strings.Contains(upperLine, "PRIMARY KEY"),
+		})
+	}
+	for i := range cols {
+		if primaryKeys[strings.ToLower(cols[i].name)] {
+			cols[i].notNull = true
+		}
+	}
+	return tblMatch[1], cols, nil
+}
+
+// normalizeWidth removes spaces from width and the zero scale of decimal
+// types for comparison with newActualColumn widths.
+func normalizeWidth(typ, width string) string {
+	width = strings.Replace(width, " ", "", -1)
+	if typeFamily(typ) == "DECIMAL" {
+		width = strings.TrimSuffix(width, ",0")
+	}
+	return width
+}
+
+func typeFamily(typ string) string {
+	typ = strings.ToUpper(strings.TrimSpace(typ))
+	if idx := strings.Index(typ, "("); idx != -1 {
+		typ = strings.TrimSpace(typ[:idx])
+	}
+	if family, ok := typeFamilies[typ]; ok {
+		return family
+	}
+	return typ
+}
+
+func isAnyOf(s string, options ...string) bool {
+	for _, o := range options {
+		if s == o {
+			return true
+		}
+	}
+	return false
+}
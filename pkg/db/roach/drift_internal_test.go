@@ -0,0 +1,200 @@
+package roach
+
+import (
+	"database/sql"
+	"reflect"
+	"testing"
+)
+
+func TestParseTableDesc(t *testing.T) {
+	tt := []struct {
+		name     string
+		desc     string
+		expTable string
+		expCols  []columnDesc
+		expErr   bool
+	}{
+		{
+			name:     "configurations",
+			desc:     TblDescConfigurations,
+			expTable: TblConfigurations,
+			expCols: []columnDesc{
+				{name: ColKey, typ: "VARCHAR", width: "56", notNull: true},
+				{name: ColValue, typ: "BYTEA", notNull: true},
+				{name: ColCreateDate, typ: "TIMESTAMPTZ", notNull: true},
+				{name: ColUpdateDate, typ: "TIMESTAMPTZ", notNull: true},
+			},
+		},
+		{
+			name:     "api keys",
+			desc:     TblDescAPIKeys,
+			expTable: TblAPIKeys,
+			expCols: []columnDesc{
+				{name: ColID, typ: "SERIAL", notNull: true},
+				{name: ColUserID, typ: "INTEGER", notNull: true},
+				{name: ColKey, typ: "VARCHAR", width: "256", notNull: true},
+				{name: ColCreateDate, typ: "TIMESTAMPTZ", notNull: true},
+				{name: ColUpdateDate, typ: "TIMESTAMPTZ", notNull: true},
+				{name: ColDeleteDate, typ: "TIMESTAMPTZ"},
+			},
+		},
+		{
+			name: "table constraints",
+			desc: `
+			CREATE TABLE prices (
+				itemID INT,
+				region varchar ( 8 ),
+				amount DECIMAL(10, 0) NOT NULL,
+				discount DECIMAL(4,2),
+				noted timestamp with time zone,
+				PRIMARY KEY (itemID, region),
+				UNIQUE (amount),
+				CHECK (amount > 0)
+			);`,
+			expTable: "prices",
+			expCols: []columnDesc{
+				{name: "itemID", typ: "INT", notNull: true},
+				{name: "region", typ: "VARCHAR", width: "8", notNull: true},
+				{name: "amount", typ: "DECIMAL", width: "10", notNull: true},
+				{name: "discount", typ: "DECIMAL", width: "4,2"},
+				{name: "noted", typ: "TIMESTAMP WITH TIME ZONE"},
+			},
+		},
+		{name: "no table name", desc: "SELECT 1", expErr: true},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			tbl, cols, err := parseTableDesc(tc.desc)
+			if tc.expErr {
+				if err == nil {
+					t.Fatal("Expected an error but got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Got error: %v", err)
+			}
+			if tbl != tc.expTable {
+				t.Errorf("Expected table '%s', got '%s'", tc.expTable, tbl)
+			}
+			if !reflect.DeepEqual(cols, tc.expCols) {
+				t.Errorf("Columns mismatch:\nExpect:\t%+v\nGot:\t%+v", tc.expCols, cols)
+			}
+		})
+	}
+}
+
+func TestDiffColumns(t *testing.T) {
+	expected := []columnDesc{
+		{name: ColID, typ: "SERIAL", notNull: true},
+		{name: ColKey, typ: "VARCHAR", width: "56", notNull: true},
+		{name: ColDeleteDate, typ: "TIMESTAMPTZ"},
+	}
+	id := newActualColumn("id", "bigint", "NO", sql.NullInt64{}, sql.NullInt64{Int64: 64, Valid: true}, sql.NullInt64{Valid: true})
+	key := newActualColumn("key", "character varying", "NO", sql.NullInt64{Int64: 56, Valid: true}, sql.NullInt64{}, sql.NullInt64{})
+	deleteDate := newActualColumn("deleteDate", "timestamp with time zone", "YES", sql.NullInt64{}, sql.NullInt64{}, sql.NullInt64{})
+	tt := []struct {
+		name     string
+		actual   []columnDesc
+		expDiffs []string
+	}{
+		{
+			name:   "no drift",
+			actual: []columnDesc{id, key, deleteDate},
+		},
+		{
+			name:     "missing table",
+			expDiffs: []string{"table apiKeys is missing"},
+		},
+		{
+			name: "width changed",
+			actual: []columnDesc{id, deleteDate,
+				newActualColumn("key", "character varying", "NO", sql.NullInt64{Int64: 256, Valid: true}, sql.NullInt64{}, sql.NullInt64{})},
+			expDiffs: []string{"column apiKeys.key has type character varying(256) NOT NULL, expected VARCHAR(56) NOT NULL"},
+		},
+		{
+			name: "nullability changed",
+			actual: []columnDesc{id, key,
+				newActualColumn("deleteDate", "timestamp with time zone", "NO", sql.NullInt64{}, sql.NullInt64{}, sql.NullInt64{})},
+			expDiffs: []string{"column apiKeys.deleteDate has type timestamp with time zone NOT NULL, expected TIMESTAMPTZ"},
+		},
+		{
+			name: "type changed",
+			actual: []columnDesc{id, deleteDate,
+				newActualColumn("key", "bytea", "NO", sql.NullInt64{}, sql.NullInt64{}, sql.NullInt64{})},
+			expDiffs: []string{"column apiKeys.key has type bytea NOT NULL, expected VARCHAR(56) NOT NULL"},
+		},
+		{
+			name: "missing and unexpected columns",
+			actual: []columnDesc{id, key,
+				newActualColumn("extra", "text", "YES", sql.NullInt64{}, sql.NullInt64{}, sql.NullInt64{})},
+			expDiffs: []string{
+				"column apiKeys.deleteDate (TIMESTAMPTZ) is missing",
+				"column apiKeys.extra (text) is not expected",
+			},
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			diffs := diffColumns(TblAPIKeys, expected, tc.actual)
+			var actDiffs []string
+			for _, diff := range diffs {
+				actDiffs = append(actDiffs, diff.String())
+			}
+			if !reflect.DeepEqual(actDiffs, tc.expDiffs) {
+				t.Errorf("Differences mismatch:\nExpect:\t%v\nGot:\t%v", tc.expDiffs, actDiffs)
+			}
+		})
+	}
+}
+
+func TestNewActualColumn_decimal(t *testing.T) {
+	tt := []struct {
+		name     string
+		scale    sql.NullInt64
+		expWidth string
+	}{
+		{name: "no scale", scale: sql.NullInt64{Int64: 0, Valid: true}, expWidth: "10"},
+		{name: "scale", scale: sql.NullInt64{Int64: 2, Valid: true}, expWidth: "10,2"},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			col := newActualColumn("amount", "numeric", "YES", sql.NullInt64{},
+				sql.NullInt64{Int64: 10, Valid: true}, tc.scale)
+			if col.width != tc.expWidth {
+				t.Errorf("Expected width '%s', got '%s'", tc.expWidth, col.width)
+			}
+		})
+	}
+}
+
+func TestUnknownColumn(t *testing.T) {
+	actual := []columnDesc{
+		newActualColumn("userid", "bigint", "NO", sql.NullInt64{}, sql.NullInt64{Int64: 64, Valid: true}, sql.NullInt64{Valid: true}),
+		newActualColumn("key", "character varying", "NO", sql.NullInt64{Int64: 256, Valid: true}, sql.NullInt64{}, sql.NullInt64{}),
+	}
+	tt := []struct {
+		name     string
+		cols     []string
+		expCol   string
+		expFound bool
+	}{
+		{name: "known columns", cols: []string{ColUserID, ColKey}},
+		{name: "no columns"},
+		{
+			name:     "unknown column",
+			cols:     []string{ColKey, "key) VALUES ('x'); DROP TABLE apiKeys; --"},
+			expCol:   "key) VALUES ('x'); DROP TABLE apiKeys; --",
+			expFound: true,
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			col, found := unknownColumn(actual, tc.cols)
+			if found != tc.expFound || col != tc.expCol {
+				t.Errorf("Expected ('%s', %t), got ('%s', %t)",
+					tc.expCol, tc.expFound, col, found)
+			}
+		})
+	}
+}
@@ -0,0 +1,53 @@
+package roach_test
+
+import (
+	"testing"
+
+	"github.com/tomogoma/seedms/pkg/db/roach"
+)
+
+func TestRoach_SchemaDrift(t *testing.T) {
+	conf, tearDown := setup(t)
+	defer tearDown()
+	r := newRoach(t, conf)
+
+	diffs, err := r.SchemaDrift()
+	if err != nil {
+		t.Fatalf("Got error: %v", err)
+	}
+	if len(diffs) != 0 {
+		t.Fatalf("Expected no differences on a fresh DB, got %v", diffs)
+	}
+
+	rdb := getDB(t, conf)
+	defer rdb.Close()
+	for _, q := range []string{
+		`ALTER TABLE ` + conf.DBName + `.` + roach.TblAPIKeys + ` DROP COLUMN ` + roach.ColDeleteDate,
+		`ALTER TABLE ` + conf.DBName + `.` + roach.TblAPIKeys + ` ADD COLUMN extra STRING`,
+	} {
+		if _, err := rdb.Exec(q); err != nil {
+			t.Fatalf("Error setting up: alter table: %v", err)
+		}
+	}
+
+	diffs, err = r.SchemaDrift()
+	if err != nil {
+		t.Fatalf("Got error: %v", err)
+	}
+	expDiffs := map[string]bool{
+		"column apiKeys.deleteDate (TIMESTAMPTZ) is missing": false,
+		"column apiKeys.extra (text) is not expected":        false,
+	}
+	for _, diff := range diffs {
+		if _, ok := expDiffs[diff.String()]; !ok {
+			t.Errorf("Unexpected difference: %s", diff)
+			continue
+		}
+		expDiffs[diff.String()] = true
+	}
+	for diff, found := range expDiffs {
+		if !found {
+			t.Errorf("Expected difference not reported: %s", diff)
+		}
+	}
+}
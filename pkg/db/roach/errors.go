@@ -0,0 +1,105 @@
+package roach
+
+import (
+	"database/sql"
+	"strings"
+
+	"github.com/lib/pq"
+	"github.com/tomogoma/go-typed-errors"
+	"github.com/tomogoma/seedms/pkg/logging"
+)
+
+// Postgres/CockroachDB error codes and classes, see
+// https://www.postgresql.org/docs/current/static/errcodes-appendix.html
+const (
+	pgClassDataException        = "22"
+	pgClassIntegrityViolation   = "23"
+	pgClassConnectionException  = "08"
+	pgClassTransactionRollback  = "40"
+	pgClassInsufficientResource = "53"
+
+	pgCodeUniqueViolation  = "23505"
+	pgCodeAdminShutdown    = "57P01"
+	pgCodeCannotConnectNow = "57P03"
+
+	// CockroachDB specific code returned when a transaction should be retried.
+	crdbCodeRetryTxn = "CR000"
+)
+
+// translateError converts err into a typed error where it originates from
+// Postgres/CockroachDB so that callers can act on its category:
+//     unique violations                 -> errors.IsConflictError
+//     other integrity violations e.g.
+//         CHECK and NOT NULL constraints,
+//         and invalid data              -> errors.IsClientError
+//     serialization failures, deadlocks
+//         and connection problems       -> errors.IsRetryableError
+//     sql.ErrNoRows                     -> errors.IsNotFoundError
+// Other errors are returned as is.
+func translateError(err error) error {
+	if err == nil {
+		return nil
+	}
+	if err == sql.ErrNoRows {
+		return errors.NewNotFound("none found")
+	}
+	pqErr, ok := err.(*pq.Error)
+	if !ok {
+		return err
+	}
+	code := string(pqErr.Code)
+	switch {
+	case code == pgCodeUniqueViolation:
+		return errors.NewConflictf("%s", describePQError(pqErr))
+	case strings.HasPrefix(code, pgClassIntegrityViolation),
+		strings.HasPrefix(code, pgClassDataException):
+		return errors.NewClientf("%s", describePQError(pqErr))
+	case strings.HasPrefix(code, pgClassTransactionRollback),
+		strings.HasPrefix(code, pgClassConnectionException),
+		strings.HasPrefix(code, pgClassInsufficientResource),
+		code == pgCodeAdminShutdown,
+		code == pgCodeCannotConnectNow,
+		code == crdbCodeRetryTxn:
+		return errors.NewRetryablef("%s", describePQError(pqErr))
+	}
+	return err
+}
+
+// annotateError prefixes the message of err with prefix, keeping the
+// category of err (see translateError).
+func annotateError(prefix string, err error) error {
+	msg := prefix + ": " + err.Error()
+	switch {
+	case errors.ConflictErrCheck{}.IsConflictError(err):
+		return errors.NewConflict(msg)
+	case errors.ClErrCheck{}.IsClientError(err):
+		return errors.NewClient(msg)
+	case errors.NotFoundErrCheck{}.IsNotFoundError(err):
+		return errors.NewNotFound(msg)
+	case errors.RetryableErrCheck{}.IsRetryableError(err):
+		return errors.NewRetryable(msg)
+	}
+	return errors.New(msg)
+}
+
+// describePQError describes err for clients. err.Detail is left out as it
+// may contain the values of the offending row e.g. a duplicate API key; see
+// logErrorDetail.
+func describePQError(err *pq.Error) string {
+	desc := err.Message
+	if err.Constraint != "" {
+		desc = desc + " (constraint " + err.Constraint + ")"
+	}
+	return desc
+}
+
+// logErrorDetail logs the detail of err, if any, for the DB operation op
+// since it is not included in errors returned to callers.
+func (r *Roach) logErrorDetail(op string, err error) {
+	pqErr, ok := err.(*pq.Error)
+	if !ok || pqErr.Detail == "" || r.logger == nil {
+		return
+	}
+	r.logger.WithField(logging.FieldAction, "DB "+op).
+		Warnf("%s: %s", pqErr.Message, pqErr.Detail)
+}
@@ -0,0 +1,127 @@
+package roach
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/lib/pq"
+	"github.com/tomogoma/go-typed-errors"
+	"github.com/tomogoma/seedms/pkg/mocks"
+)
+
+func TestTranslateError(t *testing.T) {
+	plainErr := errors.New("plain")
+	tt := []struct {
+		name         string
+		err          error
+		expNil       bool
+		expClErr     bool
+		expConflict  bool
+		expRetryable bool
+		expNotFound  bool
+		expSame      bool
+	}{
+		{name: "nil", err: nil, expNil: true},
+		{name: "no rows", err: sql.ErrNoRows, expNotFound: true},
+		{name: "unique violation", err: &pq.Error{Code: "23505"}, expConflict: true},
+		{name: "check violation", err: &pq.Error{Code: "23514", Constraint: "check_key"}, expClErr: true},
+		{name: "not null violation", err: &pq.Error{Code: "23502"}, expClErr: true},
+		{name: "invalid text representation", err: &pq.Error{Code: "22P02"}, expClErr: true},
+		{name: "serialization failure", err: &pq.Error{Code: "40001"}, expRetryable: true},
+		{name: "cockroach retry", err: &pq.Error{Code: "CR000"}, expRetryable: true},
+		{name: "connection failure", err: &pq.Error{Code: "08006"}, expRetryable: true},
+		{name: "admin shutdown", err: &pq.Error{Code: "57P01"}, expRetryable: true},
+		{name: "syntax error", err: &pq.Error{Code: "42601"}, expSame: true},
+		{name: "none pq error", err: plainErr, expSame: true},
+	}
+	r := NewRoach()
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			err := translateError(tc.err)
+			if tc.expNil {
+				if err != nil {
+					t.Fatalf("Expected nil, got %v", err)
+				}
+				return
+			}
+			if tc.expSame && err != tc.err {
+				t.Errorf("Expected error to be returned as is, got %v", err)
+			}
+			if r.IsClientError(err) != tc.expClErr {
+				t.Errorf("Expected client error %t, got %v", tc.expClErr, err)
+			}
+			if r.IsConflictError(err) != tc.expConflict {
+				t.Errorf("Expected conflict error %t, got %v", tc.expConflict, err)
+			}
+			if r.IsRetryableError(err) != tc.expRetryable {
+				t.Errorf("Expected retryable error %t, got %v", tc.expRetryable, err)
+			}
+			if r.IsNotFoundError(err) != tc.expNotFound {
+				t.Errorf("Expected not found error %t, got %v", tc.expNotFound, err)
+			}
+		})
+	}
+}
+
+func TestAnnotateError(t *testing.T) {
+	tt := []struct {
+		name         string
+		err          error
+		expClErr     bool
+		expConflict  bool
+		expRetryable bool
+		expNotFound  bool
+	}{
+		{name: "client", err: errors.NewClient("bad"), expClErr: true},
+		{name: "conflict", err: errors.NewConflict("dup"), expConflict: true},
+		{name: "retryable", err: errors.NewRetryable("again"), expRetryable: true},
+		{name: "not found", err: errors.NewNotFound("none"), expNotFound: true},
+		{name: "plain", err: errors.New("plain")},
+	}
+	r := NewRoach()
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			err := annotateError("restore users", tc.err)
+			if expMsg := "restore users: " + tc.err.Error(); err.Error() != expMsg {
+				t.Errorf("Expected message '%s', got '%s'", expMsg, err)
+			}
+			if r.IsClientError(err) != tc.expClErr {
+				t.Errorf("Expected client error %t, got %v", tc.expClErr, err)
+			}
+			if r.IsConflictError(err) != tc.expConflict {
+				t.Errorf("Expected conflict error %t, got %v", tc.expConflict, err)
+			}
+			if r.IsRetryableError(err) != tc.expRetryable {
+				t.Errorf("Expected retryable error %t, got %v", tc.expRetryable, err)
+			}
+			if r.IsNotFoundError(err) != tc.expNotFound {
+				t.Errorf("Expected not found error %t, got %v", tc.expNotFound, err)
+			}
+		})
+	}
+}
+
+func TestTranslateError_detail(t *testing.T) {
+	pqErr := &pq.Error{
+		Code:       "23505",
+		Message:    "duplicate key value violates unique constraint",
+		Constraint: "apikeys_key_key",
+		Detail:     "Key (key)=('secret-api-key') already exists.",
+	}
+	err := translateError(pqErr)
+	expMsg := "duplicate key value violates unique constraint (constraint apikeys_key_key)"
+	if err.Error() != expMsg {
+		t.Errorf("Expected message '%s', got '%s'", expMsg, err)
+	}
+
+	lg := &mocks.Logger{}
+	r := NewRoach(WithLogger(lg))
+	r.logErrorDetail("InsertAPIKey", pqErr)
+	if len(lg.Spinoffs) != 1 || len(lg.Spinoffs[0].Logs) != 1 {
+		t.Fatalf("Expected the detail to be logged once, got %+v", lg)
+	}
+	e := lg.Spinoffs[0].Logs[0]
+	if e.Level != mocks.LevelWarn || len(e.Args) != 2 || e.Args[1] != pqErr.Detail {
+		t.Errorf("Expected a warning with the detail, got %+v", e)
+	}
+}
@@ -0,0 +1,157 @@
+package roach
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/tomogoma/go-typed-errors"
+	"gopkg.in/yaml.v2"
+)
+
+// Fixtures maps table names to the rows to be inserted into them. Each row
+// maps column names to values e.g. in YAML:
+//     apiKeys:
+//       - userID: 123
+//         key: a-56-character-or-longer-api-key...
+//         updateDate: 2018-01-01T00:00:00Z
+type Fixtures map[string][]map[string]interface{}
+
+// ReadFixtures reads fixture files and merges their contents in the order
+// provided. Files with a .json extension are decoded as JSON, all others
+// as YAML.
+func ReadFixtures(files ...string) (Fixtures, error) {
+	f := make(Fixtures)
+	for _, fName := range files {
+		fileB, err := ioutil.ReadFile(fName)
+		if err != nil {
+			return nil, errors.Newf("read %s: %v", fName, err)
+		}
+		var fileF Fixtures
+		if strings.EqualFold(filepath.Ext(fName), ".json") {
+			err = json.Unmarshal(fileB, &fileF)
+		} else {
+			err = yaml.Unmarshal(fileB, &fileF)
+		}
+		if err != nil {
+			return nil, errors.NewClientf("unmarshal %s: %v", fName, err)
+		}
+		for tbl, rows := range fileF {
+			f[tbl] = append(f[tbl], rows...)
+		}
+	}
+	return f, nil
+}
+
+// LoadFixtures inserts f into the DB in a single transaction. Tables are
+// populated in the order of AllTableNames so that parent rows exist before
+// the rows referencing them. Fixture columns must exist in their tables.
+func (r *Roach) LoadFixtures(f Fixtures) error {
+	for tbl := range f {
+		if !isKnownTable(tbl) {
+			return errors.NewClientf("fixtures contain unknown table '%s'", tbl)
+		}
+	}
+	if err := r.InitDBIfNot(); err != nil {
+		return err
+	}
+	for tbl, rows := range f {
+		if err := r.validateColumns(tbl, fixtureColumns(rows)); err != nil {
+			return err
+		}
+	}
+
+	// DB errors are returned as is for ExecuteTx to retry and translate
+	// them; they are then annotated with the fixture being inserted.
+	var failed string
+	err := r.ExecuteTx(func(tx *sql.Tx) error {
+		for _, tbl := range AllTableNames {
+			for i, row := range f[tbl] {
+				if err := insertFixture(tx, tbl, row); err != nil {
+					failed = fmt.Sprintf("insert %s fixture %d", tbl, i)
+					return err
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil && failed != "" {
+		return annotateError(failed, err)
+	}
+	return err
+}
+
+// Truncate deletes all rows from all tables in reverse order of
+// AllTableNames. The db version configuration is retained.
+func (r *Roach) Truncate() error {
+	var tbl string
+	err := r.ExecuteTx(func(tx *sql.Tx) error {
+		for i := len(AllTableNames) - 1; i >= 0; i-- {
+			tbl = AllTableNames[i]
+			q := `DELETE FROM ` + tbl
+			var args []interface{}
+			if tbl == TblConfigurations {
+				q = q + ` WHERE ` + ColKey + ` != $1`
+				args = append(args, keyDBVersion)
+			}
+			if _, err := tx.Exec(q, args...); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil && tbl != "" {
+		return annotateError("truncate "+tbl, err)
+	}
+	return err
+}
+
+// fixtureColumns returns the distinct column names used in rows.
+func fixtureColumns(rows []map[string]interface{}) []string {
+	seen := make(map[string]bool)
+	var cols []string
+	for _, row := range rows {
+		for col := range row {
+			if !seen[col] {
+				seen[col] = true
+				cols = append(cols, col)
+			}
+		}
+	}
+	sort.Strings(cols)
+	return cols
+}
+
+func insertFixture(tx *sql.Tx, tbl string, row map[string]interface{}) error {
+	if len(row) == 0 {
+		return errors.NewClient("fixture has no columns")
+	}
+	cols := make([]string, 0, len(row))
+	for col := range row {
+		cols = append(cols, col)
+	}
+	sort.Strings(cols)
+	placeholders := make([]string, len(cols))
+	args := make([]interface{}, len(cols))
+	for i, col := range cols {
+		placeholders[i] = fmt.Sprintf("$%d", i+1)
+		args[i] = row[col]
+	}
+	q := `INSERT INTO ` + tbl + ` (` + ColDesc(cols...) + `)
+		VALUES (` + strings.Join(placeholders, ", ") + `)`
+	_, err := tx.Exec(q, args...)
+	return err
+}
+
+func isKnownTable(tbl string) bool {
+	for _, known := range AllTableNames {
+		if tbl == known {
+			return true
+		}
+	}
+	return false
+}
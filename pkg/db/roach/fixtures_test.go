@@ -0,0 +1,120 @@
+package roach_test
+
+import (
+	"path"
+	"strings"
+	"testing"
+
+	"github.com/tomogoma/seedms/pkg/db/roach"
+)
+
+func TestReadFixtures(t *testing.T) {
+	tt := []struct {
+		name    string
+		files   []string
+		expRows int
+		expErr  bool
+	}{
+		{name: "single file", files: []string{fixturesFile}, expRows: 2},
+		{name: "merged files", files: []string{fixturesFile, fixturesFile}, expRows: 4},
+		{name: "missing file", files: []string{"none_existent.yml"}, expErr: true},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			f, err := roach.ReadFixtures(tc.files...)
+			if tc.expErr {
+				if err == nil {
+					t.Fatalf("Expected an error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Got error: %v", err)
+			}
+			if len(f[roach.TblAPIKeys]) != tc.expRows {
+				t.Errorf("Expected %d %s rows, got %d", tc.expRows,
+					roach.TblAPIKeys, len(f[roach.TblAPIKeys]))
+			}
+		})
+	}
+}
+
+func TestRoach_LoadFixtures(t *testing.T) {
+	conf, tearDown := setup(t)
+	defer tearDown()
+	r := newRoach(t, conf)
+
+	tt := []struct {
+		name     string
+		fixtures roach.Fixtures
+		expErr   bool
+	}{
+		{name: "valid", fixtures: readFixtures(t, fixturesFile), expErr: false},
+		{name: "unknown table", fixtures: roach.Fixtures{"none_existent": nil}, expErr: true},
+		{
+			name: "constraint violation",
+			fixtures: roach.Fixtures{roach.TblAPIKeys: {
+				{roach.ColUserID: 123, roach.ColKey: "short", roach.ColUpdateDate: "2018-01-01T00:00:00Z"},
+			}},
+			expErr: true,
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			truncate(t, r)
+			err := r.LoadFixtures(tc.fixtures)
+			if tc.expErr {
+				if err == nil {
+					t.Fatalf("Expected an error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Got error: %v", err)
+			}
+			key := []byte(strings.Repeat("x", 56))
+			if _, err := r.APIKeyByUserIDVal("123", key); err != nil {
+				t.Fatalf("Get API key loaded from fixtures: %v", err)
+			}
+		})
+	}
+}
+
+func TestRoach_Truncate(t *testing.T) {
+	conf, tearDown := setup(t)
+	defer tearDown()
+	r := newRoach(t, conf)
+	loadFixtures(t, r, fixturesFile)
+
+	truncate(t, r)
+
+	key := []byte(strings.Repeat("x", 56))
+	if _, err := r.APIKeyByUserIDVal("123", key); !r.IsNotFoundError(err) {
+		t.Errorf("Expected not found error after truncate, got %v", err)
+	}
+	if err := r.InitDBIfNot(); err != nil {
+		t.Errorf("DB not usable after truncate: %v", err)
+	}
+}
+
+var fixturesFile = path.Join("testdata", "fixtures.yml")
+
+func readFixtures(t *testing.T, files ...string) roach.Fixtures {
+	f, err := roach.ReadFixtures(files...)
+	if err != nil {
+		t.Fatalf("Error setting up: read fixtures: %v", err)
+	}
+	return f
+}
+
+func loadFixtures(t *testing.T, r *roach.Roach, files ...string) {
+	if err := r.LoadFixtures(readFixtures(t, files...)); err != nil {
+		t.Fatalf("Error setting up: load fixtures: %v", err)
+	}
+}
+
+func truncate(t *testing.T, r *roach.Roach) {
+	if err := r.Truncate(); err != nil {
+		t.Fatalf("Error setting up: truncate: %v", err)
+	}
+}
@@ -14,13 +14,22 @@ func (r *Roach) migrate(fromVersion, toVersion int) error {
 		return fmt.Errorf("connect to db: %v", err)
 	}
 
-	// TODO supported migration logic here e.g.
-	//		if fromVersion == 0 && toVersion == 1 {
-	//			if err := r.migrate0To1(); err != nil { // implement r.migrate0To1()
-	//				return err
-	//			}
-	//			return r.setRunningVersionCurrent()
-	//		}
+	if fromVersion == 0 && toVersion == 1 {
+		if err := r.migrate0To1(); err != nil {
+			return err
+		}
+		return r.setRunningVersionCurrent()
+	}
 
 	return errors.New("not supported")
 }
+
+// migrate0To1 adds the soft-delete column to the API keys table.
+func (r *Roach) migrate0To1() error {
+	q := `ALTER TABLE ` + TblAPIKeys + `
+		ADD COLUMN IF NOT EXISTS ` + ColDeleteDate + ` TIMESTAMPTZ`
+	if _, err := r.db.Exec(q); err != nil {
+		return fmt.Errorf("add %s.%s column: %v", TblAPIKeys, ColDeleteDate, err)
+	}
+	return nil
+}
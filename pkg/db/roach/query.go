@@ -0,0 +1,171 @@
+package roach
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/tomogoma/go-typed-errors"
+)
+
+const (
+	// DefaultPageLimit is the number of rows returned by paginated queries
+	// when none is requested.
+	DefaultPageLimit = 20
+	// MaxPageLimit is the maximum number of rows returned by paginated queries.
+	MaxPageLimit = 100
+
+	// Filter operators.
+	OpEq        = "="
+	OpNotEq     = "!="
+	OpLT        = "<"
+	OpLTE       = "<="
+	OpGT        = ">"
+	OpGTE       = ">="
+	OpIsNull    = "IS NULL"
+	OpIsNotNull = "IS NOT NULL"
+)
+
+// Filter is a condition on a column e.g.
+//     Filter{Col: ColUserID, Op: OpEq, Val: "123"}
+// Val is ignored for OpIsNull and OpIsNotNull.
+type Filter struct {
+	Col string
+	Op  string
+	Val interface{}
+}
+
+// ListQuery describes a keyset paginated SELECT query. Use Build() to get
+// the SQL and its arguments and NextCursor() to get the cursor for the
+// page that follows the one scanned.
+type ListQuery struct {
+	Table string
+	// Cols are the columns to select.
+	Cols []string
+	// KeyCols uniquely identify a row and determine the sort order of results.
+	// The values of the last row in a page are encoded in the cursor.
+	KeyCols []string
+	// Filters are combined using AND.
+	Filters []Filter
+	// SoftDeleteCol is the column holding deletion timestamps. Rows where it
+	// is not NULL are excluded unless IncludeDeleted is true. Leave empty for
+	// tables without soft-delete.
+	SoftDeleteCol  string
+	IncludeDeleted bool
+	// Cursor is the opaque cursor returned by NextCursor() for the previous
+	// page, empty for the first page.
+	Cursor string
+	// Limit is the maximum number of rows in a page. It defaults to
+	// DefaultPageLimit and is capped at MaxPageLimit.
+	Limit int
+}
+
+// Build returns the SQL and its arguments for lq. The query selects one row
+// more than the page limit so that the existence of a next page can be
+// determined by NextCursor().
+func (lq ListQuery) Build() (string, []interface{}, error) {
+
+	if lq.Table == "" || len(lq.Cols) == 0 || len(lq.KeyCols) == 0 {
+		return "", nil, errors.New("table, columns and key columns are required")
+	}
+
+	var conds []string
+	var args []interface{}
+	nextPlaceholder := func(val interface{}) string {
+		args = append(args, val)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	for _, f := range lq.Filters {
+		switch f.Op {
+		case OpIsNull, OpIsNotNull:
+			conds = append(conds, f.Col+" "+f.Op)
+		case OpEq, OpNotEq, OpLT, OpLTE, OpGT, OpGTE:
+			conds = append(conds, f.Col+" "+f.Op+" "+nextPlaceholder(f.Val))
+		default:
+			return "", nil, errors.Newf("unsupported filter operator '%s'", f.Op)
+		}
+	}
+
+	if lq.SoftDeleteCol != "" && !lq.IncludeDeleted {
+		conds = append(conds, lq.SoftDeleteCol+" "+OpIsNull)
+	}
+
+	if lq.Cursor != "" {
+		keyVals, err := DecodeCursor(lq.Cursor)
+		if err != nil {
+			return "", nil, err
+		}
+		if len(keyVals) != len(lq.KeyCols) {
+			return "", nil, errors.NewClient("invalid cursor")
+		}
+		placeholders := make([]string, len(keyVals))
+		for i, val := range keyVals {
+			placeholders[i] = nextPlaceholder(val)
+		}
+		conds = append(conds, "("+ColDesc(lq.KeyCols...)+") > ("+
+			strings.Join(placeholders, ", ")+")")
+	}
+
+	q := `SELECT ` + ColDesc(lq.Cols...) + ` FROM ` + lq.Table
+	if len(conds) > 0 {
+		q = q + ` WHERE ` + strings.Join(conds, " AND ")
+	}
+	q = q + ` ORDER BY ` + ColDesc(lq.KeyCols...) +
+		` LIMIT ` + nextPlaceholder(lq.PageLimit()+1)
+	return q, args, nil
+}
+
+// NextCursor returns the cursor for the page following the one containing
+// numRows rows, or an empty string if there is none. lastKeyVals are the
+// KeyCols values of the last row in the page. numRows should be the number
+// of rows scanned from the query returned by Build().
+func (lq ListQuery) NextCursor(numRows int, lastKeyVals ...interface{}) string {
+	if numRows <= lq.PageLimit() {
+		return ""
+	}
+	return EncodeCursor(lastKeyVals...)
+}
+
+// PageLimit returns the number of rows that belong in a page of lq,
+// callers should discard rows scanned beyond this number.
+func (lq ListQuery) PageLimit() int {
+	if lq.Limit <= 0 {
+		return DefaultPageLimit
+	}
+	if lq.Limit > MaxPageLimit {
+		return MaxPageLimit
+	}
+	return lq.Limit
+}
+
+// EncodeCursor returns an opaque cursor for keyVals.
+func EncodeCursor(keyVals ...interface{}) string {
+	encVals := make([]*string, len(keyVals))
+	for i, val := range keyVals {
+		encVals[i] = encodeValue(val, "")
+	}
+	valsB, _ := json.Marshal(encVals)
+	return base64.RawURLEncoding.EncodeToString(valsB)
+}
+
+// DecodeCursor returns the key values encoded in cursor by EncodeCursor().
+func DecodeCursor(cursor string) ([]interface{}, error) {
+	valsB, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, errors.NewClient("invalid cursor")
+	}
+	var encVals []*string
+	if err := json.Unmarshal(valsB, &encVals); err != nil {
+		return nil, errors.NewClient("invalid cursor")
+	}
+	vals := make([]interface{}, len(encVals))
+	for i, val := range encVals {
+		if val == nil {
+			continue
+		}
+		vals[i] = *val
+	}
+	return vals, nil
+}
@@ -0,0 +1,123 @@
+package roach_test
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/tomogoma/seedms/pkg/db/roach"
+)
+
+func TestListQuery_Build(t *testing.T) {
+	cursor := roach.EncodeCursor(time.Date(2018, 1, 2, 3, 4, 5, 0, time.UTC), "42")
+	tt := []struct {
+		name    string
+		lq      roach.ListQuery
+		expQ    string
+		expArgs []interface{}
+		expErr  bool
+	}{
+		{
+			name: "first page",
+			lq: roach.ListQuery{
+				Table:   "tbl",
+				Cols:    []string{"a", "b"},
+				KeyCols: []string{"a"},
+			},
+			expQ:    "SELECT a, b FROM tbl ORDER BY a LIMIT $1",
+			expArgs: []interface{}{roach.DefaultPageLimit + 1},
+		},
+		{
+			name: "filtered soft-delete page after cursor",
+			lq: roach.ListQuery{
+				Table:   "tbl",
+				Cols:    []string{"a", "b", "c"},
+				KeyCols: []string{"b", "a"},
+				Filters: []roach.Filter{
+					{Col: "c", Op: roach.OpEq, Val: "x"},
+					{Col: "d", Op: roach.OpIsNotNull},
+				},
+				SoftDeleteCol: "deleted",
+				Cursor:        cursor,
+				Limit:         roach.MaxPageLimit + 1,
+			},
+			expQ: "SELECT a, b, c FROM tbl WHERE c = $1 AND d IS NOT NULL" +
+				" AND deleted IS NULL AND (b, a) > ($2, $3) ORDER BY b, a LIMIT $4",
+			expArgs: []interface{}{"x", "2018-01-02T03:04:05Z", "42", roach.MaxPageLimit + 1},
+		},
+		{
+			name: "include deleted",
+			lq: roach.ListQuery{
+				Table:          "tbl",
+				Cols:           []string{"a"},
+				KeyCols:        []string{"a"},
+				SoftDeleteCol:  "deleted",
+				IncludeDeleted: true,
+				Limit:          5,
+			},
+			expQ:    "SELECT a FROM tbl ORDER BY a LIMIT $1",
+			expArgs: []interface{}{6},
+		},
+		{
+			name: "cursor key count mismatch",
+			lq: roach.ListQuery{
+				Table:   "tbl",
+				Cols:    []string{"a"},
+				KeyCols: []string{"a"},
+				Cursor:  cursor,
+			},
+			expErr: true,
+		},
+		{
+			name:   "invalid cursor",
+			lq:     roach.ListQuery{Table: "tbl", Cols: []string{"a"}, KeyCols: []string{"a"}, Cursor: "!"},
+			expErr: true,
+		},
+		{
+			name: "bad operator",
+			lq: roach.ListQuery{Table: "tbl", Cols: []string{"a"}, KeyCols: []string{"a"},
+				Filters: []roach.Filter{{Col: "a", Op: "LIKE", Val: "%"}}},
+			expErr: true,
+		},
+		{
+			name:   "missing key columns",
+			lq:     roach.ListQuery{Table: "tbl", Cols: []string{"a"}},
+			expErr: true,
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			q, args, err := tc.lq.Build()
+			if tc.expErr {
+				if err == nil {
+					t.Fatalf("Expected an error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Got error: %v", err)
+			}
+			if q != tc.expQ {
+				t.Errorf("Query mismatch:\nExpect:\t%s\nGot:\t%s", tc.expQ, q)
+			}
+			if !reflect.DeepEqual(args, tc.expArgs) {
+				t.Errorf("Args mismatch:\nExpect:\t%#v\nGot:\t%#v", tc.expArgs, args)
+			}
+		})
+	}
+}
+
+func TestListQuery_NextCursor(t *testing.T) {
+	lq := roach.ListQuery{Limit: 2}
+	if c := lq.NextCursor(2, "1"); c != "" {
+		t.Errorf("Expected no cursor for the last page, got %s", c)
+	}
+	c := lq.NextCursor(3, "1")
+	vals, err := roach.DecodeCursor(c)
+	if err != nil {
+		t.Fatalf("Decode cursor: %v", err)
+	}
+	if !reflect.DeepEqual(vals, []interface{}{"1"}) {
+		t.Errorf("Expected cursor values [1], got %v", vals)
+	}
+}
@@ -7,21 +7,33 @@ import (
 	"fmt"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/cockroachdb/cockroach-go/crdb"
 	crdbH "github.com/tomogoma/crdb"
 	"github.com/tomogoma/go-typed-errors"
 	"github.com/tomogoma/seedms/pkg/config"
+	"github.com/tomogoma/seedms/pkg/logging"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
 )
 
 // Roach is a cockroach db store.
 // Use NewRoach() to instantiate.
 type Roach struct {
 	errors.NotFoundErrCheck
+	errors.ClErrCheck
+	errors.ConflictErrCheck
+	errors.RetryableErrCheck
 	dsn              string
 	dbName           string
 	db               *sql.DB
 	compatibilityErr error
+	observeQuery     QueryObserver
+	tracer           trace.Tracer
+	logger           logging.Logger
 
 	isDBInitMutex sync.Mutex
 	isDBInit      bool
@@ -29,6 +41,8 @@ type Roach struct {
 
 const (
 	keyDBVersion = "db.version"
+
+	tracerName = "github.com/tomogoma/seedms/pkg/db/roach"
 )
 
 // NewRoach creates an instance of *Roach. A db connection is only established
@@ -38,6 +52,7 @@ func NewRoach(opts ...Option) *Roach {
 		isDBInit:      false,
 		isDBInitMutex: sync.Mutex{},
 		dbName:        config.CanonicalName(),
+		tracer:        noop.NewTracerProvider().Tracer(tracerName),
 	}
 	for _, f := range opts {
 		f(r)
@@ -57,11 +72,80 @@ func (r *Roach) InitDBIfNot() error {
 
 // ExecuteTx prepares a transaction (with retries) for execution in fn.
 // It commits the changes if fn returns nil, otherwise changes are rolled back.
+// DB errors are translated into typed errors (see translateError).
 func (r *Roach) ExecuteTx(fn func(*sql.Tx) error) error {
 	if err := r.InitDBIfNot(); err != nil {
 		return err
 	}
-	return crdb.ExecuteTx(context.Background(), r.db, nil, fn)
+	ctx, done := r.instrument(context.Background(), "ExecuteTx")
+	err := crdb.ExecuteTx(ctx, r.db, nil, fn)
+	done(err)
+	return translateError(err)
+}
+
+// instrument starts a span (a child of any span in ctx) for the DB
+// operation op. The returned func ends the span and notifies the
+// QueryObserver, if any, of the operation's duration and resulting err.
+// sql.ErrNoRows is reported as success. err must not yet be translated for
+// its detail to be logged (see logErrorDetail).
+func (r *Roach) instrument(ctx context.Context, op string) (context.Context, func(err error)) {
+	start := time.Now()
+	ctx, span := r.tracer.Start(ctx, op,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.DBSystemCockroachdb,
+			semconv.DBName(r.dbName),
+			semconv.DBOperation(op),
+		),
+	)
+	return ctx, func(err error) {
+		if err == sql.ErrNoRows {
+			err = nil
+		}
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+			r.logErrorDetail(op, err)
+		}
+		span.End()
+		if r.observeQuery != nil {
+			r.observeQuery(op, time.Since(start), err)
+		}
+	}
+}
+
+// Ready returns an error if the DB cannot be reached or its schema version
+// is not the one this version of the micro-service expects.
+func (r *Roach) Ready(ctx context.Context) error {
+	if err := r.InitDBIfNot(); err != nil {
+		return err
+	}
+	if err := r.db.PingContext(ctx); err != nil {
+		return errors.Newf("ping db: %v", err)
+	}
+	runningVersion, err := r.runningVersion(ctx)
+	if err != nil {
+		return err
+	}
+	if runningVersion != Version {
+		return errors.Newf("db incompatible: need db version '%d', found '%d'",
+			Version, runningVersion)
+	}
+	return nil
+}
+
+// Close closes the DB connections, if any. A later call to InitDBIfNot()
+// or one of the Execute/Query methods reconnects.
+func (r *Roach) Close() error {
+	r.isDBInitMutex.Lock()
+	defer r.isDBInitMutex.Unlock()
+	if r.db == nil {
+		return nil
+	}
+	err := r.db.Close()
+	r.db = nil
+	r.isDBInit = false
+	return err
 }
 
 // ColDesc returns a string containing cols in the given order separated by ",".
@@ -107,10 +191,24 @@ func (r *Roach) instantiate() error {
 }
 
 func (r *Roach) validateRunningVersion() (int, error) {
+	runningVersion, err := r.runningVersion(context.Background())
+	if err != nil {
+		return -1, err
+	}
+	if runningVersion != Version {
+		r.compatibilityErr = errors.Newf("db incompatible: need db"+
+			" version '%d', found '%d'", Version, runningVersion)
+		return runningVersion, r.compatibilityErr
+	}
+	return runningVersion, nil
+}
+
+// runningVersion returns the schema version recorded in the DB.
+func (r *Roach) runningVersion(ctx context.Context) (int, error) {
 	var runningVersion int
 	q := `SELECT ` + ColValue + ` FROM ` + TblConfigurations + ` WHERE ` + ColKey + `=$1`
 	var confB []byte
-	if err := r.db.QueryRow(q, keyDBVersion).Scan(&confB); err != nil {
+	if err := r.db.QueryRowContext(ctx, q, keyDBVersion).Scan(&confB); err != nil {
 		if err == sql.ErrNoRows {
 			return -1, errors.NewNotFoundf("config not found")
 		}
@@ -119,11 +217,6 @@ func (r *Roach) validateRunningVersion() (int, error) {
 	if err := json.Unmarshal(confB, &runningVersion); err != nil {
 		return -1, errors.Newf("Unmarshalling config: %v", err)
 	}
-	if runningVersion != Version {
-		r.compatibilityErr = errors.Newf("db incompatible: need db"+
-			" version '%d', found '%d'", Version, runningVersion)
-		return runningVersion, r.compatibilityErr
-	}
 	return runningVersion, nil
 }
 
@@ -1,5 +1,12 @@
 package roach
 
+import (
+	"time"
+
+	"github.com/tomogoma/seedms/pkg/logging"
+	"go.opentelemetry.io/otel/trace"
+)
+
 // Option allows extra configuration for instantiating Roach. Use the With...
 // functions to set options e.g.
 //     nameOpt := WithDBName("my_app_db")
@@ -18,3 +25,32 @@ func WithDBName(db string) Option {
 		r.dbName = db
 	}
 }
+
+// QueryObserver is notified of the duration and outcome of DB operations
+// e.g. to record metrics. op names the operation e.g. "APIKeyByUserIDVal".
+type QueryObserver func(op string, d time.Duration, err error)
+
+// WithQueryObserver sets the QueryObserver to be notified of DB operations
+// performed by Roach.
+func WithQueryObserver(o QueryObserver) Option {
+	return func(r *Roach) {
+		r.observeQuery = o
+	}
+}
+
+// WithTracerProvider sets the trace.TracerProvider used to create spans for
+// DB operations performed by Roach. No spans are created by default.
+func WithTracerProvider(tp trace.TracerProvider) Option {
+	return func(r *Roach) {
+		r.tracer = tp.Tracer(tracerName)
+	}
+}
+
+// WithLogger sets the logging.Logger used to log the details of DB errors,
+// which are left out of the errors returned to callers. The details are not
+// logged by default.
+func WithLogger(lg logging.Logger) Option {
+	return func(r *Roach) {
+		r.logger = lg
+	}
+}
@@ -2,7 +2,7 @@ package roach
 
 const (
 	// Database definition version
-	Version = 0
+	Version = 1
 
 	// Table names
 	TblConfigurations = "configurations"
@@ -12,6 +12,7 @@ const (
 	ColID         = "ID"
 	ColCreateDate = "createDate"
 	ColUpdateDate = "updateDate"
+	ColDeleteDate = "deleteDate"
 	ColUserID     = "userID"
 	ColKey        = "key"
 	ColValue      = "value"
@@ -31,7 +32,8 @@ const (
 		` + ColUserID + ` INTEGER NOT NULL,
 		` + ColKey + ` VARCHAR(256) NOT NULL CHECK ( LENGTH(` + ColKey + `) >= 56 ),
 		` + ColCreateDate + ` TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
-		` + ColUpdateDate + ` TIMESTAMPTZ NOT NULL
+		` + ColUpdateDate + ` TIMESTAMPTZ NOT NULL,
+		` + ColDeleteDate + ` TIMESTAMPTZ
 	);
 	`
 )
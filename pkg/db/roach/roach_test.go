@@ -1,6 +1,7 @@
 package roach_test
 
 import (
+	"context"
 	"database/sql"
 	"strconv"
 	"testing"
@@ -103,9 +104,15 @@ func TestRoach_InitDBIfNot(t *testing.T) {
 			expErr:     false,
 		},
 		{
-			name:       "db version smaller",
+			name:       "db version smaller (migrated)",
 			hasVersion: true,
 			version:    []byte(strconv.Itoa(roach.Version - 1)),
+			expErr:     false,
+		},
+		{
+			name:       "db version smaller (migration not supported)",
+			hasVersion: true,
+			version:    []byte(strconv.Itoa(-1)),
 			expErr:     true,
 		},
 		{
@@ -166,6 +173,62 @@ func TestRoach_InitDBIfNot(t *testing.T) {
 	}
 }
 
+func TestRoach_Close(t *testing.T) {
+
+	conf, tearDown := setup(t)
+	defer tearDown()
+
+	r := newRoach(t, conf)
+	if err := r.Close(); err != nil {
+		t.Fatalf("Close() before connecting: %v", err)
+	}
+	if err := r.InitDBIfNot(); err != nil {
+		t.Fatalf("Error setting up: init DB: %v", err)
+	}
+	if err := r.Close(); err != nil {
+		t.Fatalf("Close(): %v", err)
+	}
+	if err := r.Close(); err != nil {
+		t.Fatalf("Close() when closed: %v", err)
+	}
+	if err := r.InitDBIfNot(); err != nil {
+		t.Fatalf("InitDBIfNot() after Close(): %v", err)
+	}
+	r.Close()
+}
+
+func TestRoach_Ready(t *testing.T) {
+
+	conf, tearDown := setup(t)
+	defer tearDown()
+
+	r := newRoach(t, conf)
+	defer r.Close()
+	rdb := getDB(t, conf)
+	defer rdb.Close()
+
+	if err := r.Ready(context.Background()); err != nil {
+		t.Fatalf("Ready(): %v", err)
+	}
+
+	updQ := `
+		UPDATE ` + roach.TblConfigurations + ` SET ` + roach.ColValue + `=$1
+			WHERE ` + roach.ColKey + `='db.version'`
+	if _, err := rdb.Exec(updQ, []byte(strconv.Itoa(roach.Version+1))); err != nil {
+		t.Fatalf("Error setting up: update db version: %v", err)
+	}
+	if err := r.Ready(context.Background()); err == nil {
+		t.Errorf("Expected an error for an incompatible db version, got nil")
+	}
+
+	if _, err := rdb.Exec(updQ, []byte(strconv.Itoa(roach.Version))); err != nil {
+		t.Fatalf("Error setting up: update db version: %v", err)
+	}
+	if err := r.Ready(context.Background()); err != nil {
+		t.Errorf("Ready() after restoring db version: %v", err)
+	}
+}
+
 func newRoach(t *testing.T, conf crdb.Config) *roach.Roach {
 	r := roach.NewRoach(
 		roach.WithDBName(conf.DBName),
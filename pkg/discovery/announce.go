@@ -0,0 +1,103 @@
+package discovery
+
+import (
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/micro/go-micro/registry"
+	"github.com/pborman/uuid"
+	"github.com/tomogoma/go-typed-errors"
+)
+
+// Announce registers a node of the service name (at version) listening on
+// addr with r, as go-micro services register themselves, for servers that
+// are not go-micro services e.g. a plain net/http server. The node is
+// registered again every interval, with a TTL of twice the interval, until
+// the returned deregister func is called. A zero interval registers the
+// node once without a TTL. Errors after the first registration are passed
+// to onErr.
+func Announce(r registry.Registry, name, version string, addr net.Addr, interval time.Duration, onErr func(error)) (deregister func() error, err error) {
+	node, err := newNode(name, addr)
+	if err != nil {
+		return nil, err
+	}
+	svc := &registry.Service{Name: name, Version: version, Nodes: []*registry.Node{node}}
+	var opts []registry.RegisterOption
+	if interval > 0 {
+		opts = append(opts, registry.RegisterTTL(2*interval))
+	}
+	if err := r.Register(svc, opts...); err != nil {
+		return nil, errors.Newf("register %s: %v", name, err)
+	}
+
+	stop := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		if interval <= 0 {
+			return
+		}
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				if err := r.Register(svc, opts...); err != nil {
+					onErr(errors.Newf("register %s: %v", name, err))
+				}
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() error {
+		err := errors.Newf("%s already deregistered", name)
+		once.Do(func() {
+			close(stop)
+			<-stopped
+			err = r.Deregister(svc)
+			if err != nil {
+				err = errors.Newf("deregister %s: %v", name, err)
+			}
+		})
+		return err
+	}, nil
+}
+
+// newNode returns a registry.Node of the service name listening on addr.
+// An unspecified host (e.g. ":8080") is replaced with the first non-loopback
+// IPv4 address of the machine.
+func newNode(name string, addr net.Addr) (*registry.Node, error) {
+	host, portStr, err := net.SplitHostPort(addr.String())
+	if err != nil {
+		return nil, errors.Newf("parse listen address: %v", err)
+	}
+	port, err := strconv.Atoi(portStr)
+	if err != nil {
+		return nil, errors.Newf("parse listen port: %v", err)
+	}
+	if ip := net.ParseIP(host); host == "" || (ip != nil && ip.IsUnspecified()) {
+		if host, err = externalIP(); err != nil {
+			return nil, err
+		}
+	}
+	return &registry.Node{Id: name + "-" + uuid.New(), Address: host, Port: port}, nil
+}
+
+func externalIP() (string, error) {
+	addrs, err := net.InterfaceAddrs()
+	if err != nil {
+		return "", errors.Newf("list interface addresses: %v", err)
+	}
+	for _, a := range addrs {
+		ipNet, ok := a.(*net.IPNet)
+		if ok && !ipNet.IP.IsLoopback() && ipNet.IP.To4() != nil {
+			return ipNet.IP.String(), nil
+		}
+	}
+	return "127.0.0.1", nil
+}
@@ -0,0 +1,72 @@
+package discovery
+
+import (
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/micro/go-micro/registry"
+)
+
+func TestAnnounce(t *testing.T) {
+	tt := []struct {
+		name       string
+		addr       net.Addr
+		expAddress string
+		expErr     bool
+	}{
+		{name: "host", addr: &net.TCPAddr{IP: net.IPv4(127, 0, 0, 2), Port: 8080}, expAddress: "127.0.0.2"},
+		{name: "unspecified host", addr: &net.TCPAddr{IP: net.IPv4zero, Port: 8080}},
+		{name: "invalid address", addr: &net.UnixAddr{Name: "/tmp/sock", Net: "unix"}, expErr: true},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			s := mustNewStatic(t, filepath.Join(t.TempDir(), "registry.json"))
+			errCh := make(chan error, 10)
+
+			deregister, err := Announce(s, "web", "1", tc.addr, 50*time.Millisecond, func(err error) { errCh <- err })
+			if tc.expErr {
+				if err == nil {
+					t.Fatal("Expected an error but got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Got error: %v", err)
+			}
+
+			services, err := s.GetService("web")
+			if err != nil {
+				t.Fatalf("GetService(): %v", err)
+			}
+			node := services[0].Nodes[0]
+			if node.Port != 8080 {
+				t.Errorf("Expected port 8080, got %d", node.Port)
+			}
+			if tc.expAddress != "" && node.Address != tc.expAddress {
+				t.Errorf("Expected address %s, got %s", tc.expAddress, node.Address)
+			}
+			if ip := net.ParseIP(node.Address); ip == nil || ip.IsUnspecified() {
+				t.Errorf("Expected a specific IP address, got '%s'", node.Address)
+			}
+
+			// Outlive a re-registration.
+			time.Sleep(60 * time.Millisecond)
+			if err := deregister(); err != nil {
+				t.Fatalf("deregister(): %v", err)
+			}
+			if _, err = s.GetService("web"); err != registry.ErrNotFound {
+				t.Errorf("Expected node to be deregistered, GetService() got %v", err)
+			}
+			if err := deregister(); err == nil {
+				t.Error("Expected an error deregistering twice but got nil")
+			}
+			select {
+			case err := <-errCh:
+				t.Errorf("Got error while announcing: %v", err)
+			default:
+			}
+		})
+	}
+}
@@ -0,0 +1,82 @@
+// Package discovery provides the go-micro registry.Registry the
+// micro-service's go-micro services are registered with and discovered
+// through, as configured in config.Registry.
+package discovery
+
+import (
+	"sync"
+
+	"github.com/micro/go-micro/registry"
+	"github.com/micro/go-micro/registry/mdns"
+	"github.com/tomogoma/go-typed-errors"
+	"github.com/tomogoma/seedms/pkg/config"
+)
+
+// Registry backends.
+const (
+	// BackendDefault is go-micro's default registry (Consul).
+	BackendDefault = ""
+	// BackendMDNS discovers services using multicast DNS.
+	BackendMDNS = "mdns"
+	// BackendStatic keeps services in a file shared by the services on a
+	// machine, see Static.
+	BackendStatic = "static"
+)
+
+// Factory creates the registry.Registry of a backend as configured in conf.
+type Factory func(conf config.Registry) (registry.Registry, error)
+
+var (
+	factoriesMu sync.RWMutex
+	factories   = map[string]Factory{
+		BackendDefault: newDefault,
+		BackendMDNS:    newMDNS,
+		BackendStatic:  newStatic,
+	}
+)
+
+// Register makes the registry backend created by f available to New under
+// the name backend e.g. to use a go-plugins registry:
+//     discovery.Register("etcd", func(conf config.Registry) (registry.Registry, error) {
+//         return etcd.NewRegistry(registry.Addrs(conf.Addresses...)), nil
+//     })
+// It replaces any backend previously registered under the same name.
+func Register(backend string, f Factory) {
+	factoriesMu.Lock()
+	defer factoriesMu.Unlock()
+	factories[backend] = f
+}
+
+// New creates the registry.Registry of the backend in conf.
+func New(conf config.Registry) (registry.Registry, error) {
+	factoriesMu.RLock()
+	f, ok := factories[conf.Backend]
+	factoriesMu.RUnlock()
+	if !ok {
+		return nil, errors.Newf("unknown registry backend '%s'", conf.Backend)
+	}
+	r, err := f(conf)
+	if err != nil {
+		return nil, errors.Newf("create %s registry: %v", conf.Backend, err)
+	}
+	return r, nil
+}
+
+func newDefault(conf config.Registry) (registry.Registry, error) {
+	if len(conf.Addresses) == 0 {
+		return registry.DefaultRegistry, nil
+	}
+	return registry.NewRegistry(registry.Addrs(conf.Addresses...)), nil
+}
+
+func newMDNS(conf config.Registry) (registry.Registry, error) {
+	return mdns.NewRegistry(registry.Addrs(conf.Addresses...)), nil
+}
+
+func newStatic(conf config.Registry) (registry.Registry, error) {
+	var opts []StaticOption
+	if conf.PollInterval > 0 {
+		opts = append(opts, WithPollInterval(conf.PollInterval))
+	}
+	return NewStatic(conf.File, opts...)
+}
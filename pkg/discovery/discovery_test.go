@@ -0,0 +1,54 @@
+package discovery
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/micro/go-micro/registry"
+	"github.com/tomogoma/go-typed-errors"
+	"github.com/tomogoma/seedms/pkg/config"
+)
+
+func TestNew(t *testing.T) {
+	Register("failing", func(config.Registry) (registry.Registry, error) {
+		return nil, errors.New("no connection")
+	})
+	static := &Static{}
+	Register("custom", func(config.Registry) (registry.Registry, error) {
+		return static, nil
+	})
+	tt := []struct {
+		name   string
+		conf   config.Registry
+		exp    registry.Registry
+		expErr bool
+	}{
+		{name: "default", conf: config.Registry{}, exp: registry.DefaultRegistry},
+		{name: "mdns", conf: config.Registry{Backend: BackendMDNS}},
+		{name: "static", conf: config.Registry{Backend: BackendStatic,
+			File: filepath.Join(t.TempDir(), "registry.json")}},
+		{name: "registered backend", conf: config.Registry{Backend: "custom"}, exp: static},
+		{name: "registered backend error", conf: config.Registry{Backend: "failing"}, expErr: true},
+		{name: "unknown backend", conf: config.Registry{Backend: "zookeeper"}, expErr: true},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			r, err := New(tc.conf)
+			if tc.expErr {
+				if err == nil {
+					t.Fatal("Expected an error but got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Got error: %v", err)
+			}
+			if r == nil {
+				t.Fatal("Got nil registry.Registry")
+			}
+			if tc.exp != nil && r != tc.exp {
+				t.Errorf("Expected registry %v, got %v", tc.exp, r)
+			}
+		})
+	}
+}
@@ -0,0 +1,381 @@
+package discovery
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/micro/go-micro/registry"
+	"github.com/tomogoma/go-typed-errors"
+)
+
+// DefaultPollInterval is how often watchers of a Static registry re-read
+// its file by default.
+const DefaultPollInterval = time.Second
+
+// metaExpires is the node metadata entry holding the (RFC3339) time a node
+// registered with a TTL expires if not registered again.
+const metaExpires = "registryExpires"
+
+// DefaultStaticFile is the file of a Static registry if none is given.
+var DefaultStaticFile = filepath.Join(os.TempDir(), "go-micro-registry.json")
+
+// Static is a registry.Registry keeping services in a JSON file (a list of
+// registry.Service) so that services on one machine discover each other
+// without a registry server. Writes are serialized across processes using
+// a lock on a sibling ".lock" file (flock on Unix, LockFileEx on Windows,
+// see static_unix.go and static_windows.go) and readers never see partial
+// writes. Nodes registered with a TTL are ignored once it expires; nodes
+// without one, e.g. written to the file by hand, never expire.
+// Use NewStatic() to instantiate.
+type Static struct {
+	file     string
+	interval time.Duration
+	mu       sync.Mutex
+}
+
+// StaticOption allows extra configuration for NewStatic. Use the With...
+// functions to set options.
+type StaticOption func(*Static)
+
+// WithPollInterval sets how often watchers re-read the file.
+// Defaults to DefaultPollInterval.
+func WithPollInterval(d time.Duration) StaticOption {
+	return func(s *Static) {
+		s.interval = d
+	}
+}
+
+// NewStatic creates a Static registry keeping services in file, or
+// DefaultStaticFile if file is empty. file is created on first
+// registration if it does not exist.
+func NewStatic(file string, opts ...StaticOption) (*Static, error) {
+	if file == "" {
+		file = DefaultStaticFile
+	}
+	s := &Static{file: file, interval: DefaultPollInterval}
+	for _, f := range opts {
+		f(s)
+	}
+	if s.interval <= 0 {
+		return nil, errors.New("poll interval must be greater than zero")
+	}
+	if _, err := s.read(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Register adds the nodes of svc to the file, replacing the metadata and
+// endpoints of any service of the same name and version.
+func (s *Static) Register(svc *registry.Service, opts ...registry.RegisterOption) error {
+	var o registry.RegisterOptions
+	for _, f := range opts {
+		f(&o)
+	}
+	var expires string
+	if o.TTL > 0 {
+		expires = time.Now().Add(o.TTL).Format(time.RFC3339Nano)
+	}
+	nodes := make([]*registry.Node, len(svc.Nodes))
+	for i, n := range svc.Nodes {
+		nodes[i] = copyNode(n, expires)
+	}
+	return s.update(func(services []*registry.Service) []*registry.Service {
+		for _, existing := range services {
+			if existing.Name != svc.Name || existing.Version != svc.Version {
+				continue
+			}
+			existing.Metadata = svc.Metadata
+			existing.Endpoints = svc.Endpoints
+			existing.Nodes = mergeNodes(existing.Nodes, nodes)
+			return services
+		}
+		return append(services, &registry.Service{
+			Name:      svc.Name,
+			Version:   svc.Version,
+			Metadata:  svc.Metadata,
+			Endpoints: svc.Endpoints,
+			Nodes:     nodes,
+		})
+	})
+}
+
+// Deregister removes the nodes of svc from the file, and the service once
+// it has no nodes left.
+func (s *Static) Deregister(svc *registry.Service) error {
+	return s.update(func(services []*registry.Service) []*registry.Service {
+		var kept []*registry.Service
+		for _, existing := range services {
+			if existing.Name == svc.Name && existing.Version == svc.Version {
+				existing.Nodes = removeNodes(existing.Nodes, svc.Nodes)
+				if len(existing.Nodes) == 0 {
+					continue
+				}
+			}
+			kept = append(kept, existing)
+		}
+		return kept
+	})
+}
+
+// GetService returns the versions of the service name that have nodes.
+func (s *Static) GetService(name string) ([]*registry.Service, error) {
+	services, err := s.read()
+	if err != nil {
+		return nil, err
+	}
+	var found []*registry.Service
+	for _, svc := range services {
+		if svc.Name == name {
+			found = append(found, svc)
+		}
+	}
+	if len(found) == 0 {
+		return nil, registry.ErrNotFound
+	}
+	return found, nil
+}
+
+// ListServices returns all the services in the file that have nodes.
+func (s *Static) ListServices() ([]*registry.Service, error) {
+	return s.read()
+}
+
+// Watch returns a registry.Watcher reporting changes to the file's
+// services (or only the service in opts) from now on.
+func (s *Static) Watch(opts ...registry.WatchOption) (registry.Watcher, error) {
+	var o registry.WatchOptions
+	for _, f := range opts {
+		f(&o)
+	}
+	w := &staticWatcher{static: s, service: o.Service, stop: make(chan struct{})}
+	services, err := s.read()
+	if err != nil {
+		return nil, err
+	}
+	w.last = w.index(services)
+	return w, nil
+}
+
+func (s *Static) String() string {
+	return BackendStatic
+}
+
+// read returns the services in the file without the expired nodes and the
+// services left with no nodes.
+func (s *Static) read() ([]*registry.Service, error) {
+	services, err := s.readAll()
+	if err != nil {
+		return nil, err
+	}
+	return prune(services, time.Now()), nil
+}
+
+func (s *Static) readAll() ([]*registry.Service, error) {
+	data, err := ioutil.ReadFile(s.file)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, errors.Newf("read registry file: %v", err)
+	}
+	var services []*registry.Service
+	if len(data) == 0 {
+		return services, nil
+	}
+	if err := json.Unmarshal(data, &services); err != nil {
+		return nil, errors.Newf("unmarshal registry file (%s) contents: %v", s.file, err)
+	}
+	return services, nil
+}
+
+// update replaces the services in the file with those returned by f while
+// holding the file's lock.
+func (s *Static) update(f func([]*registry.Service) []*registry.Service) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	lock, err := os.OpenFile(s.file+".lock", os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return errors.Newf("open registry lock file: %v", err)
+	}
+	defer lock.Close()
+	if err := lockFile(lock); err != nil {
+		return errors.Newf("lock registry file: %v", err)
+	}
+	defer unlockFile(lock)
+
+	services, err := s.readAll()
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(f(prune(services, time.Now())), "", "  ")
+	if err != nil {
+		return errors.Newf("marshal registry: %v", err)
+	}
+
+	// Write to a temporary file and rename it over the file so that
+	// readers, which do not take the lock, never see partial writes.
+	tmp, err := ioutil.TempFile(filepath.Dir(s.file), filepath.Base(s.file)+".tmp")
+	if err != nil {
+		return errors.Newf("create temporary registry file: %v", err)
+	}
+	defer os.Remove(tmp.Name())
+	_, err = tmp.Write(data)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return errors.Newf("write temporary registry file: %v", err)
+	}
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		return errors.Newf("set registry file permissions: %v", err)
+	}
+	if err := os.Rename(tmp.Name(), s.file); err != nil {
+		return errors.Newf("replace registry file: %v", err)
+	}
+	return nil
+}
+
+// staticWatcher is the registry.Watcher of a Static registry. It re-reads
+// the file every poll interval and reports the services that changed.
+type staticWatcher struct {
+	static   *Static
+	service  string
+	last     map[string]*registry.Service
+	pending  []*registry.Result
+	stop     chan struct{}
+	stopOnce sync.Once
+}
+
+func (w *staticWatcher) Next() (*registry.Result, error) {
+	for len(w.pending) == 0 {
+		select {
+		case <-w.stop:
+			return nil, errors.New("watcher stopped")
+		case <-time.After(w.static.interval):
+		}
+		services, err := w.static.read()
+		if err != nil {
+			return nil, err
+		}
+		current := w.index(services)
+		w.pending = diff(w.last, current)
+		w.last = current
+	}
+	r := w.pending[0]
+	w.pending = w.pending[1:]
+	return r, nil
+}
+
+func (w *staticWatcher) Stop() {
+	w.stopOnce.Do(func() { close(w.stop) })
+}
+
+// index maps the watched services by name and version.
+func (w *staticWatcher) index(services []*registry.Service) map[string]*registry.Service {
+	idx := make(map[string]*registry.Service)
+	for _, svc := range services {
+		if w.service == "" || svc.Name == w.service {
+			idx[svc.Name+"@"+svc.Version] = svc
+		}
+	}
+	return idx
+}
+
+// diff returns the "create", "update" and "delete" results that turn
+// services from into services to.
+func diff(from, to map[string]*registry.Service) []*registry.Result {
+	var results []*registry.Result
+	for key, svc := range to {
+		prev, ok := from[key]
+		switch {
+		case !ok:
+			results = append(results, &registry.Result{Action: "create", Service: svc})
+		case !reflect.DeepEqual(prev, svc):
+			results = append(results, &registry.Result{Action: "update", Service: svc})
+		}
+	}
+	for key, svc := range from {
+		if _, ok := to[key]; !ok {
+			results = append(results, &registry.Result{Action: "delete", Service: svc})
+		}
+	}
+	return results
+}
+
+// prune removes the nodes that expired before now from services and the
+// services left with no nodes.
+func prune(services []*registry.Service, now time.Time) []*registry.Service {
+	var kept []*registry.Service
+	for _, svc := range services {
+		var nodes []*registry.Node
+		for _, n := range svc.Nodes {
+			if expires, err := time.Parse(time.RFC3339Nano, n.Metadata[metaExpires]); err == nil && expires.Before(now) {
+				continue
+			}
+			nodes = append(nodes, n)
+		}
+		if len(nodes) == 0 {
+			continue
+		}
+		svc.Nodes = nodes
+		kept = append(kept, svc)
+	}
+	return kept
+}
+
+// mergeNodes replaces the nodes having the ID of any of add and appends the
+// rest of add.
+func mergeNodes(nodes, add []*registry.Node) []*registry.Node {
+	for _, a := range add {
+		replaced := false
+		for i, n := range nodes {
+			if n.Id == a.Id {
+				nodes[i] = a
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			nodes = append(nodes, a)
+		}
+	}
+	return nodes
+}
+
+// removeNodes returns the nodes not having the ID of any of remove.
+func removeNodes(nodes, remove []*registry.Node) []*registry.Node {
+	var kept []*registry.Node
+	for _, n := range nodes {
+		removed := false
+		for _, r := range remove {
+			if n.Id == r.Id {
+				removed = true
+				break
+			}
+		}
+		if !removed {
+			kept = append(kept, n)
+		}
+	}
+	return kept
+}
+
+// copyNode copies n setting the time it expires if expires is not empty.
+func copyNode(n *registry.Node, expires string) *registry.Node {
+	c := *n
+	c.Metadata = make(map[string]string, len(n.Metadata)+1)
+	for k, v := range n.Metadata {
+		c.Metadata[k] = v
+	}
+	if expires != "" {
+		c.Metadata[metaExpires] = expires
+	}
+	return &c
+}
@@ -0,0 +1,187 @@
+package discovery
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/micro/go-micro/registry"
+)
+
+func TestNewStatic(t *testing.T) {
+	dir := t.TempDir()
+	invalid := filepath.Join(dir, "invalid.json")
+	if err := ioutil.WriteFile(invalid, []byte("{not json"), 0644); err != nil {
+		t.Fatalf("Error setting up: write file: %v", err)
+	}
+	tt := []struct {
+		name   string
+		file   string
+		opts   []StaticOption
+		expErr bool
+	}{
+		{name: "new file", file: filepath.Join(dir, "registry.json")},
+		{name: "invalid file", file: invalid, expErr: true},
+		{name: "zero poll interval", file: filepath.Join(dir, "registry.json"),
+			opts: []StaticOption{WithPollInterval(0)}, expErr: true},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			s, err := NewStatic(tc.file, tc.opts...)
+			if tc.expErr {
+				if err == nil {
+					t.Fatal("Expected an error but got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Got error: %v", err)
+			}
+			if s == nil {
+				t.Fatal("Got nil *Static")
+			}
+		})
+	}
+}
+
+func TestStatic_sharedFile(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "registry.json")
+	// Each service (process) has its own Static on the same file.
+	a := mustNewStatic(t, file)
+	b := mustNewStatic(t, file)
+
+	svc1 := &registry.Service{Name: "svc", Version: "1", Nodes: []*registry.Node{{Id: "svc-1", Address: "127.0.0.1", Port: 8081}}}
+	svc2 := &registry.Service{Name: "svc", Version: "1", Nodes: []*registry.Node{{Id: "svc-2", Address: "127.0.0.1", Port: 8082}}}
+	other := &registry.Service{Name: "other", Version: "1", Nodes: []*registry.Node{{Id: "other-1", Address: "127.0.0.1", Port: 9091}}}
+	for _, reg := range []struct {
+		r   *Static
+		svc *registry.Service
+	}{{a, svc1}, {b, svc2}, {b, other}, {a, svc1}} {
+		if err := reg.r.Register(reg.svc, registry.RegisterTTL(time.Minute)); err != nil {
+			t.Fatalf("Register(%s): %v", reg.svc.Nodes[0].Id, err)
+		}
+	}
+
+	assertNodes(t, b, "svc", "svc-1", "svc-2")
+	assertNodes(t, a, "other", "other-1")
+	services, err := a.ListServices()
+	if err != nil {
+		t.Fatalf("ListServices(): %v", err)
+	}
+	if len(services) != 2 {
+		t.Errorf("Expected 2 services, got %d", len(services))
+	}
+
+	if err := a.Deregister(svc1); err != nil {
+		t.Fatalf("Deregister(): %v", err)
+	}
+	assertNodes(t, b, "svc", "svc-2")
+	if err := b.Deregister(svc2); err != nil {
+		t.Fatalf("Deregister(): %v", err)
+	}
+	if _, err := a.GetService("svc"); err != registry.ErrNotFound {
+		t.Errorf("Expected registry.ErrNotFound, got %v", err)
+	}
+}
+
+func TestStatic_ttl(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "registry.json")
+	// e.g. an entry written by hand.
+	err := ioutil.WriteFile(file, []byte(`[{"name":"static","version":"1","nodes":[{"id":"static-1","address":"10.0.0.1","port":80}]}]`), 0644)
+	if err != nil {
+		t.Fatalf("Error setting up: write file: %v", err)
+	}
+	s := mustNewStatic(t, file)
+	svc := &registry.Service{Name: "svc", Version: "1", Nodes: []*registry.Node{{Id: "svc-1"}}}
+	if err := s.Register(svc, registry.RegisterTTL(time.Millisecond)); err != nil {
+		t.Fatalf("Register(): %v", err)
+	}
+	time.Sleep(5 * time.Millisecond)
+
+	if _, err := s.GetService("svc"); err != registry.ErrNotFound {
+		t.Errorf("Expected registry.ErrNotFound for expired node, got %v", err)
+	}
+	assertNodes(t, s, "static", "static-1")
+}
+
+func TestStatic_Watch(t *testing.T) {
+	s := mustNewStatic(t, filepath.Join(t.TempDir(), "registry.json"), WithPollInterval(time.Millisecond))
+	svc := &registry.Service{Name: "svc", Version: "1", Nodes: []*registry.Node{{Id: "svc-1"}}}
+	other := &registry.Service{Name: "other", Version: "1", Nodes: []*registry.Node{{Id: "other-1"}}}
+	if err := s.Register(svc); err != nil {
+		t.Fatalf("Register(): %v", err)
+	}
+
+	w, err := s.Watch(registry.WatchService("svc"))
+	if err != nil {
+		t.Fatalf("Watch(): %v", err)
+	}
+	steps := []struct {
+		name      string
+		change    func() error
+		expAction string
+	}{
+		{name: "unwatched service", change: func() error { return s.Register(other) }},
+		{name: "node added", change: func() error {
+			return s.Register(&registry.Service{Name: "svc", Version: "1", Nodes: []*registry.Node{{Id: "svc-2"}}})
+		}, expAction: "update"},
+		{name: "new version", change: func() error {
+			return s.Register(&registry.Service{Name: "svc", Version: "2", Nodes: []*registry.Node{{Id: "svc-3"}}})
+		}, expAction: "create"},
+		{name: "removed", change: func() error {
+			return s.Deregister(&registry.Service{Name: "svc", Version: "2", Nodes: []*registry.Node{{Id: "svc-3"}}})
+		}, expAction: "delete"},
+	}
+	for _, step := range steps {
+		if err := step.change(); err != nil {
+			t.Fatalf("%s: %v", step.name, err)
+		}
+		if step.expAction == "" {
+			continue
+		}
+		r, err := w.Next()
+		if err != nil {
+			t.Fatalf("%s: Next(): %v", step.name, err)
+		}
+		if r.Action != step.expAction || r.Service.Name != "svc" {
+			t.Errorf("%s: expected %s of svc, got %s of %s",
+				step.name, step.expAction, r.Action, r.Service.Name)
+		}
+	}
+
+	w.Stop()
+	if _, err := w.Next(); err == nil {
+		t.Error("Expected an error from Next() after Stop() but got nil")
+	}
+}
+
+func mustNewStatic(t *testing.T, file string, opts ...StaticOption) *Static {
+	s, err := NewStatic(file, opts...)
+	if err != nil {
+		t.Fatalf("Error setting up: NewStatic(): %v", err)
+	}
+	return s
+}
+
+func assertNodes(t *testing.T, s *Static, service string, expIDs ...string) {
+	t.Helper()
+	services, err := s.GetService(service)
+	if err != nil {
+		t.Fatalf("GetService(%s): %v", service, err)
+	}
+	var ids []string
+	for _, svc := range services {
+		for _, n := range svc.Nodes {
+			ids = append(ids, n.Id)
+		}
+	}
+	if len(ids) != len(expIDs) {
+		t.Fatalf("Expected %s nodes %v, got %v", service, expIDs, ids)
+	}
+	for i := range ids {
+		if ids[i] != expIDs[i] {
+			t.Errorf("Expected %s nodes %v, got %v", service, expIDs, ids)
+		}
+	}
+}
@@ -0,0 +1,31 @@
+package grpc
+
+import (
+	"context"
+
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+// healthServer implements the standard gRPC health checking protocol
+// (grpc.health.v1.Health) using the readiness checks of a HealthChecker.
+// Watch is not supported.
+type healthServer struct {
+	healthpb.UnimplementedHealthServer
+	health HealthChecker
+}
+
+// Check reports SERVING if all readiness checks pass, NOT_SERVING
+// otherwise. The overall server ("") and the api.Status service share the
+// same status.
+func (s *healthServer) Check(ctx context.Context, req *healthpb.HealthCheckRequest) (*healthpb.HealthCheckResponse, error) {
+	if req.GetService() != "" && req.GetService() != StatusServiceName {
+		return nil, status.Errorf(codes.NotFound, "unknown service %s", req.GetService())
+	}
+	resp := &healthpb.HealthCheckResponse{Status: healthpb.HealthCheckResponse_NOT_SERVING}
+	if s.health.Ready(ctx).Ready {
+		resp.Status = healthpb.HealthCheckResponse_SERVING
+	}
+	return resp, nil
+}
@@ -0,0 +1,195 @@
+// Package grpc serves the go-micro RPC handlers (e.g. api.StatusHandler) on
+// a plain gRPC server so that clients not using go-micro can call them.
+package grpc
+
+import (
+	"context"
+	"crypto/tls"
+	"net/http"
+	"strings"
+
+	microErrs "github.com/micro/go-micro/errors"
+	"github.com/micro/go-micro/metadata"
+	"github.com/micro/go-micro/server"
+	"github.com/tomogoma/go-typed-errors"
+	"github.com/tomogoma/seedms/pkg/api"
+	"github.com/tomogoma/seedms/pkg/certs"
+	"github.com/tomogoma/seedms/pkg/health"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	grpcMD "google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
+)
+
+type HealthChecker interface {
+	Ready(ctx context.Context) health.Report
+}
+
+type options struct {
+	wrappers   []server.HandlerWrapper
+	tlsConf    *tls.Config
+	reflection bool
+}
+
+// Option allows extra configuration for NewServer. Use the With...
+// functions to set options.
+type Option func(*options)
+
+// WithHandlerWrappers passes requests to the api.Status service through
+// the go-micro server.HandlerWrappers ws (e.g. rpc.NewAuthWrapper) in the
+// order they would be passed through by micro.WrapHandler. The gRPC
+// metadata of requests is available to ws as go-micro metadata.
+func WithHandlerWrappers(ws ...server.HandlerWrapper) Option {
+	return func(o *options) {
+		o.wrappers = append(o.wrappers, ws...)
+	}
+}
+
+// WithTLSConfig serves TLS as configured in c e.g. by
+// certs.NewServerConfig. The subject of verified client certificates is
+// available to handlers through api.ClientCertSubjectFromContext.
+// Requests are served without TLS by default.
+func WithTLSConfig(c *tls.Config) Option {
+	return func(o *options) {
+		o.tlsConf = c
+	}
+}
+
+// WithReflection registers the gRPC server reflection service.
+func WithReflection() Option {
+	return func(o *options) {
+		o.reflection = true
+	}
+}
+
+// NewServer returns a grpc.Server serving h as the api.Status service and
+// the readiness reported by hc as the standard gRPC health service. Serve
+// it using its Serve() method and stop it using GracefulStop().
+func NewServer(h api.StatusHandler, hc HealthChecker, opts ...Option) (*grpc.Server, error) {
+	if h == nil {
+		return nil, errors.New("StatusHandler was nil")
+	}
+	if hc == nil {
+		return nil, errors.New("HealthChecker was nil")
+	}
+	o := &options{}
+	for _, f := range opts {
+		f(o)
+	}
+
+	srvOpts := []grpc.ServerOption{grpc.UnaryInterceptor(wrapUnary(o.wrappers))}
+	if o.tlsConf != nil {
+		srvOpts = append(srvOpts, grpc.Creds(credentials.NewTLS(o.tlsConf)))
+	}
+	s := grpc.NewServer(srvOpts...)
+	s.RegisterService(&StatusServiceDesc, h)
+	healthpb.RegisterHealthServer(s, &healthServer{health: hc})
+	if o.reflection {
+		reflection.Register(s)
+	}
+	return s, nil
+}
+
+// request is the go-micro server.Request of a gRPC request.
+type request struct {
+	service string
+	method  string
+	body    interface{}
+}
+
+func (r request) Service() string      { return r.service }
+func (r request) Method() string       { return r.method }
+func (r request) ContentType() string  { return "application/grpc" }
+func (r request) Request() interface{} { return r.body }
+func (r request) Stream() bool         { return false }
+
+// wrapUnary returns a grpc.UnaryServerInterceptor passing requests to the
+// api.Status service through wrappers.
+func wrapUnary(wrappers []server.HandlerWrapper) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if p, ok := peer.FromContext(ctx); ok {
+			if tlsInfo, ok := p.AuthInfo.(credentials.TLSInfo); ok {
+				if subject, ok := certs.VerifiedSubject(&tlsInfo.State); ok {
+					ctx = api.ContextWithClientCertSubject(ctx, subject)
+				}
+			}
+		}
+
+		svc, method := splitFullMethod(info.FullMethod)
+		if svc != StatusServiceName {
+			return handler(ctx, req)
+		}
+
+		var resp interface{}
+		fn := func(ctx context.Context, _ server.Request, _ interface{}) error {
+			var err error
+			resp, err = handler(ctx, req)
+			return err
+		}
+		for i := len(wrappers); i > 0; i-- {
+			fn = wrappers[i-1](fn)
+		}
+
+		md := metadata.Metadata{}
+		inMD, _ := grpcMD.FromIncomingContext(ctx)
+		for k, vs := range inMD {
+			md[k] = strings.Join(vs, ",")
+		}
+		// go-micro methods are named Service.Method without the package
+		// e.g. Status.Check.
+		microMethod := svc[strings.LastIndex(svc, ".")+1:] + "." + method
+		err := fn(metadata.NewContext(ctx, md), request{service: svc, method: microMethod, body: req}, resp)
+		if err != nil {
+			return nil, statusError(err)
+		}
+		return resp, nil
+	}
+}
+
+// splitFullMethod splits a gRPC method name e.g. "/api.Status/Check" into
+// its service ("api.Status") and method ("Check").
+func splitFullMethod(fullMethod string) (string, string) {
+	fullMethod = strings.TrimPrefix(fullMethod, "/")
+	i := strings.LastIndex(fullMethod, "/")
+	if i < 0 {
+		return "", fullMethod
+	}
+	return fullMethod[:i], fullMethod[i+1:]
+}
+
+// statusError converts err into a gRPC status error. The HTTP status codes
+// of go-micro errors are mapped to their gRPC equivalents. Details of
+// internal and non go-micro errors are withheld from the caller.
+func statusError(err error) error {
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+	mErr, ok := err.(*microErrs.Error)
+	if !ok || mErr.Code == http.StatusInternalServerError {
+		return status.Error(codes.Internal, "Something wicked happened")
+	}
+	code := codes.Unknown
+	switch mErr.Code {
+	case http.StatusBadRequest:
+		code = codes.InvalidArgument
+	case http.StatusUnauthorized:
+		code = codes.Unauthenticated
+	case http.StatusForbidden:
+		code = codes.PermissionDenied
+	case http.StatusNotFound:
+		code = codes.NotFound
+	case http.StatusConflict:
+		code = codes.Aborted
+	case http.StatusTooManyRequests:
+		code = codes.ResourceExhausted
+	case http.StatusNotImplemented:
+		code = codes.Unimplemented
+	case http.StatusServiceUnavailable:
+		code = codes.Unavailable
+	}
+	return status.Error(code, mErr.Detail)
+}
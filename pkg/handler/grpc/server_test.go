@@ -0,0 +1,280 @@
+package grpc_test
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"testing"
+
+	microErrs "github.com/micro/go-micro/errors"
+	"github.com/tomogoma/go-typed-errors"
+	"github.com/tomogoma/seedms/pkg/api"
+	"github.com/tomogoma/seedms/pkg/certs"
+	"github.com/tomogoma/seedms/pkg/config"
+	grpcIntl "github.com/tomogoma/seedms/pkg/handler/grpc"
+	"github.com/tomogoma/seedms/pkg/handler/rpc"
+	"github.com/tomogoma/seedms/pkg/health"
+	"github.com/tomogoma/seedms/pkg/mocks"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// statusService is an api.StatusHandler echoing the API key, the bearer
+// token claims and the client certificate subject of requests.
+type statusService struct {
+	expErr error
+}
+
+func (s *statusService) Check(ctx context.Context, req *api.Request, resp *api.Response) error {
+	if s.expErr != nil {
+		return s.expErr
+	}
+	claims, _ := api.ClaimsFromContext(ctx)
+	subject, _ := api.ClientCertSubjectFromContext(ctx)
+	*resp = api.Response{Name: req.APIKey, Description: claims.UserID, CanonicalName: subject}
+	return nil
+}
+
+func (s *statusService) Health(ctx context.Context, req *api.HealthRequest, resp *api.HealthResponse) error {
+	*resp = api.HealthResponse{Ready: true}
+	return nil
+}
+
+func TestNewServer(t *testing.T) {
+	tt := []struct {
+		name    string
+		handler api.StatusHandler
+		health  grpcIntl.HealthChecker
+		opts    []grpcIntl.Option
+		expErr  bool
+	}{
+		{name: "valid deps", handler: &statusService{}, health: &mocks.HealthChecker{}},
+		{name: "nil handler", health: &mocks.HealthChecker{}, expErr: true},
+		{name: "nil health checker", handler: &statusService{}, expErr: true},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			s, err := grpcIntl.NewServer(tc.handler, tc.health, tc.opts...)
+			if tc.expErr {
+				if err == nil {
+					t.Fatal("Expected an error but got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Got error: %v", err)
+			}
+			if s == nil {
+				t.Fatal("Got nil *grpc.Server")
+			}
+		})
+	}
+}
+
+func TestServer_statusCheck(t *testing.T) {
+	tt := []struct {
+		name    string
+		svc     *statusService
+		jwter   *mocks.JWTEr
+		md      metadata.MD
+		expCode codes.Code
+		expMsg  string
+		expResp api.Response
+	}{
+		{
+			name:    "public method without token",
+			svc:     &statusService{},
+			jwter:   &mocks.JWTEr{},
+			expCode: codes.OK,
+			expResp: api.Response{Name: "some-key"},
+		},
+		{
+			name:    "bearer token in metadata",
+			svc:     &statusService{},
+			jwter:   &mocks.JWTEr{ExpValidateClaims: &api.Claims{UserID: "123"}},
+			md:      metadata.Pairs("authorization", "Bearer some.jwt"),
+			expCode: codes.OK,
+			expResp: api.Response{Name: "some-key", Description: "123"},
+		},
+		{
+			name:    "invalid bearer token",
+			svc:     &statusService{},
+			jwter:   &mocks.JWTEr{ExpValidateErr: errors.NewUnauthorized("expired")},
+			md:      metadata.Pairs("authorization", "Bearer some.jwt"),
+			expCode: codes.Unauthenticated,
+		},
+		{
+			name:    "go-micro error",
+			svc:     &statusService{expErr: microErrs.New("status", "invalid API key", http.StatusForbidden)},
+			jwter:   &mocks.JWTEr{},
+			expCode: codes.PermissionDenied,
+			expMsg:  "invalid API key",
+		},
+		{
+			name:    "go-micro unavailable error",
+			svc:     &statusService{expErr: microErrs.New("status", "db down", http.StatusServiceUnavailable)},
+			jwter:   &mocks.JWTEr{},
+			expCode: codes.Unavailable,
+			expMsg:  "db down",
+		},
+		{
+			name:    "go-micro internal error details withheld",
+			svc:     &statusService{expErr: microErrs.InternalServerError("status", "db password is hunter2")},
+			jwter:   &mocks.JWTEr{},
+			expCode: codes.Internal,
+			expMsg:  "Something wicked happened",
+		},
+		{
+			name:    "other error details withheld",
+			svc:     &statusService{expErr: errors.New("db password is hunter2")},
+			jwter:   &mocks.JWTEr{},
+			expCode: codes.Internal,
+			expMsg:  "Something wicked happened",
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			authWrapper := rpc.NewAuthWrapper(tc.jwter, &mocks.Logger{}, rpc.StatusPublicMethods...)
+			s, err := grpcIntl.NewServer(tc.svc, &mocks.HealthChecker{},
+				grpcIntl.WithHandlerWrappers(authWrapper))
+			if err != nil {
+				t.Fatalf("grpc.NewServer(): %v", err)
+			}
+			conn := serve(t, s)
+
+			ctx := context.Background()
+			if tc.md != nil {
+				ctx = metadata.NewOutgoingContext(ctx, tc.md)
+			}
+			resp := &api.Response{}
+			err = conn.Invoke(ctx, "/api.Status/Check", &api.Request{APIKey: "some-key"}, resp)
+			st, _ := status.FromError(err)
+			if st.Code() != tc.expCode {
+				t.Fatalf("Expected code %s, got %s (%v)", tc.expCode, st.Code(), err)
+			}
+			if tc.expMsg != "" && st.Message() != tc.expMsg {
+				t.Errorf("Expected message '%s', got '%s'", tc.expMsg, st.Message())
+			}
+			if tc.expCode != codes.OK {
+				return
+			}
+			if resp.Name != tc.expResp.Name || resp.Description != tc.expResp.Description {
+				t.Errorf("Expected response %+v, got %+v", tc.expResp, *resp)
+			}
+		})
+	}
+}
+
+func TestServer_healthCheck(t *testing.T) {
+	tt := []struct {
+		name      string
+		service   string
+		ready     bool
+		expCode   codes.Code
+		expStatus healthpb.HealthCheckResponse_ServingStatus
+	}{
+		{name: "server ready", ready: true, expStatus: healthpb.HealthCheckResponse_SERVING},
+		{name: "server not ready", ready: false, expStatus: healthpb.HealthCheckResponse_NOT_SERVING},
+		{name: "status service ready", service: grpcIntl.StatusServiceName, ready: true,
+			expStatus: healthpb.HealthCheckResponse_SERVING},
+		{name: "unknown service", service: "api.Other", expCode: codes.NotFound},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			hc := &mocks.HealthChecker{ExpReport: health.Report{Ready: tc.ready}}
+			s, err := grpcIntl.NewServer(&statusService{}, hc)
+			if err != nil {
+				t.Fatalf("grpc.NewServer(): %v", err)
+			}
+			conn := serve(t, s)
+
+			resp, err := healthpb.NewHealthClient(conn).Check(context.Background(),
+				&healthpb.HealthCheckRequest{Service: tc.service})
+			if st, _ := status.FromError(err); st.Code() != tc.expCode {
+				t.Fatalf("Expected code %s, got %s (%v)", tc.expCode, st.Code(), err)
+			}
+			if tc.expCode != codes.OK {
+				return
+			}
+			if resp.GetStatus() != tc.expStatus {
+				t.Errorf("Expected status %s, got %s", tc.expStatus, resp.GetStatus())
+			}
+		})
+	}
+}
+
+func TestServer_mutualTLS(t *testing.T) {
+	files := mocks.WriteTLSFiles(t, t.TempDir(), "billing")
+	srvConf, err := certs.NewServerConfig(config.TLS{
+		CertFile:     files.ServerCertFile,
+		KeyFile:      files.ServerKeyFile,
+		ClientCAFile: files.CAFile,
+	}, &mocks.Logger{})
+	if err != nil {
+		t.Fatalf("Error setting up: certs.NewServerConfig(): %v", err)
+	}
+	caPEM, err := ioutil.ReadFile(files.CAFile)
+	if err != nil {
+		t.Fatalf("Error setting up: read CA file: %v", err)
+	}
+	clCert, err := tls.LoadX509KeyPair(files.ClientCertFile, files.ClientKeyFile)
+	if err != nil {
+		t.Fatalf("Error setting up: load client cert: %v", err)
+	}
+	tt := []struct {
+		name       string
+		clCerts    []tls.Certificate
+		expSubject string
+	}{
+		{name: "client cert", clCerts: []tls.Certificate{clCert}, expSubject: "CN=billing,O=seedms"},
+		{name: "no client cert"},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			s, err := grpcIntl.NewServer(&statusService{}, &mocks.HealthChecker{}, grpcIntl.WithTLSConfig(srvConf))
+			if err != nil {
+				t.Fatalf("grpc.NewServer(): %v", err)
+			}
+			clConf := &tls.Config{RootCAs: x509.NewCertPool(), ServerName: "localhost", Certificates: tc.clCerts}
+			clConf.RootCAs.AppendCertsFromPEM(caPEM)
+			conn := serve(t, s, grpc.WithTransportCredentials(credentials.NewTLS(clConf)))
+
+			resp := &api.Response{}
+			err = conn.Invoke(context.Background(), "/api.Status/Check", &api.Request{APIKey: "some-key"}, resp)
+			if err != nil {
+				t.Fatalf("Invoke(): %v", err)
+			}
+			if resp.CanonicalName != tc.expSubject {
+				t.Errorf("Expected client cert subject '%s', got '%s'", tc.expSubject, resp.CanonicalName)
+			}
+		})
+	}
+}
+
+// serve serves s on an in-memory listener for the duration of the test and
+// returns a client connection to it, insecure unless opts set credentials.
+func serve(t *testing.T, s *grpc.Server, opts ...grpc.DialOption) *grpc.ClientConn {
+	lis := bufconn.Listen(1 << 20)
+	go s.Serve(lis)
+	t.Cleanup(s.Stop)
+	if len(opts) == 0 {
+		opts = append(opts, grpc.WithInsecure())
+	}
+	opts = append(opts, grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+		return lis.DialContext(ctx)
+	}))
+	conn, err := grpc.Dial("bufnet", opts...)
+	if err != nil {
+		t.Fatalf("Error setting up: dial: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
@@ -0,0 +1,60 @@
+package grpc
+
+import (
+	"context"
+
+	"github.com/tomogoma/seedms/pkg/api"
+	"google.golang.org/grpc"
+)
+
+// StatusServiceName is the fully qualified name of the Status service in
+// api/status.proto.
+const StatusServiceName = "api.Status"
+
+// StatusServiceDesc describes the Status service in api/status.proto to
+// grpc.Server in terms of the go-micro api.StatusHandler so that one
+// implementation serves both.
+var StatusServiceDesc = grpc.ServiceDesc{
+	ServiceName: StatusServiceName,
+	HandlerType: (*api.StatusHandler)(nil),
+	Methods: []grpc.MethodDesc{
+		{MethodName: "Check", Handler: statusCheckHandler},
+		{MethodName: "Health", Handler: statusHealthHandler},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "github.com/tomogoma/seedms/pkg/api/status.proto",
+}
+
+func statusCheckHandler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(api.Request)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	h := func(ctx context.Context, req interface{}) (interface{}, error) {
+		resp := new(api.Response)
+		err := srv.(api.StatusHandler).Check(ctx, req.(*api.Request), resp)
+		return resp, err
+	}
+	if interceptor == nil {
+		return h(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{Server: srv, FullMethod: "/" + StatusServiceName + "/Check"}
+	return interceptor(ctx, in, info, h)
+}
+
+func statusHealthHandler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(api.HealthRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	h := func(ctx context.Context, req interface{}) (interface{}, error) {
+		resp := new(api.HealthResponse)
+		err := srv.(api.StatusHandler).Health(ctx, req.(*api.HealthRequest), resp)
+		return resp, err
+	}
+	if interceptor == nil {
+		return h(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{Server: srv, FullMethod: "/" + StatusServiceName + "/Health"}
+	return interceptor(ctx, in, info, h)
+}
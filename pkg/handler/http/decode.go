@@ -0,0 +1,106 @@
+package http
+
+import (
+	"encoding/json"
+	stderrors "errors"
+	"io"
+	"mime"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/tomogoma/go-typed-errors"
+)
+
+// DefaultMaxBodyBytes is the maximum request body size accepted by
+// decodeJSON if none is configured.
+const DefaultMaxBodyBytes = 1 << 20
+
+// bodyTooLargeError is returned by decodeJSON if the request body exceeds
+// the configured limit. handleError responds to it with a 413.
+type bodyTooLargeError struct {
+	limit int64
+}
+
+func (e bodyTooLargeError) Error() string {
+	return "request body exceeds " + strconv.FormatInt(e.limit, 10) + " bytes"
+}
+
+// WithMaxBodyBytes sets the maximum size of request bodies decoded by
+// decodeJSON e.g. those of RPC routes. Defaults to DefaultMaxBodyBytes.
+func WithMaxBodyBytes(n int64) Option {
+	return func(s *handler) {
+		if n > 0 {
+			s.maxBodyBytes = n
+		}
+	}
+}
+
+// decodeJSON decodes the single JSON value in r's body into v. Bodies
+// larger than s.maxBodyBytes, of a Content-Type other than JSON or with
+// fields v does not have are rejected with client errors; type mismatches
+// are reported as FieldErrors.
+func (s *handler) decodeJSON(w http.ResponseWriter, r *http.Request, v interface{}) error {
+	if cType := r.Header.Get("Content-Type"); cType != "" {
+		mt, _, err := mime.ParseMediaType(cType)
+		if err != nil || mt != "application/json" {
+			return errors.NewClientf("Content-Type must be application/json, got '%s'", cType)
+		}
+	}
+
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, s.maxBodyBytes))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		return s.decodeError(err)
+	}
+	var extra json.RawMessage
+	if err := dec.Decode(&extra); err != io.EOF {
+		return errors.NewClient("request body must contain a single JSON value")
+	}
+	return nil
+}
+
+// decodeError converts an error from json.Decoder.Decode into the client
+// error to respond with.
+func (s *handler) decodeError(err error) error {
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	var maxBytesErr *http.MaxBytesError
+	switch {
+	case err == io.EOF:
+		return errors.NewClient("request body is required")
+	case err == io.ErrUnexpectedEOF:
+		return errors.NewClient("request body contains malformed JSON")
+	case stderrors.As(err, &syntaxErr):
+		return errors.NewClientf("request body contains malformed JSON at position %d",
+			syntaxErr.Offset)
+	case stderrors.As(err, &typeErr):
+		return FieldErrors{{Field: typeErr.Field, Message: "must be of type " + jsonType(typeErr.Type)}}
+	case stderrors.As(err, &maxBytesErr):
+		return bodyTooLargeError{limit: s.maxBodyBytes}
+	case strings.HasPrefix(err.Error(), "json: unknown field "):
+		field := strings.Trim(strings.TrimPrefix(err.Error(), "json: unknown field "), `"`)
+		return FieldErrors{{Field: field, Message: "is not a known field"}}
+	default:
+		return errors.NewClientf("invalid request body: %v", err)
+	}
+}
+
+// jsonType names the JSON type values of t are decoded from.
+func jsonType(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.Bool:
+		return "boolean"
+	case reflect.String:
+		return "string"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return "number"
+	case reflect.Slice, reflect.Array:
+		return "array"
+	default:
+		return "object"
+	}
+}
@@ -0,0 +1,122 @@
+package http
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	testingH "github.com/tomogoma/seedms/pkg/mocks"
+	"github.com/tomogoma/seedms/pkg/tracing"
+)
+
+func TestHandler_decodeJSON(t *testing.T) {
+	type body struct {
+		Name  string `json:"name"`
+		Count int    `json:"count"`
+	}
+	tt := []struct {
+		name          string
+		cType         string
+		reqBody       string
+		expStatusCode int
+		expBody       string
+	}{
+		{
+			name:          "valid",
+			cType:         "application/json; charset=utf-8",
+			reqBody:       `{"name":"seed","count":3}`,
+			expStatusCode: http.StatusOK,
+			expBody:       "seed 3",
+		},
+		{
+			name:          "no content type",
+			reqBody:       `{"name":"seed"}`,
+			expStatusCode: http.StatusOK,
+			expBody:       "seed 0",
+		},
+		{
+			name:          "wrong content type",
+			cType:         "text/plain",
+			reqBody:       `{"name":"seed"}`,
+			expStatusCode: http.StatusBadRequest,
+			expBody:       `{"error":{"code":"badRequest","message":"Content-Type must be application/json, got 'text/plain'","requestID":"req-123"}}`,
+		},
+		{
+			name:          "empty body",
+			expStatusCode: http.StatusBadRequest,
+			expBody:       `{"error":{"code":"badRequest","message":"request body is required","requestID":"req-123"}}`,
+		},
+		{
+			name:          "malformed",
+			reqBody:       `{"name":}`,
+			expStatusCode: http.StatusBadRequest,
+			expBody:       `{"error":{"code":"badRequest","message":"request body contains malformed JSON at position 9","requestID":"req-123"}}`,
+		},
+		{
+			name:          "truncated",
+			reqBody:       `{"name":"seed"`,
+			expStatusCode: http.StatusBadRequest,
+			expBody:       `{"error":{"code":"badRequest","message":"request body contains malformed JSON","requestID":"req-123"}}`,
+		},
+		{
+			name:          "multiple values",
+			reqBody:       `{"name":"seed"}{"name":"seed"}`,
+			expStatusCode: http.StatusBadRequest,
+			expBody:       `{"error":{"code":"badRequest","message":"request body must contain a single JSON value","requestID":"req-123"}}`,
+		},
+		{
+			name:          "unknown field",
+			reqBody:       `{"name":"seed","colour":"red"}`,
+			expStatusCode: http.StatusBadRequest,
+			expBody: `{"error":{"code":"badRequest","message":"invalid fields: colour: is not a known field",` +
+				`"requestID":"req-123","fields":[{"field":"colour","message":"is not a known field"}]}}`,
+		},
+		{
+			name:          "wrong type",
+			reqBody:       `{"name":"seed","count":"3"}`,
+			expStatusCode: http.StatusBadRequest,
+			expBody: `{"error":{"code":"badRequest","message":"invalid fields: count: must be of type number",` +
+				`"requestID":"req-123","fields":[{"field":"count","message":"must be of type number"}]}}`,
+		},
+		{
+			name:          "too large",
+			reqBody:       `{"name":"` + strings.Repeat("a", 64) + `"}`,
+			expStatusCode: http.StatusRequestEntityTooLarge,
+			expBody:       `{"error":{"code":"requestTooLarge","message":"request body exceeds 32 bytes","requestID":"req-123"}}`,
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			lg := &testingH.Logger{}
+			s := &handler{logger: lg, maxBodyBytes: 32}
+			h := s.prepLogger(func(w http.ResponseWriter, r *http.Request) {
+				b := new(body)
+				if err := s.decodeJSON(w, r, b); err != nil {
+					s.handleError(w, r, nil, err)
+					return
+				}
+				w.Write([]byte(b.Name + " " + strconv.Itoa(b.Count)))
+			})
+
+			req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(tc.reqBody))
+			req = req.WithContext(tracing.ContextWithRequestID(req.Context(), "req-123"))
+			if tc.cType != "" {
+				req.Header.Set("Content-Type", tc.cType)
+			}
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, req)
+
+			if w.Code != tc.expStatusCode {
+				lg.PrintLogs(t)
+				t.Errorf("Expected status code %d, got %d", tc.expStatusCode, w.Code)
+			}
+			body, _ := ioutil.ReadAll(w.Body)
+			if string(body) != tc.expBody {
+				t.Errorf("Expected body '%s', got '%s'", tc.expBody, body)
+			}
+		})
+	}
+}
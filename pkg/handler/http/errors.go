@@ -0,0 +1,233 @@
+package http
+
+import (
+	"encoding/json"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	microErrs "github.com/micro/go-micro/errors"
+	"github.com/tomogoma/go-typed-errors"
+	"github.com/tomogoma/seedms/pkg/logging"
+	"github.com/tomogoma/seedms/pkg/tracing"
+)
+
+// Machine readable error codes sent in the error envelope.
+const (
+	ErrCodeBadRequest      = "badRequest"
+	ErrCodeUnauthorized    = "unauthorized"
+	ErrCodeForbidden       = "forbidden"
+	ErrCodeNotFound        = "notFound"
+	ErrCodeConflict        = "conflict"
+	ErrCodeTooLarge        = "requestTooLarge"
+	ErrCodeTooManyRequests = "tooManyRequests"
+	ErrCodeInternal        = "internal"
+	ErrCodeNotImplemented  = "notImplemented"
+	ErrCodeUnavailable     = "unavailable"
+)
+
+const (
+	msgInternal    = "Something wicked happened, please try again later"
+	msgUnavailable = "Service temporarily unavailable, please try again"
+	msgNotFound    = "Nothing to see here"
+	msgRateLimited = "Too many requests, please try again later"
+)
+
+var (
+	authErrCheck      = errors.AuthErrCheck{}
+	clErrCheck        = errors.ClErrCheck{}
+	notFoundErrCheck  = errors.NotFoundErrCheck{}
+	conflictErrCheck  = errors.ConflictErrCheck{}
+	retryableErrCheck = errors.RetryableErrCheck{}
+	notImplErrCheck   = errors.NotImplErrCheck{}
+
+	errCodes = map[int]string{
+		http.StatusBadRequest:            ErrCodeBadRequest,
+		http.StatusUnauthorized:          ErrCodeUnauthorized,
+		http.StatusForbidden:             ErrCodeForbidden,
+		http.StatusNotFound:              ErrCodeNotFound,
+		http.StatusConflict:              ErrCodeConflict,
+		http.StatusRequestEntityTooLarge: ErrCodeTooLarge,
+		http.StatusTooManyRequests:       ErrCodeTooManyRequests,
+		http.StatusInternalServerError:   ErrCodeInternal,
+		http.StatusNotImplemented:        ErrCodeNotImplemented,
+		http.StatusServiceUnavailable:    ErrCodeUnavailable,
+	}
+)
+
+// FieldError describes why the value of a request field is invalid.
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// FieldErrors is a client error listing the invalid fields of a request.
+// handleError responds to it with a 400 and the fields in the envelope.
+type FieldErrors []FieldError
+
+func (fe FieldErrors) Error() string {
+	msgs := make([]string, len(fe))
+	for i, f := range fe {
+		msgs[i] = f.Field + ": " + f.Message
+	}
+	return "invalid fields: " + strings.Join(msgs, "; ")
+}
+
+// errorEnvelope is the JSON body of error responses.
+type errorEnvelope struct {
+	Error errorDetail `json:"error"`
+}
+
+type errorDetail struct {
+	Code      string       `json:"code"`
+	Message   string       `json:"message"`
+	RequestID string       `json:"requestID"`
+	Fields    []FieldError `json:"fields,omitempty"`
+}
+
+// handleError writes an error response for err to w and logs the error
+// using the logger of r (see requestLogger). reqData is
+// included in the log data. The status code follows the category of err
+// e.g. 409 for conflict errors and 503 (with a Retry-After header) for
+// retryable errors, or the code of go-micro errors returned by RPC
+// methods (see WithRPCService). Errors of unknown category are logged as
+// errors and their details withheld from the caller.
+func (s *handler) handleError(w http.ResponseWriter, r *http.Request, reqData interface{}, err error) {
+	reqDataB, _ := json.Marshal(reqData)
+	log := s.requestLogger(r).WithField(logging.FieldRequest, string(reqDataB))
+
+	msg := err.Error()
+	var fields []FieldError
+	var code int
+	switch {
+	case isFieldErrors(err, &fields):
+		code = http.StatusBadRequest
+	case isBodyTooLarge(err):
+		code = http.StatusRequestEntityTooLarge
+	case isRPCError(err, &code, &msg):
+		if code == http.StatusServiceUnavailable {
+			msg = msgUnavailable
+			w.Header().Set("Retry-After", retryAfter)
+		}
+	case authErrCheck.IsUnauthorizedError(err):
+		code = http.StatusUnauthorized
+	case authErrCheck.IsForbiddenError(err):
+		code = http.StatusForbidden
+	case clErrCheck.IsClientError(err):
+		code = http.StatusBadRequest
+	case notFoundErrCheck.IsNotFoundError(err):
+		code = http.StatusNotFound
+	case conflictErrCheck.IsConflictError(err):
+		code = http.StatusConflict
+	case retryableErrCheck.IsRetryableError(err):
+		code = http.StatusServiceUnavailable
+		msg = msgUnavailable
+		w.Header().Set("Retry-After", retryAfter)
+	case notImplErrCheck.IsNotImplementedError(err):
+		code = http.StatusNotImplemented
+	default:
+		log.WithField(logging.FieldResponseCode, http.StatusInternalServerError).
+			Error(err)
+		writeError(w, r, http.StatusInternalServerError, msgInternal, nil)
+		return
+	}
+	log.WithField(logging.FieldResponseCode, code).Warn(err)
+	writeError(w, r, code, msg, fields)
+}
+
+func isFieldErrors(err error, fields *[]FieldError) bool {
+	fe, ok := err.(FieldErrors)
+	if ok {
+		*fields = fe
+	}
+	return ok
+}
+
+// isRPCError reports whether err is a go-micro error with a code other
+// than 500, setting code and msg to its code and detail.
+func isRPCError(err error, code *int, msg *string) bool {
+	rpcErr, ok := err.(*microErrs.Error)
+	if !ok || rpcErr.Code < http.StatusBadRequest ||
+		rpcErr.Code == http.StatusInternalServerError {
+		return false
+	}
+	*code, *msg = int(rpcErr.Code), rpcErr.Detail
+	return true
+}
+
+func isBodyTooLarge(err error) bool {
+	_, ok := err.(bodyTooLargeError)
+	return ok
+}
+
+// writeError writes the error envelope with code as the http header to w,
+// or msg as plain text if the client prefers text/plain over JSON
+// (see acceptsJSON).
+func writeError(w http.ResponseWriter, r *http.Request, code int, msg string, fields []FieldError) {
+	if !acceptsJSON(r) {
+		http.Error(w, msg, code)
+		return
+	}
+	errCode, ok := errCodes[code]
+	if !ok {
+		errCode = strconv.Itoa(code)
+	}
+	respBytes, _ := json.Marshal(errorEnvelope{Error: errorDetail{
+		Code:      errCode,
+		Message:   msg,
+		RequestID: tracing.RequestID(r.Context()),
+		Fields:    fields,
+	}})
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(code)
+	w.Write(respBytes)
+}
+
+// acceptsJSON reports whether the Accept header of r ranks
+// application/json at least as high as text/plain. Requests without an
+// Accept header, or accepting neither, get JSON.
+func acceptsJSON(r *http.Request) bool {
+	accept := r.Header.Get("Accept")
+	if accept == "" {
+		return true
+	}
+	jsonQ, textQ := acceptQuality(accept, "application", "json"),
+		acceptQuality(accept, "text", "plain")
+	return jsonQ >= textQ
+}
+
+// acceptQuality returns the quality (q) the accept header value gives the
+// media type typ/subtype, taking the most specific matching media range.
+func acceptQuality(accept, typ, subtype string) float64 {
+	q, specificity := 0.0, -1
+	for _, rng := range strings.Split(accept, ",") {
+		mt, params, err := mime.ParseMediaType(strings.TrimSpace(rng))
+		if err != nil {
+			continue
+		}
+		var s int
+		switch mt {
+		case typ + "/" + subtype:
+			s = 2
+		case typ + "/*":
+			s = 1
+		case "*/*":
+			s = 0
+		default:
+			continue
+		}
+		if s < specificity {
+			continue
+		}
+		rq := 1.0
+		if qStr, ok := params["q"]; ok {
+			if rq, err = strconv.ParseFloat(qStr, 64); err != nil {
+				continue
+			}
+		}
+		q, specificity = rq, s
+	}
+	return q
+}
@@ -0,0 +1,47 @@
+package http
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestAcceptsJSON(t *testing.T) {
+	tt := []struct {
+		name   string
+		accept string
+		exp    bool
+	}{
+		{name: "no accept header", accept: "", exp: true},
+		{name: "json", accept: "application/json", exp: true},
+		{name: "plain text", accept: "text/plain", exp: false},
+		{name: "any", accept: "*/*", exp: true},
+		{name: "browser", accept: "text/html,application/xhtml+xml,*/*;q=0.8", exp: true},
+		{name: "text range", accept: "text/*", exp: false},
+		{name: "json preferred", accept: "text/plain;q=0.5, application/json", exp: true},
+		{name: "text preferred", accept: "application/json;q=0.5, text/plain", exp: false},
+		{name: "specific range wins", accept: "*/*, application/json;q=0", exp: false},
+		{name: "neither accepted", accept: "application/xml", exp: true},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			r, _ := http.NewRequest(http.MethodGet, "/", nil)
+			if tc.accept != "" {
+				r.Header.Set("Accept", tc.accept)
+			}
+			if act := acceptsJSON(r); act != tc.exp {
+				t.Errorf("Expected %t, got %t", tc.exp, act)
+			}
+		})
+	}
+}
+
+func TestFieldErrors_Error(t *testing.T) {
+	fe := FieldErrors{
+		{Field: "limit", Message: "must be a positive integer"},
+		{Field: "cursor", Message: "is malformed"},
+	}
+	exp := "invalid fields: limit: must be a positive integer; cursor: is malformed"
+	if act := fe.Error(); act != exp {
+		t.Errorf("Expected '%s', got '%s'", exp, act)
+	}
+}
@@ -3,14 +3,28 @@ package http
 import (
 	"context"
 	"encoding/json"
+	"math"
+	"net"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/dgrijalva/jwt-go"
 	"github.com/gorilla/handlers"
 	"github.com/gorilla/mux"
-	"github.com/pborman/uuid"
 	"github.com/tomogoma/go-typed-errors"
+	"github.com/tomogoma/seedms/pkg/api"
+	"github.com/tomogoma/seedms/pkg/certs"
 	"github.com/tomogoma/seedms/pkg/config"
+	"github.com/tomogoma/seedms/pkg/health"
 	"github.com/tomogoma/seedms/pkg/logging"
+	"github.com/tomogoma/seedms/pkg/tracing"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
 )
 
 type contextKey string
@@ -19,24 +33,129 @@ type Guard interface {
 	APIKeyValid(key []byte) (string, error)
 }
 
-type handler struct {
-	errors.ErrToHTTP
+type JWTValidator interface {
+	Validate(token string, claims jwt.Claims) (*jwt.Token, error)
+}
+
+type RateLimiter interface {
+	Allow(route, clientKey string) (bool, time.Duration, error)
+}
+
+type Metrics interface {
+	ObserveHTTP(route, method string, code int, d time.Duration)
+	Handler() http.Handler
+}
 
+type HealthChecker interface {
+	Ready(ctx context.Context) health.Report
+}
+
+type APIKeyStore interface {
+	APIKeysByUserID(ctx context.Context, userID, cursor string, limit int) ([]api.Key, string, error)
+	DeleteAPIKey(ctx context.Context, userID, keyID string) error
+}
+
+type handler struct {
 	guard   Guard
+	jwter   JWTValidator
+	apiKeys APIKeyStore
+	limiter RateLimiter
 	logger  logging.Logger
 	docsDir string
+
+	metrics      Metrics
+	guardMetrics bool
+	tracer       trace.Tracer
+	health       HealthChecker
+	reqIDHeader  string
+	maxBodyBytes int64
+	rpcServices  []rpcService
 }
 
+// Option allows extra configuration for NewHandler. Use the With...
+// functions to set options.
+type Option func(*handler)
+
 const (
 	keyAPIKey = "x-api-key"
+	keyAuth   = "Authorization"
+
+	bearerPrefix = "Bearer "
+
+	keyCursor = "cursor"
+	keyLimit  = "limit"
+	keyUserID = "userID"
+	keyKeyID  = "keyID"
 
 	ctxKeyLog = contextKey("log")
+
+	// Route names as used to configure rate limits.
+	routeStatus       = "status"
+	routeListAPIKeys  = "listAPIKeys"
+	routeRevokeAPIKey = "revokeAPIKey"
+	routeDocs         = "docs"
+	routeMetrics      = "metrics"
+
+	// routeNotFound labels metrics and spans of requests matching no route.
+	routeNotFound = "notFound"
+
+	tracerName = "github.com/tomogoma/seedms/pkg/handler/http"
+
+	// retryAfter is the Retry-After header value (seconds) sent with
+	// responses to retryable errors.
+	retryAfter = "1"
 )
 
-func NewHandler(g Guard, l logging.Logger, baseURL, docsDir string, allowedOrigins []string) (http.Handler, error) {
+// WithMetrics records request counts and latencies per route and status
+// code using m and serves m's metrics on /metrics. The API key guard
+// protects /metrics if guarded is true.
+func WithMetrics(m Metrics, guarded bool) Option {
+	return func(s *handler) {
+		s.metrics = m
+		s.guardMetrics = guarded
+	}
+}
+
+// WithTracerProvider sets the trace.TracerProvider used to create a span for
+// each request. Spans continue traces propagated in the request's W3C trace
+// context headers. No spans are created by default.
+func WithTracerProvider(tp trace.TracerProvider) Option {
+	return func(s *handler) {
+		s.tracer = tp.Tracer(tracerName)
+	}
+}
+
+// WithHealthChecker serves the readiness of the micro-service as reported
+// by hc on /readyz. /readyz is not served by default.
+func WithHealthChecker(hc HealthChecker) Option {
+	return func(s *handler) {
+		s.health = hc
+	}
+}
+
+// WithRequestIDHeader sets the header carrying callers' request IDs.
+// Defaults to tracing.DefaultRequestIDHeader.
+func WithRequestIDHeader(h string) Option {
+	return func(s *handler) {
+		if h != "" {
+			s.reqIDHeader = h
+		}
+	}
+}
+
+func NewHandler(g Guard, jv JWTValidator, ks APIKeyStore, rl RateLimiter, l logging.Logger, baseURL, docsDir string, allowedOrigins []string, opts ...Option) (http.Handler, error) {
 	if g == nil {
 		return nil, errors.New("Guard was nil")
 	}
+	if jv == nil {
+		return nil, errors.New("JWTValidator was nil")
+	}
+	if ks == nil {
+		return nil, errors.New("APIKeyStore was nil")
+	}
+	if rl == nil {
+		return nil, errors.New("RateLimiter was nil")
+	}
 	if l == nil {
 		return nil, errors.New("Logger was nil")
 	}
@@ -45,22 +164,38 @@ func NewHandler(g Guard, l logging.Logger, baseURL, docsDir string, allowedOrigi
 	}
 
 	r := mux.NewRouter().PathPrefix(baseURL).Subrouter()
-	handler{guard: g, logger: l, docsDir: docsDir}.handleRoute(r)
+	s := handler{guard: g, jwter: jv, apiKeys: ks, limiter: rl, logger: l, docsDir: docsDir,
+		tracer:       noop.NewTracerProvider().Tracer(tracerName),
+		reqIDHeader:  tracing.DefaultRequestIDHeader,
+		maxBodyBytes: DefaultMaxBodyBytes}
+	for _, f := range opts {
+		f(&s)
+	}
+	if err := s.handleRPCServices(r); err != nil {
+		return nil, err
+	}
+	s.handleRoute(r)
 
 	corsOpts := []handlers.CORSOption{
 		handlers.AllowedHeaders([]string{
 			"X-Requested-With", "Accept", "Content-Type", "Content-Length",
 			"Accept-Encoding", "X-CSRF-Token", "Authorization", "X-api-key",
+			s.reqIDHeader,
 		}),
+		handlers.ExposedHeaders([]string{s.reqIDHeader}),
 		handlers.AllowedOrigins(allowedOrigins),
-		handlers.AllowedMethods([]string{"GET", "HEAD", "POST", "PUT", "OPTIONS"}),
+		handlers.AllowedMethods([]string{"GET", "HEAD", "POST", "PUT", "DELETE", "OPTIONS"}),
 	}
-	return handlers.CORS(corsOpts...)(r), nil
+	return handlers.CORS(corsOpts...)(s.instrument(r)), nil
 }
 
 func (s handler) handleRoute(r *mux.Router) {
-	s.handleStatus(r)
+	s.handleAPIKeys(r)
+	s.handleDeleteAPIKey(r)
 	s.handleDocs(r)
+	s.handleMetrics(r)
+	s.handleHealthz(r)
+	s.handleReadyz(r)
 	s.handleNotFound(r)
 }
 
@@ -69,31 +204,122 @@ func (s handler) handleRoute(r *mux.Router) {
  * @apiName Status
  * @apiVersion 0.1.0
  * @apiGroup Service
+ * @apiDescription Served by the Status.Check RPC method (see WithRPCService).
  *
  * @apiHeader x-api-key the api key
  *
  * @apiSuccess (200) {String} name Micro-service name.
  * @apiSuccess (200)  {String} version http://semver.org version.
  * @apiSuccess (200)  {String} description Short description of the micro-service.
- * @apiSuccess (200)  {String} canonicalName Canonical name of the micro-service.
+ * @apiSuccess (200)  {String} canonicalName Canonical (RPC) name of the micro-service.
+ *
+ */
+
+// StatusRPCRoutes serve the methods of an api.StatusHandler on HTTP. Use
+// them with WithRPCService. The API key is validated by the HTTP handler
+// (from the x-api-key header) as on other routes, not by the RPC method.
+var StatusRPCRoutes = []RPCRoute{
+	{
+		Name:       routeStatus,
+		RPCMethod:  "Status.Check",
+		Method:     http.MethodGet,
+		Path:       "/status",
+		PathPrefix: true,
+	},
+}
+
+/**
+ * @api {get} /users/:userID/apikeys?cursor=:cursor&limit=:limit List API Keys
+ * @apiName ListAPIKeys
+ * @apiVersion 0.1.0
+ * @apiGroup APIKeys
+ * @apiDescription Pages through the (none revoked) API keys of a user.
+ * The bearer token must belong to the user.
+ *
+ * @apiHeader x-api-key the api key
+ * @apiHeader Authorization Bearer token of the user e.g. "Bearer eyJhbGciOi..."
+ *
+ * @apiParam {String} userID ID of the user owning the API keys.
+ * @apiParam {String} [cursor] nextCursor value from the previous page.
+ * @apiParam {Number{1-100}} [limit=20] Maximum number of API keys to return.
+ *
+ * @apiSuccess (200) {Object[]} apiKeys API keys in this page.
+ * @apiSuccess (200) {String} apiKeys.ID ID of the API key.
+ * @apiSuccess (200) {String} apiKeys.userID ID of the user owning the API key.
+ * @apiSuccess (200) {String} apiKeys.created ISO8601 date the API key was created.
+ * @apiSuccess (200) {String} apiKeys.lastUpdated ISO8601 date the API key was last updated.
+ * @apiSuccess (200) {String} [nextCursor] Cursor for the next page, omitted on the last page.
  *
  */
-func (s *handler) handleStatus(r *mux.Router) {
+func (s *handler) handleAPIKeys(r *mux.Router) {
 	r.Methods(http.MethodGet).
-		PathPrefix("/status").
+		Path("/users/{" + keyUserID + "}/apikeys").
 		HandlerFunc(
-		s.apiGuardChain(func(w http.ResponseWriter, r *http.Request) {
-			s.respondJsonOn(w, r, nil, struct {
-				Name          string `json:"name"`
-				Version       string `json:"version"`
-				Description   string `json:"description"`
-				CanonicalName string `json:"canonicalName"`
+		s.jwtGuardChain(routeListAPIKeys, func(w http.ResponseWriter, r *http.Request) {
+			req := struct {
+				UserID string `json:"userID"`
+				Cursor string `json:"cursor"`
+				Limit  int    `json:"limit"`
 			}{
-				Name:          config.Name,
-				Version:       config.VersionFull,
-				Description:   config.Description,
-				CanonicalName: config.CanonicalWebName(),
-			}, http.StatusOK, nil, s)
+				UserID: mux.Vars(r)[keyUserID],
+				Cursor: r.URL.Query().Get(keyCursor),
+			}
+			if err := claimsOwnUser(r, req.UserID); err != nil {
+				s.handleError(w, r, req, err)
+				return
+			}
+			if limitStr := r.URL.Query().Get(keyLimit); limitStr != "" {
+				var err error
+				req.Limit, err = strconv.Atoi(limitStr)
+				if err != nil || req.Limit < 1 {
+					s.handleError(w, r, req, FieldErrors{{Field: keyLimit,
+						Message: "must be a positive integer"}})
+					return
+				}
+			}
+			ks, next, err := s.apiKeys.APIKeysByUserID(r.Context(), req.UserID, req.Cursor, req.Limit)
+			s.respondJsonOn(w, r, req, newAPIKeysPage(ks, next), http.StatusOK, err)
+		}),
+	)
+}
+
+/**
+ * @api {delete} /users/:userID/apikeys/:keyID Revoke API Key
+ * @apiName RevokeAPIKey
+ * @apiVersion 0.1.0
+ * @apiGroup APIKeys
+ * @apiDescription The bearer token must belong to the user.
+ *
+ * @apiHeader x-api-key the api key
+ * @apiHeader Authorization Bearer token of the user e.g. "Bearer eyJhbGciOi..."
+ *
+ * @apiParam {String} userID ID of the user owning the API key.
+ * @apiParam {String} keyID ID of the API key to revoke.
+ *
+ * @apiSuccess (204) {null} body Empty.
+ *
+ */
+func (s *handler) handleDeleteAPIKey(r *mux.Router) {
+	r.Methods(http.MethodDelete).
+		Path("/users/{" + keyUserID + "}/apikeys/{" + keyKeyID + "}").
+		HandlerFunc(
+		s.jwtGuardChain(routeRevokeAPIKey, func(w http.ResponseWriter, r *http.Request) {
+			req := struct {
+				UserID string `json:"userID"`
+				KeyID  string `json:"keyID"`
+			}{
+				UserID: mux.Vars(r)[keyUserID],
+				KeyID:  mux.Vars(r)[keyKeyID],
+			}
+			if err := claimsOwnUser(r, req.UserID); err != nil {
+				s.handleError(w, r, req, err)
+				return
+			}
+			if err := s.apiKeys.DeleteAPIKey(r.Context(), req.UserID, req.KeyID); err != nil {
+				s.handleError(w, r, req, err)
+				return
+			}
+			w.WriteHeader(http.StatusNoContent)
 		}),
 	)
 }
@@ -107,68 +333,373 @@ func (s *handler) handleStatus(r *mux.Router) {
  * @apiSuccess (200) {html} docs Docs page to be viewed on browser.
  *
  */
+
+/**
+ * @api {get} /docs/openapi.json OpenAPI
+ * @apiName OpenAPI
+ * @apiVersion 0.1.0
+ * @apiGroup Service
+ * @apiDescription OpenAPI 3 document of this API, generated from these docs
+ * for use with client SDK generators and contract testing tools.
+ *
+ * @apiSuccess (200) {Object} openapi OpenAPI 3 document.
+ *
+ */
 func (s *handler) handleDocs(r *mux.Router) {
 	r.PathPrefix("/" + config.DocsPath).
-		Handler(http.FileServer(http.Dir(s.docsDir)))
+		HandlerFunc(s.prepLogger(s.rateLimit(routeDocs,
+			http.FileServer(http.Dir(s.docsDir)).ServeHTTP)))
+}
+
+/**
+ * @api {get} /metrics Metrics
+ * @apiName Metrics
+ * @apiVersion 0.1.0
+ * @apiGroup Service
+ * @apiDescription Request, DB and Go runtime metrics in the Prometheus text
+ * format. Only available if enabled in the config.
+ *
+ * @apiHeader [x-api-key] the api key (only if metrics guarding is enabled)
+ *
+ * @apiSuccess (200) {text} metrics Metrics in the Prometheus text format.
+ *
+ */
+func (s *handler) handleMetrics(r *mux.Router) {
+	if s.metrics == nil {
+		return
+	}
+	serve := s.metrics.Handler().ServeHTTP
+	chain := s.prepLogger(s.rateLimit(routeMetrics, serve))
+	if s.guardMetrics {
+		chain = s.apiGuardChain(routeMetrics, serve)
+	}
+	r.Methods(http.MethodGet).
+		Path("/" + config.MetricsPath).
+		HandlerFunc(chain)
+}
+
+/**
+ * @api {get} /healthz Liveness
+ * @apiName Healthz
+ * @apiVersion 0.1.0
+ * @apiGroup Service
+ * @apiDescription Reports that the process is alive. Requires no API key.
+ *
+ * @apiSuccess (200) {Boolean} alive Always true.
+ *
+ */
+func (s *handler) handleHealthz(r *mux.Router) {
+	r.Methods(http.MethodGet).
+		Path("/" + config.HealthzPath).
+		HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			writeJSON(w, struct {
+				Alive bool `json:"alive"`
+			}{Alive: true}, http.StatusOK)
+		})
+}
+
+/**
+ * @api {get} /readyz Readiness
+ * @apiName Readyz
+ * @apiVersion 0.1.0
+ * @apiGroup Service
+ * @apiDescription Reports whether the micro-service is ready to serve
+ * requests i.e. the DB is reachable and on the expected schema version,
+ * JWT verification keys are loaded and the RPC service is registered.
+ * Requires no API key.
+ *
+ * @apiSuccess (200) {Boolean} ready true.
+ * @apiSuccess (200) {Object[]} checks Outcome of each readiness check.
+ * @apiSuccess (200) {String} checks.name Name of the check e.g. db, jwtKey, registry.
+ * @apiSuccess (200) {Boolean} checks.ready Whether the check passed.
+ * @apiSuccess (200) {String} [checks.error] Why the check failed.
+ *
+ * @apiError (503) {Boolean} ready false.
+ * @apiError (503) {Object[]} checks As for 200, at least one check failed.
+ *
+ */
+func (s *handler) handleReadyz(r *mux.Router) {
+	if s.health == nil {
+		return
+	}
+	r.Methods(http.MethodGet).
+		Path("/" + config.ReadyzPath).
+		HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			rep := s.health.Ready(r.Context())
+			code := http.StatusOK
+			if !rep.Ready {
+				code = http.StatusServiceUnavailable
+			}
+			writeJSON(w, rep, code)
+		})
 }
 
 func (s handler) handleNotFound(r *mux.Router) {
 	r.NotFoundHandler = http.HandlerFunc(
 		s.prepLogger(func(w http.ResponseWriter, r *http.Request) {
-			http.Error(w, "Nothing to see here", http.StatusNotFound)
+			writeError(w, r, http.StatusNotFound, msgNotFound, nil)
 		}),
 	)
 }
 
-func (s *handler) apiGuardChain(next http.HandlerFunc) http.HandlerFunc {
-	return s.prepLogger(s.guardRoute(next))
+func (s *handler) apiGuardChain(route string, next http.HandlerFunc) http.HandlerFunc {
+	return s.prepLogger(s.guardRoute(s.rateLimit(route, next)))
+}
+
+// jwtGuardChain is the apiGuardChain followed by validation of the request's
+// bearer token (see bearerAuth).
+func (s *handler) jwtGuardChain(route string, next http.HandlerFunc) http.HandlerFunc {
+	return s.apiGuardChain(route, s.bearerAuth(next))
 }
 
 func (s handler) prepLogger(next http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 
 		log := s.logger.WithHTTPRequest(r).
-			WithField(logging.FieldTransID, uuid.New())
+			WithField(logging.FieldTransID, tracing.RequestID(r.Context()))
+		ctx := r.Context()
+		if subject, ok := certs.VerifiedSubject(r.TLS); ok {
+			log = log.WithField(logging.FieldClientCertSubject, subject)
+			ctx = api.ContextWithClientCertSubject(ctx, subject)
+		}
 
 		log.WithFields(map[string]interface{}{
 			logging.FieldURLPath:    r.URL.Path,
 			logging.FieldHTTPMethod: r.Method,
 		}).Info("new request")
 
-		ctx := context.WithValue(r.Context(), ctxKeyLog, log)
+		ctx = context.WithValue(ctx, ctxKeyLog, log)
 		next.ServeHTTP(w, r.WithContext(ctx))
 	}
 }
 
+// requestLogger returns the logger acquired by the prepLogger middleware on
+// r or, for requests that did not go through prepLogger, the handler's
+// logger with the request and its transaction ID.
+func (s *handler) requestLogger(r *http.Request) logging.Logger {
+	if log, ok := r.Context().Value(ctxKeyLog).(logging.Logger); ok {
+		return log
+	}
+	return s.logger.WithHTTPRequest(r).
+		WithField(logging.FieldTransID, tracing.RequestID(r.Context()))
+}
+
 func (s *handler) guardRoute(next http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		APIKey := r.Header.Get(keyAPIKey)
 		clUsrID, err := s.guard.APIKeyValid([]byte(APIKey))
-		log := r.Context().Value(ctxKeyLog).(logging.Logger).
+		log := s.requestLogger(r).
 			WithField(logging.FieldClientAppUserID, clUsrID)
 		ctx := context.WithValue(r.Context(), ctxKeyLog, log)
 		if err != nil {
-			handleError(w, r.WithContext(ctx), nil, err, s)
+			s.handleError(w, r.WithContext(ctx), nil, err)
 			return
 		}
+		ctx = api.ContextWithClientAppUserID(ctx, clUsrID)
 		next.ServeHTTP(w, r.WithContext(ctx))
 	}
 }
 
+// rateLimit limits requests to route per client. Clients are identified by
+// the client application user ID set by guardRoute or, on routes without
+// guardRoute, by their remote IP address. Throttled requests get a 429 with
+// a Retry-After header. Requests are let through if the limiter fails.
+func (s *handler) rateLimit(route string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		clientKey, ok := api.ClientAppUserIDFromContext(r.Context())
+		if ok {
+			clientKey = "user:" + clientKey
+		} else {
+			clientKey = "ip:" + remoteIP(r)
+		}
+		allowed, wait, err := s.limiter.Allow(route, clientKey)
+		log := s.requestLogger(r)
+		if err != nil {
+			log.Warnf("rate limit: %v", err)
+			next.ServeHTTP(w, r)
+			return
+		}
+		if !allowed {
+			log.WithField(logging.FieldResponseCode, http.StatusTooManyRequests).
+				Warnf("rate limit exceeded for %s on %s", clientKey, route)
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+			writeError(w, r, http.StatusTooManyRequests, msgRateLimited, nil)
+			return
+		}
+		next.ServeHTTP(w, r)
+	}
+}
+
+// bearerAuth validates the JWT in the request's Authorization header and
+// makes its claims available through ClaimsFromContext(). The user ID and
+// roles are added to the request's logger fields.
+func (s *handler) bearerAuth(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		authHeader := r.Header.Get(keyAuth)
+		if !strings.HasPrefix(authHeader, bearerPrefix) {
+			s.handleError(w, r, nil, errors.NewUnauthorized("bearer token required"))
+			return
+		}
+		tkn := strings.TrimSpace(strings.TrimPrefix(authHeader, bearerPrefix))
+		claims := new(api.Claims)
+		if _, err := s.jwter.Validate(tkn, claims); err != nil {
+			// e.g. the verification keys could not be fetched.
+			if retryableErrCheck.IsRetryableError(err) {
+				s.handleError(w, r, nil, err)
+				return
+			}
+			s.handleError(w, r, nil, errors.NewUnauthorizedf("invalid bearer token: %v", err))
+			return
+		}
+		log := s.requestLogger(r).
+			WithFields(map[string]interface{}{
+				logging.FieldUserID:    claims.UserID,
+				logging.FieldUserRoles: claims.Roles,
+			})
+		ctx := context.WithValue(r.Context(), ctxKeyLog, log)
+		ctx = api.ContextWithClaims(ctx, *claims)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	}
+}
+
+// bearerAuthIfPresent is bearerAuth for requests with an Authorization
+// header. Requests without one are passed on to next as is.
+func (s *handler) bearerAuthIfPresent(next http.HandlerFunc) http.HandlerFunc {
+	withAuth := s.bearerAuth(next)
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get(keyAuth) == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		withAuth.ServeHTTP(w, r)
+	}
+}
+
+// ClaimsFromContext returns the JWT claims of a request that went through
+// bearer token validation.
+func ClaimsFromContext(ctx context.Context) (api.Claims, bool) {
+	return api.ClaimsFromContext(ctx)
+}
+
+// ClientCertSubjectFromContext returns the subject of the TLS client
+// certificate verified for a request (mutual TLS).
+func ClientCertSubjectFromContext(ctx context.Context) (string, bool) {
+	return api.ClientCertSubjectFromContext(ctx)
+}
+
+// instrument wraps requests handled by r in a span named after the route
+// (path template) continuing any trace in the request headers, and records
+// their count and latency by route and status code if metrics are enabled.
+// The request ID in the request headers (or the trace ID if there is none)
+// is added to the request context and echoed in the response headers.
+// Panics in handlers are recovered and responded to with a 500 (see
+// recoverPanics).
+func (s *handler) instrument(r *mux.Router) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		start := time.Now()
+		route := routeNotFound
+		var match mux.RouteMatch
+		if r.Match(req, &match) && match.Route != nil {
+			if tpl, err := match.Route.GetPathTemplate(); err == nil {
+				route = tpl
+			}
+		}
+
+		ctx := tracing.Propagator.Extract(req.Context(), propagation.HeaderCarrier(req.Header))
+		ctx, span := s.tracer.Start(ctx, req.Method+" "+route,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				semconv.HTTPRequestMethodKey.String(req.Method),
+				semconv.HTTPRoute(route),
+				semconv.URLPath(req.URL.Path),
+			),
+		)
+		defer span.End()
+
+		reqID := req.Header.Get(s.reqIDHeader)
+		if !tracing.ValidRequestID(reqID) {
+			reqID = tracing.TransID(ctx)
+		}
+		ctx = tracing.ContextWithRequestID(ctx, reqID)
+		w.Header().Set(s.reqIDHeader, reqID)
+
+		sw := &statusWriter{ResponseWriter: w}
+		s.recoverPanics(r).ServeHTTP(sw, req.WithContext(ctx))
+
+		code := sw.status()
+		span.SetAttributes(semconv.HTTPResponseStatusCode(code))
+		if code >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(code))
+		}
+		if s.metrics != nil {
+			s.metrics.ObserveHTTP(route, req.Method, code, time.Since(start))
+		}
+	})
+}
+
+// statusWriter records the status code written to the http.ResponseWriter.
+type statusWriter struct {
+	http.ResponseWriter
+	code int
+}
+
+func (w *statusWriter) WriteHeader(code int) {
+	if w.code == 0 {
+		w.code = code
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *statusWriter) Write(b []byte) (int, error) {
+	if w.code == 0 {
+		w.code = http.StatusOK
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *statusWriter) status() int {
+	if w.code == 0 {
+		return http.StatusOK
+	}
+	return w.code
+}
+
+// remoteIP returns the IP address (without port) of the client that sent r.
+func remoteIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// claimsOwnUser returns a forbidden error if the claims in r do not belong
+// to userID.
+func claimsOwnUser(r *http.Request, userID string) error {
+	claims, ok := ClaimsFromContext(r.Context())
+	if !ok {
+		return errors.NewUnauthorized("bearer token required")
+	}
+	if claims.UserID != userID {
+		return errors.NewForbiddenf("bearer token does not belong to user %s", userID)
+	}
+	return nil
+}
+
 // respondJsonOn marshals respData to json and writes it and the code as the
 // http header to w. If err is not nil, handleError is called instead of the
 // documented write to w.
 func (s *handler) respondJsonOn(w http.ResponseWriter, r *http.Request, reqData interface{},
-	respData interface{}, code int, err error, errSrc errors.ToHTTPResponser) int {
+	respData interface{}, code int, err error) int {
 
 	if err != nil {
-		handleError(w, r, reqData, err, errSrc)
+		s.handleError(w, r, reqData, err)
 		return 0
 	}
 
 	respBytes, err := json.Marshal(respData)
 	if err != nil {
-		handleError(w, r, reqData, err, errSrc)
+		s.handleError(w, r, reqData, err)
 		return 0
 	}
 
@@ -177,7 +708,7 @@ func (s *handler) respondJsonOn(w http.ResponseWriter, r *http.Request, reqData
 
 	i, err := w.Write(respBytes)
 	if err != nil {
-		log := r.Context().Value(ctxKeyLog).(logging.Logger)
+		log := s.requestLogger(r)
 		log.Errorf("unable write data to response stream: %v", err)
 		return i
 	}
@@ -185,21 +716,43 @@ func (s *handler) respondJsonOn(w http.ResponseWriter, r *http.Request, reqData
 	return i
 }
 
-// handleError writes an error to w using errSrc's logic and logs the error
-// using the logger acquired by the prepLogger middleware on r. reqData is
-// included in the log data.
-func handleError(w http.ResponseWriter, r *http.Request, reqData interface{}, err error, errSrc errors.ToHTTPResponser) {
-	reqDataB, _ := json.Marshal(reqData)
-	log := r.Context().Value(ctxKeyLog).(logging.Logger).
-		WithField(logging.FieldRequest, string(reqDataB))
-
-	if code, ok := errSrc.ToHTTPResponse(err, w); ok {
-		log.WithField(logging.FieldResponseCode, code).Warn(err)
+// writeJSON writes data as JSON with the code as the http header to w.
+// Unlike respondJsonOn it needs no logger on the request; it serves
+// probes which are not logged.
+func writeJSON(w http.ResponseWriter, data interface{}, code int) {
+	respBytes, err := json.Marshal(data)
+	if err != nil {
+		http.Error(w, msgInternal, http.StatusInternalServerError)
 		return
 	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	w.Write(respBytes)
+}
 
-	log.WithField(logging.FieldResponseCode, http.StatusInternalServerError).
-		Error(err)
-	http.Error(w, "Something wicked happened, please try again later",
-		http.StatusInternalServerError)
+type apiKey struct {
+	ID          string    `json:"ID"`
+	UserID      string    `json:"userID"`
+	Created     time.Time `json:"created"`
+	LastUpdated time.Time `json:"lastUpdated"`
+}
+
+type apiKeysPage struct {
+	APIKeys    []apiKey `json:"apiKeys"`
+	NextCursor string   `json:"nextCursor,omitempty"`
+}
+
+// newAPIKeysPage converts ks into their JSON representation, leaving out
+// the key values.
+func newAPIKeysPage(ks []api.Key, nextCursor string) apiKeysPage {
+	p := apiKeysPage{APIKeys: make([]apiKey, len(ks)), NextCursor: nextCursor}
+	for i, k := range ks {
+		p.APIKeys[i] = apiKey{
+			ID:          k.ID,
+			UserID:      k.UserID,
+			Created:     k.Created,
+			LastUpdated: k.LastUpdated,
+		}
+	}
+	return p
 }
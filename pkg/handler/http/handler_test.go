@@ -2,19 +2,29 @@ package http
 
 import (
 	"bytes"
+	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/tomogoma/go-typed-errors"
+	"github.com/tomogoma/seedms/pkg/api"
+	"github.com/tomogoma/seedms/pkg/handler/rpc"
+	"github.com/tomogoma/seedms/pkg/health"
 	"github.com/tomogoma/seedms/pkg/logging"
 	testingH "github.com/tomogoma/seedms/pkg/mocks"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
 )
 
 func TestNewHandler(t *testing.T) {
 	tt := []struct {
 		name           string
 		guard          Guard
+		jwter          JWTValidator
+		apiKeys        APIKeyStore
+		limiter        RateLimiter
 		logger         logging.Logger
 		allowedOrigins []string
 		expErr         bool
@@ -22,32 +32,71 @@ func TestNewHandler(t *testing.T) {
 		{
 			name:           "valid deps",
 			guard:          &testingH.Guard{},
+			jwter:          &testingH.JWTEr{},
+			apiKeys:        &testingH.DB{},
+			limiter:        &testingH.RateLimiter{},
 			logger:         &testingH.Logger{},
 			allowedOrigins: []string{"*"},
 			expErr:         false,
 		},
 		{
-			name:   "valid deps (nil origins)",
-			guard:  &testingH.Guard{},
-			logger: &testingH.Logger{},
-			expErr: false,
+			name:    "valid deps (nil origins)",
+			guard:   &testingH.Guard{},
+			jwter:   &testingH.JWTEr{},
+			apiKeys: &testingH.DB{},
+			limiter: &testingH.RateLimiter{},
+			logger:  &testingH.Logger{},
+			expErr:  false,
 		},
 		{
-			name:   "nil guard",
-			guard:  nil,
-			logger: &testingH.Logger{},
-			expErr: true,
+			name:    "nil guard",
+			guard:   nil,
+			jwter:   &testingH.JWTEr{},
+			apiKeys: &testingH.DB{},
+			limiter: &testingH.RateLimiter{},
+			logger:  &testingH.Logger{},
+			expErr:  true,
 		},
 		{
-			name:   "nil logger",
-			guard:  &testingH.Guard{},
-			logger: nil,
-			expErr: true,
+			name:    "nil JWTValidator",
+			guard:   &testingH.Guard{},
+			jwter:   nil,
+			apiKeys: &testingH.DB{},
+			limiter: &testingH.RateLimiter{},
+			logger:  &testingH.Logger{},
+			expErr:  true,
+		},
+		{
+			name:    "nil APIKeyStore",
+			guard:   &testingH.Guard{},
+			jwter:   &testingH.JWTEr{},
+			apiKeys: nil,
+			limiter: &testingH.RateLimiter{},
+			logger:  &testingH.Logger{},
+			expErr:  true,
+		},
+		{
+			name:    "nil RateLimiter",
+			guard:   &testingH.Guard{},
+			jwter:   &testingH.JWTEr{},
+			apiKeys: &testingH.DB{},
+			limiter: nil,
+			logger:  &testingH.Logger{},
+			expErr:  true,
+		},
+		{
+			name:    "nil logger",
+			guard:   &testingH.Guard{},
+			jwter:   &testingH.JWTEr{},
+			apiKeys: &testingH.DB{},
+			limiter: &testingH.RateLimiter{},
+			logger:  nil,
+			expErr:  true,
 		},
 	}
 	for _, tc := range tt {
 		t.Run(tc.name, func(t *testing.T) {
-			h, err := NewHandler(tc.guard, tc.logger, "", "", tc.allowedOrigins)
+			h, err := NewHandler(tc.guard, tc.jwter, tc.apiKeys, tc.limiter, tc.logger, "", "", tc.allowedOrigins)
 			if tc.expErr {
 				if err == nil {
 					t.Fatal("Expected an error but got nil")
@@ -72,8 +121,16 @@ func TestHandler_handleRoute(t *testing.T) {
 		reqMethod     string
 		reqBody       string
 		reqWBasicAuth bool
+		reqWBearer    bool
 		expStatusCode int
+		expBody       string
 		guard         Guard
+		jwter         *testingH.JWTEr
+		apiKeys       *testingH.DB
+		limiter       *testingH.RateLimiter
+		expRoute      string
+		expClientKey  string
+		expRetryAfter string
 	}{
 		{
 			name:          "status",
@@ -82,6 +139,62 @@ func TestHandler_handleRoute(t *testing.T) {
 			reqMethod:     http.MethodGet,
 			expStatusCode: http.StatusOK,
 		},
+		{
+			name:          "status path prefix",
+			guard:         &testingH.Guard{},
+			reqURLSuffix:  "/status/anything",
+			reqMethod:     http.MethodGet,
+			expStatusCode: http.StatusOK,
+		},
+		{
+			name:          "status API key not read from query",
+			guard:         &testingH.Guard{ExpAPIKValidErr: errors.NewUnauthorized("no API key")},
+			reqURLSuffix:  "/status?APIKey=some.key",
+			reqMethod:     http.MethodGet,
+			expStatusCode: http.StatusUnauthorized,
+		},
+		{
+			name:          "status rate limited per API key user",
+			guard:         &testingH.Guard{ExpAPIKValidUsrID: "app1"},
+			limiter:       &testingH.RateLimiter{ExpDeny: true, ExpRetryAfter: 1500 * time.Millisecond},
+			reqURLSuffix:  "/status",
+			reqMethod:     http.MethodGet,
+			expStatusCode: http.StatusTooManyRequests,
+			expRoute:      routeStatus,
+			expClientKey:  "user:app1",
+			expRetryAfter: "2",
+		},
+		{
+			name:          "status rate limiter error",
+			guard:         &testingH.Guard{},
+			limiter:       &testingH.RateLimiter{ExpAllowErr: errors.New("store down")},
+			reqURLSuffix:  "/status",
+			reqMethod:     http.MethodGet,
+			expStatusCode: http.StatusOK,
+		},
+		{
+			name:          "docs rate limited per IP",
+			guard:         &testingH.Guard{},
+			limiter:       &testingH.RateLimiter{ExpDeny: true, ExpRetryAfter: time.Second},
+			reqURLSuffix:  "/docs",
+			reqMethod:     http.MethodGet,
+			expStatusCode: http.StatusTooManyRequests,
+			expRoute:      routeDocs,
+			expClientKey:  "ip:127.0.0.1",
+			expRetryAfter: "1",
+		},
+		{
+			name:          "list API keys rate limited",
+			guard:         &testingH.Guard{ExpAPIKValidUsrID: "app1"},
+			limiter:       &testingH.RateLimiter{ExpDeny: true, ExpRetryAfter: time.Second},
+			reqURLSuffix:  "/users/123/apikeys",
+			reqWBearer:    true,
+			reqMethod:     http.MethodGet,
+			expStatusCode: http.StatusTooManyRequests,
+			expRoute:      routeListAPIKeys,
+			expClientKey:  "user:app1",
+			expRetryAfter: "1",
+		},
 		{
 			name:          "status guard error",
 			guard:         &testingH.Guard{ExpAPIKValidErr: errors.Newf("guard error")},
@@ -89,6 +202,101 @@ func TestHandler_handleRoute(t *testing.T) {
 			reqMethod:     http.MethodGet,
 			expStatusCode: http.StatusInternalServerError,
 		},
+		{
+			name:          "list API keys",
+			guard:         &testingH.Guard{},
+			apiKeys:       &testingH.DB{ExpAPIKsPage: []api.Key{{ID: "1"}}, ExpAPIKsPageNext: "next"},
+			reqURLSuffix:  "/users/123/apikeys?limit=1",
+			reqWBearer:    true,
+			reqMethod:     http.MethodGet,
+			expStatusCode: http.StatusOK,
+		},
+		{
+			name:          "list API keys bad limit",
+			guard:         &testingH.Guard{},
+			reqURLSuffix:  "/users/123/apikeys?limit=none",
+			reqWBearer:    true,
+			reqMethod:     http.MethodGet,
+			expStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:          "list API keys empty page",
+			guard:         &testingH.Guard{},
+			apiKeys:       &testingH.DB{ExpAPIKsPage: []api.Key{}},
+			reqURLSuffix:  "/users/123/apikeys",
+			reqWBearer:    true,
+			reqMethod:     http.MethodGet,
+			expStatusCode: http.StatusOK,
+			expBody:       `{"apiKeys":[]}`,
+		},
+		{
+			name:          "list API keys retryable error",
+			guard:         &testingH.Guard{},
+			apiKeys:       &testingH.DB{ExpAPIKsPageErr: errors.NewRetryable("try again")},
+			reqURLSuffix:  "/users/123/apikeys",
+			reqWBearer:    true,
+			reqMethod:     http.MethodGet,
+			expStatusCode: http.StatusServiceUnavailable,
+			expRetryAfter: "1",
+		},
+		{
+			name:          "revoke API key",
+			guard:         &testingH.Guard{},
+			reqURLSuffix:  "/users/123/apikeys/1",
+			reqWBearer:    true,
+			reqMethod:     http.MethodDelete,
+			expStatusCode: http.StatusNoContent,
+		},
+		{
+			name:          "revoke API key not found",
+			guard:         &testingH.Guard{},
+			apiKeys:       &testingH.DB{ExpDelAPIKErr: errors.NewNotFound("none")},
+			reqURLSuffix:  "/users/123/apikeys/1",
+			reqWBearer:    true,
+			reqMethod:     http.MethodDelete,
+			expStatusCode: http.StatusNotFound,
+		},
+		{
+			name:          "revoke API key conflict",
+			guard:         &testingH.Guard{},
+			apiKeys:       &testingH.DB{ExpDelAPIKErr: errors.NewConflict("conflict")},
+			reqURLSuffix:  "/users/123/apikeys/1",
+			reqWBearer:    true,
+			reqMethod:     http.MethodDelete,
+			expStatusCode: http.StatusConflict,
+		},
+		{
+			name:          "list API keys no bearer token",
+			guard:         &testingH.Guard{},
+			reqURLSuffix:  "/users/123/apikeys",
+			reqMethod:     http.MethodGet,
+			expStatusCode: http.StatusUnauthorized,
+		},
+		{
+			name:          "list API keys invalid bearer token",
+			guard:         &testingH.Guard{},
+			jwter:         &testingH.JWTEr{ExpValidateErr: errors.New("expired")},
+			reqURLSuffix:  "/users/123/apikeys",
+			reqWBearer:    true,
+			reqMethod:     http.MethodGet,
+			expStatusCode: http.StatusUnauthorized,
+		},
+		{
+			name:          "list API keys of another user",
+			guard:         &testingH.Guard{},
+			reqURLSuffix:  "/users/456/apikeys",
+			reqWBearer:    true,
+			reqMethod:     http.MethodGet,
+			expStatusCode: http.StatusForbidden,
+		},
+		{
+			name:          "revoke API key of another user",
+			guard:         &testingH.Guard{},
+			reqURLSuffix:  "/users/456/apikeys/1",
+			reqWBearer:    true,
+			reqMethod:     http.MethodDelete,
+			expStatusCode: http.StatusForbidden,
+		},
 		{
 			name:          "not found",
 			guard:         &testingH.Guard{},
@@ -101,7 +309,16 @@ func TestHandler_handleRoute(t *testing.T) {
 		t.Run(tc.name, func(t *testing.T) {
 
 			lg := &testingH.Logger{}
-			h := newHandler(t, tc.guard, lg, tc.baseURL, nil)
+			if tc.apiKeys == nil {
+				tc.apiKeys = &testingH.DB{}
+			}
+			if tc.jwter == nil {
+				tc.jwter = &testingH.JWTEr{ExpValidateClaims: &api.Claims{UserID: "123"}}
+			}
+			if tc.limiter == nil {
+				tc.limiter = &testingH.RateLimiter{}
+			}
+			h := newHandler(t, tc.guard, tc.jwter, tc.apiKeys, tc.limiter, lg, tc.baseURL, nil)
 			srvr := httptest.NewServer(h)
 			defer srvr.Close()
 
@@ -116,6 +333,9 @@ func TestHandler_handleRoute(t *testing.T) {
 			if tc.reqWBasicAuth {
 				req.SetBasicAuth("username", "password")
 			}
+			if tc.reqWBearer {
+				req.Header.Set("Authorization", "Bearer some.jwt.token")
+			}
 
 			cl := &http.Client{}
 			resp, err := cl.Do(req)
@@ -129,14 +349,492 @@ func TestHandler_handleRoute(t *testing.T) {
 				t.Errorf("Expected status code %d, got %s",
 					tc.expStatusCode, resp.Status)
 			}
+			if tc.expRoute != "" {
+				if len(tc.limiter.Routes) != 1 || tc.limiter.Routes[0] != tc.expRoute {
+					t.Errorf("Expected rate limit on route %s, got %v",
+						tc.expRoute, tc.limiter.Routes)
+				}
+				if len(tc.limiter.ClientKeys) != 1 || tc.limiter.ClientKeys[0] != tc.expClientKey {
+					t.Errorf("Expected rate limit for client %s, got %v",
+						tc.expClientKey, tc.limiter.ClientKeys)
+				}
+			}
+			if tc.expBody != "" {
+				body, _ := ioutil.ReadAll(resp.Body)
+				if string(body) != tc.expBody {
+					t.Errorf("Expected body '%s', got '%s'", tc.expBody, body)
+				}
+			}
+			if retryAfter := resp.Header.Get("Retry-After"); retryAfter != tc.expRetryAfter {
+				t.Errorf("Expected Retry-After '%s', got '%s'",
+					tc.expRetryAfter, retryAfter)
+			}
+		})
+	}
+}
+
+func TestHandler_metrics(t *testing.T) {
+	tt := []struct {
+		name          string
+		guarded       bool
+		guard         *testingH.Guard
+		reqURLSuffix  string
+		reqWBearer    bool
+		expStatusCode int
+		expBody       string
+		expObserved   testingH.Observation
+	}{
+		{
+			name:          "metrics",
+			guard:         &testingH.Guard{},
+			reqURLSuffix:  "/metrics",
+			expStatusCode: http.StatusOK,
+			expBody:       "metrics",
+			expObserved:   testingH.Observation{Route: "/metrics", Method: http.MethodGet, Code: http.StatusOK},
+		},
+		{
+			name:          "guarded metrics guard error",
+			guarded:       true,
+			guard:         &testingH.Guard{ExpAPIKValidErr: errors.Newf("guard error")},
+			reqURLSuffix:  "/metrics",
+			expStatusCode: http.StatusInternalServerError,
+			expObserved:   testingH.Observation{Route: "/metrics", Method: http.MethodGet, Code: http.StatusInternalServerError},
+		},
+		{
+			name:          "unguarded metrics ignore guard",
+			guard:         &testingH.Guard{ExpAPIKValidErr: errors.Newf("guard error")},
+			reqURLSuffix:  "/metrics",
+			expStatusCode: http.StatusOK,
+			expBody:       "metrics",
+			expObserved:   testingH.Observation{Route: "/metrics", Method: http.MethodGet, Code: http.StatusOK},
+		},
+		{
+			name:          "route labelled by path template",
+			guard:         &testingH.Guard{},
+			reqURLSuffix:  "/users/123/apikeys",
+			reqWBearer:    true,
+			expStatusCode: http.StatusOK,
+			expObserved:   testingH.Observation{Route: "/users/{userID}/apikeys", Method: http.MethodGet, Code: http.StatusOK},
+		},
+		{
+			name:          "not found",
+			guard:         &testingH.Guard{},
+			reqURLSuffix:  "/none_existent",
+			expStatusCode: http.StatusNotFound,
+			expObserved:   testingH.Observation{Route: routeNotFound, Method: http.MethodGet, Code: http.StatusNotFound},
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			lg := &testingH.Logger{}
+			m := &testingH.Metrics{ExpBody: "metrics"}
+			jwter := &testingH.JWTEr{ExpValidateClaims: &api.Claims{UserID: "123"}}
+			h, err := NewHandler(tc.guard, jwter, &testingH.DB{}, &testingH.RateLimiter{}, lg, "", "", nil,
+				WithMetrics(m, tc.guarded))
+			if err != nil {
+				t.Fatalf("http.NewHandler(): %v", err)
+			}
+			srvr := httptest.NewServer(h)
+			defer srvr.Close()
+
+			req, err := http.NewRequest(http.MethodGet, srvr.URL+tc.reqURLSuffix, nil)
+			if err != nil {
+				t.Fatalf("Error setting up: new request: %v", err)
+			}
+			if tc.reqWBearer {
+				req.Header.Set("Authorization", "Bearer some.jwt.token")
+			}
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				lg.PrintLogs(t)
+				t.Fatalf("Do request error: %v", err)
+			}
+			defer resp.Body.Close()
+
+			if resp.StatusCode != tc.expStatusCode {
+				lg.PrintLogs(t)
+				t.Errorf("Expected status code %d, got %s",
+					tc.expStatusCode, resp.Status)
+			}
+			if tc.expBody != "" {
+				body, _ := ioutil.ReadAll(resp.Body)
+				if string(body) != tc.expBody {
+					t.Errorf("Expected body '%s', got '%s'", tc.expBody, body)
+				}
+			}
+			if len(m.HTTP) != 1 || m.HTTP[0] != tc.expObserved {
+				t.Errorf("Expected observations [%+v], got %+v",
+					tc.expObserved, m.HTTP)
+			}
 		})
 	}
 }
 
-func newHandler(t *testing.T, g Guard, lg logging.Logger, baseURL string, allowedOrigins []string) http.Handler {
-	h, err := NewHandler(g, lg, baseURL, "", allowedOrigins)
+func TestHandler_tracing(t *testing.T) {
+	const traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	tt := []struct {
+		name         string
+		traceparent  string
+		reqURLSuffix string
+		expName      string
+		expParent    bool
+	}{
+		{
+			name:         "new trace",
+			reqURLSuffix: "/users/123/apikeys",
+			expName:      "GET /users/{userID}/apikeys",
+		},
+		{
+			name:         "continued trace",
+			traceparent:  "00-" + traceID + "-00f067aa0ba902b7-01",
+			reqURLSuffix: "/users/123/apikeys",
+			expName:      "GET /users/{userID}/apikeys",
+			expParent:    true,
+		},
+		{
+			name:         "not found",
+			reqURLSuffix: "/none_existent",
+			expName:      "GET " + routeNotFound,
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			lg := &testingH.Logger{}
+			sr := tracetest.NewSpanRecorder()
+			tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(sr))
+			jwter := &testingH.JWTEr{ExpValidateClaims: &api.Claims{UserID: "123"}}
+			h, err := NewHandler(&testingH.Guard{}, jwter, &testingH.DB{}, &testingH.RateLimiter{}, lg, "", "", nil,
+				WithTracerProvider(tp))
+			if err != nil {
+				t.Fatalf("http.NewHandler(): %v", err)
+			}
+			srvr := httptest.NewServer(h)
+			defer srvr.Close()
+
+			req, err := http.NewRequest(http.MethodGet, srvr.URL+tc.reqURLSuffix, nil)
+			if err != nil {
+				t.Fatalf("Error setting up: new request: %v", err)
+			}
+			req.Header.Set("Authorization", "Bearer some.jwt.token")
+			if tc.traceparent != "" {
+				req.Header.Set("traceparent", tc.traceparent)
+			}
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatalf("Do request error: %v", err)
+			}
+			resp.Body.Close()
+
+			spans := sr.Ended()
+			if len(spans) != 1 {
+				t.Fatalf("Expected 1 span, got %d", len(spans))
+			}
+			span := spans[0]
+			if span.Name() != tc.expName {
+				t.Errorf("Expected span name '%s', got '%s'", tc.expName, span.Name())
+			}
+			if span.Parent().IsValid() != tc.expParent {
+				t.Errorf("Expected parent %t, got %+v", tc.expParent, span.Parent())
+			}
+			if tc.expParent && span.SpanContext().TraceID().String() != traceID {
+				t.Errorf("Expected trace ID %s, got %s",
+					traceID, span.SpanContext().TraceID())
+			}
+		})
+	}
+}
+
+func TestHandler_health(t *testing.T) {
+	notReady := health.Report{Ready: false, Checks: []health.Result{
+		{Name: health.CheckDB, Ready: true},
+		{Name: health.CheckJWTKey, Error: "no keys"},
+	}}
+	tt := []struct {
+		name          string
+		health        *testingH.HealthChecker
+		reqURLSuffix  string
+		expStatusCode int
+		expBody       string
+	}{
+		{
+			name:          "healthz",
+			reqURLSuffix:  "/healthz",
+			expStatusCode: http.StatusOK,
+			expBody:       `{"alive":true}`,
+		},
+		{
+			name: "readyz ready",
+			health: &testingH.HealthChecker{ExpReport: health.Report{Ready: true,
+				Checks: []health.Result{{Name: health.CheckDB, Ready: true}}}},
+			reqURLSuffix:  "/readyz",
+			expStatusCode: http.StatusOK,
+			expBody:       `{"ready":true,"checks":[{"name":"db","ready":true}]}`,
+		},
+		{
+			name:          "readyz not ready",
+			health:        &testingH.HealthChecker{ExpReport: notReady},
+			reqURLSuffix:  "/readyz",
+			expStatusCode: http.StatusServiceUnavailable,
+			expBody:       `{"ready":false,"checks":[{"name":"db","ready":true},{"name":"jwtKey","ready":false,"error":"no keys"}]}`,
+		},
+		{
+			name:          "readyz without health checker",
+			reqURLSuffix:  "/readyz",
+			expStatusCode: http.StatusNotFound,
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			lg := &testingH.Logger{}
+			var opts []Option
+			if tc.health != nil {
+				opts = append(opts, WithHealthChecker(tc.health))
+			}
+			// probes need no API key.
+			g := &testingH.Guard{ExpAPIKValidErr: errors.NewUnauthorized("guard")}
+			h, err := NewHandler(g, &testingH.JWTEr{}, &testingH.DB{}, &testingH.RateLimiter{}, lg, "", "", nil, opts...)
+			if err != nil {
+				t.Fatalf("http.NewHandler(): %v", err)
+			}
+			srvr := httptest.NewServer(h)
+			defer srvr.Close()
+
+			resp, err := http.Get(srvr.URL + tc.reqURLSuffix)
+			if err != nil {
+				t.Fatalf("Do request error: %v", err)
+			}
+			defer resp.Body.Close()
+
+			if resp.StatusCode != tc.expStatusCode {
+				lg.PrintLogs(t)
+				t.Errorf("Expected status code %d, got %s",
+					tc.expStatusCode, resp.Status)
+			}
+			if tc.expBody != "" {
+				body, _ := ioutil.ReadAll(resp.Body)
+				if string(body) != tc.expBody {
+					t.Errorf("Expected body '%s', got '%s'", tc.expBody, body)
+				}
+			}
+		})
+	}
+}
+
+func TestHandler_requestID(t *testing.T) {
+	tt := []struct {
+		name          string
+		header        string
+		guard         *testingH.Guard
+		reqHeader     string
+		reqID         string
+		expStatusCode int
+		expReqID      string
+	}{
+		{
+			name:          "request ID echoed",
+			guard:         &testingH.Guard{},
+			reqHeader:     "X-Request-ID",
+			reqID:         "req-123",
+			expStatusCode: http.StatusOK,
+			expReqID:      "req-123",
+		},
+		{
+			name:          "request ID echoed on error",
+			guard:         &testingH.Guard{ExpAPIKValidErr: errors.NewUnauthorized("guard")},
+			reqHeader:     "X-Request-ID",
+			reqID:         "req-123",
+			expStatusCode: http.StatusUnauthorized,
+			expReqID:      "req-123",
+		},
+		{
+			name:          "configured header",
+			header:        "X-Correlation-ID",
+			guard:         &testingH.Guard{},
+			reqHeader:     "X-Correlation-ID",
+			reqID:         "req-123",
+			expStatusCode: http.StatusOK,
+			expReqID:      "req-123",
+		},
+		{
+			name:          "invalid request ID replaced",
+			guard:         &testingH.Guard{},
+			reqHeader:     "X-Request-ID",
+			reqID:         "req 123",
+			expStatusCode: http.StatusOK,
+		},
+		{
+			name:          "request ID generated",
+			guard:         &testingH.Guard{},
+			expStatusCode: http.StatusOK,
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			lg := &testingH.Logger{}
+			h, err := NewHandler(tc.guard, &testingH.JWTEr{}, &testingH.DB{}, &testingH.RateLimiter{}, lg, "", "", nil,
+				WithRequestIDHeader(tc.header), withStatusService(t, lg))
+			if err != nil {
+				t.Fatalf("http.NewHandler(): %v", err)
+			}
+			srvr := httptest.NewServer(h)
+			defer srvr.Close()
+
+			req, err := http.NewRequest(http.MethodGet, srvr.URL+"/status", nil)
+			if err != nil {
+				t.Fatalf("Error setting up: new request: %v", err)
+			}
+			if tc.reqHeader != "" {
+				req.Header.Set(tc.reqHeader, tc.reqID)
+			}
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatalf("Do request error: %v", err)
+			}
+			resp.Body.Close()
+
+			if resp.StatusCode != tc.expStatusCode {
+				lg.PrintLogs(t)
+				t.Errorf("Expected status code %d, got %s",
+					tc.expStatusCode, resp.Status)
+			}
+			respHeader := tc.header
+			if respHeader == "" {
+				respHeader = "X-Request-ID"
+			}
+			actReqID := resp.Header.Get(respHeader)
+			if tc.expReqID != "" && actReqID != tc.expReqID {
+				t.Errorf("Expected request ID '%s', got '%s'", tc.expReqID, actReqID)
+			}
+			if actReqID == "" || actReqID == tc.reqID && tc.expReqID == "" {
+				t.Errorf("Expected a generated request ID, got '%s'", actReqID)
+			}
+			if lg.Fields[logging.FieldTransID] != actReqID {
+				t.Errorf("Expected request ID '%s' logged as %s, got '%v'",
+					actReqID, logging.FieldTransID, lg.Fields[logging.FieldTransID])
+			}
+		})
+	}
+}
+
+func TestHandler_errorEnvelope(t *testing.T) {
+	tt := []struct {
+		name          string
+		guard         *testingH.Guard
+		apiKeys       *testingH.DB
+		reqURLSuffix  string
+		accept        string
+		expStatusCode int
+		expCType      string
+		expBody       string
+	}{
+		{
+			name:          "not found",
+			guard:         &testingH.Guard{},
+			reqURLSuffix:  "/none_existent",
+			expStatusCode: http.StatusNotFound,
+			expCType:      "application/json",
+			expBody:       `{"error":{"code":"notFound","message":"Nothing to see here","requestID":"req-123"}}`,
+		},
+		{
+			name:          "not found plain text",
+			guard:         &testingH.Guard{},
+			reqURLSuffix:  "/none_existent",
+			accept:        "text/plain",
+			expStatusCode: http.StatusNotFound,
+			expCType:      "text/plain; charset=utf-8",
+			expBody:       "Nothing to see here\n",
+		},
+		{
+			name:          "field errors",
+			guard:         &testingH.Guard{},
+			reqURLSuffix:  "/users/123/apikeys?limit=none",
+			accept:        "application/json",
+			expStatusCode: http.StatusBadRequest,
+			expCType:      "application/json",
+			expBody: `{"error":{"code":"badRequest","message":"invalid fields: limit: must be a positive integer",` +
+				`"requestID":"req-123","fields":[{"field":"limit","message":"must be a positive integer"}]}}`,
+		},
+		{
+			name:          "unauthorized",
+			guard:         &testingH.Guard{ExpAPIKValidErr: errors.NewUnauthorized("invalid API key")},
+			reqURLSuffix:  "/status",
+			expStatusCode: http.StatusUnauthorized,
+			expCType:      "application/json",
+			expBody:       `{"error":{"code":"unauthorized","message":"invalid API key","requestID":"req-123"}}`,
+		},
+		{
+			name:          "retryable error",
+			guard:         &testingH.Guard{},
+			apiKeys:       &testingH.DB{ExpAPIKsPageErr: errors.NewRetryable("db down")},
+			reqURLSuffix:  "/users/123/apikeys",
+			expStatusCode: http.StatusServiceUnavailable,
+			expCType:      "application/json",
+			expBody:       `{"error":{"code":"unavailable","message":"Service temporarily unavailable, please try again","requestID":"req-123"}}`,
+		},
+		{
+			name:          "internal error details withheld",
+			guard:         &testingH.Guard{ExpAPIKValidErr: errors.Newf("db password is hunter2")},
+			reqURLSuffix:  "/status",
+			expStatusCode: http.StatusInternalServerError,
+			expCType:      "application/json",
+			expBody:       `{"error":{"code":"internal","message":"Something wicked happened, please try again later","requestID":"req-123"}}`,
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			lg := &testingH.Logger{}
+			if tc.apiKeys == nil {
+				tc.apiKeys = &testingH.DB{}
+			}
+			jwter := &testingH.JWTEr{ExpValidateClaims: &api.Claims{UserID: "123"}}
+			h := newHandler(t, tc.guard, jwter, tc.apiKeys, &testingH.RateLimiter{}, lg, "", nil)
+			srvr := httptest.NewServer(h)
+			defer srvr.Close()
+
+			req, err := http.NewRequest(http.MethodGet, srvr.URL+tc.reqURLSuffix, nil)
+			if err != nil {
+				t.Fatalf("Error setting up: new request: %v", err)
+			}
+			req.Header.Set("Authorization", "Bearer some.jwt.token")
+			req.Header.Set("X-Request-ID", "req-123")
+			if tc.accept != "" {
+				req.Header.Set("Accept", tc.accept)
+			}
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatalf("Do request error: %v", err)
+			}
+			defer resp.Body.Close()
+
+			if resp.StatusCode != tc.expStatusCode {
+				lg.PrintLogs(t)
+				t.Errorf("Expected status code %d, got %s",
+					tc.expStatusCode, resp.Status)
+			}
+			if cType := resp.Header.Get("Content-Type"); cType != tc.expCType {
+				t.Errorf("Expected Content-Type '%s', got '%s'", tc.expCType, cType)
+			}
+			body, _ := ioutil.ReadAll(resp.Body)
+			if string(body) != tc.expBody {
+				t.Errorf("Expected body '%s', got '%s'", tc.expBody, body)
+			}
+		})
+	}
+}
+
+func newHandler(t *testing.T, g Guard, jv JWTValidator, ks APIKeyStore, rl RateLimiter, lg logging.Logger, baseURL string, allowedOrigins []string) http.Handler {
+	h, err := NewHandler(g, jv, ks, rl, lg, baseURL, "", allowedOrigins, withStatusService(t, lg))
 	if err != nil {
 		t.Fatalf("http.NewHandler(): %v", err)
 	}
 	return h
 }
+
+// withStatusService serves the StatusRPCRoutes using an rpc.StatusHandler.
+func withStatusService(t *testing.T, lg logging.Logger) Option {
+	sh, err := rpc.NewStatusHandler(&testingH.HealthChecker{}, lg)
+	if err != nil {
+		t.Fatalf("Error setting up: rpc.NewStatusHandler(): %v", err)
+	}
+	return WithRPCService(sh, StatusRPCRoutes, rpc.StatusPublicMethods...)
+}
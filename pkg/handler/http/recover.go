@@ -0,0 +1,38 @@
+package http
+
+import (
+	"net/http"
+	"runtime/debug"
+
+	"github.com/tomogoma/seedms/pkg/logging"
+)
+
+// recoverPanics responds with a 500 to requests whose handlers panic,
+// instead of net/http dropping the connection, and logs the panic value
+// and stack trace with the request's transaction ID (see requestLogger).
+// If the handler had already written the response headers, the panic is
+// only logged. http.ErrAbortHandler panics are passed on to net/http.
+func (s *handler) recoverPanics(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer func() {
+			rec := recover()
+			if rec == nil {
+				return
+			}
+			if rec == http.ErrAbortHandler {
+				panic(rec)
+			}
+			s.requestLogger(r).WithFields(map[string]interface{}{
+				logging.FieldURLPath:      r.URL.Path,
+				logging.FieldHTTPMethod:   r.Method,
+				logging.FieldResponseCode: http.StatusInternalServerError,
+				logging.FieldStack:        string(debug.Stack()),
+			}).Errorf("Panic handling request: %v", rec)
+			if sw, ok := w.(*statusWriter); ok && sw.code != 0 {
+				return
+			}
+			writeError(w, r, http.StatusInternalServerError, msgInternal, nil)
+		}()
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,108 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/tomogoma/seedms/pkg/logging"
+	testingH "github.com/tomogoma/seedms/pkg/mocks"
+	"github.com/tomogoma/seedms/pkg/tracing"
+)
+
+func TestHandler_recoverPanics(t *testing.T) {
+	tt := []struct {
+		name          string
+		handler       http.HandlerFunc
+		expStatusCode int
+		expBody       string
+		expLog        bool
+	}{
+		{
+			name: "no panic",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte("ok"))
+			},
+			expStatusCode: http.StatusOK,
+			expBody:       "ok",
+		},
+		{
+			name: "panic",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				var m map[string]int
+				m["boom"]++
+			},
+			expStatusCode: http.StatusInternalServerError,
+			expBody:       `{"error":{"code":"internal","message":"Something wicked happened, please try again later","requestID":"req-123"}}`,
+			expLog:        true,
+		},
+		{
+			name: "panic after writing headers",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusAccepted)
+				panic(errors.New("boom"))
+			},
+			expStatusCode: http.StatusAccepted,
+			expLog:        true,
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			lg := &testingH.Logger{}
+			s := &handler{logger: lg}
+			req := httptest.NewRequest(http.MethodGet, "/items/1", nil)
+			req = req.WithContext(tracing.ContextWithRequestID(req.Context(), "req-123"))
+			rec := httptest.NewRecorder()
+			sw := &statusWriter{ResponseWriter: rec}
+
+			s.recoverPanics(tc.handler).ServeHTTP(sw, req)
+
+			if sw.status() != tc.expStatusCode {
+				t.Errorf("Expected status code %d, got %d", tc.expStatusCode, sw.status())
+			}
+			if body := rec.Body.String(); body != tc.expBody {
+				t.Errorf("Expected body '%s', got '%s'", tc.expBody, body)
+			}
+			if !tc.expLog {
+				return
+			}
+			if lg.Fields[logging.FieldTransID] != "req-123" {
+				t.Errorf("Expected transaction ID 'req-123' logged, got %v", lg.Fields[logging.FieldTransID])
+			}
+			if stack, _ := lg.Fields[logging.FieldStack].(string); !strings.Contains(stack, "recoverPanics") {
+				t.Errorf("Expected the stack trace logged, got '%s'", stack)
+			}
+		})
+	}
+}
+
+func TestHandler_recoverPanics_abortHandler(t *testing.T) {
+	s := &handler{logger: &testingH.Logger{}}
+	defer func() {
+		if rec := recover(); rec != http.ErrAbortHandler {
+			t.Errorf("Expected http.ErrAbortHandler to be re-panicked, got %v", rec)
+		}
+	}()
+	s.recoverPanics(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic(http.ErrAbortHandler)
+	})).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+}
+
+func TestHandler_handleError_noRequestLogger(t *testing.T) {
+	lg := &testingH.Logger{}
+	s := &handler{logger: lg}
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req = req.WithContext(tracing.ContextWithRequestID(req.Context(), "req-123"))
+	rec := httptest.NewRecorder()
+
+	s.handleError(rec, req, nil, errors.New("boom"))
+
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("Expected status code %d, got %d", http.StatusInternalServerError, rec.Code)
+	}
+	if lg.Fields[logging.FieldTransID] != "req-123" {
+		t.Errorf("Expected transaction ID 'req-123' logged, got %v", lg.Fields[logging.FieldTransID])
+	}
+}
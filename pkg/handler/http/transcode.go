@@ -0,0 +1,242 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/tomogoma/go-typed-errors"
+)
+
+// RPCRoute binds an HTTP route to a method of a go-micro (proto) service
+// handler e.g. an api.StatusHandler. Requests to the route are transcoded
+// into the method's request message and its response message into the JSON
+// response, so the method's logic serves both transports.
+//
+// The request message is populated from, in order of precedence, Headers,
+// path variables and the JSON body or, for GET, HEAD and DELETE requests,
+// the query parameters. Fields are matched by their JSON names. The
+// populated message is then validated against its `validate` struct tags
+// (see validateStruct).
+type RPCRoute struct {
+	// Name labels the route when rate limiting e.g. "status".
+	Name string
+	// RPCMethod is the method as registered with go-micro e.g.
+	// "Status.Check".
+	RPCMethod string
+	// Method is the HTTP method e.g. http.MethodGet.
+	Method string
+	// Path is the mux path template e.g. "/users/{userID}".
+	Path string
+	// PathPrefix matches any path starting with Path e.g. "/status/x" for
+	// "/status", rather than Path only.
+	PathPrefix bool
+	// Headers maps request headers to the request message fields they set
+	// e.g. {"x-api-key": "APIKey"}.
+	Headers map[string]string
+}
+
+type rpcService struct {
+	handler interface{}
+	routes  []RPCRoute
+	public  map[string]bool
+}
+
+// rpcMethod is a method of a go-micro service handler with the signature
+//     func(ctx context.Context, req *Req, resp *Resp) error
+type rpcMethod struct {
+	fn       reflect.Value
+	reqType  reflect.Type
+	respType reflect.Type
+}
+
+var (
+	ctxType        = reflect.TypeOf((*context.Context)(nil)).Elem()
+	errType        = reflect.TypeOf((*error)(nil)).Elem()
+	pathVarRegex   = regexp.MustCompile(`\{([^}:]+)(?::[^}]*)?\}`)
+	queryMsgMethod = map[string]bool{http.MethodGet: true, http.MethodHead: true, http.MethodDelete: true}
+)
+
+// WithRPCService serves routes on HTTP by calling the methods of the
+// go-micro service handler h. Routes go through the same API key guard
+// and rate limits as other routes. As with rpc.NewAuthWrapper, a bearer
+// token is required on all routes except those of publicMethods (e.g.
+// "Status.Check") where it is only validated if present. NewHandler fails
+// if a route does not match a method of h.
+func WithRPCService(h interface{}, routes []RPCRoute, publicMethods ...string) Option {
+	return func(s *handler) {
+		public := make(map[string]bool)
+		for _, m := range publicMethods {
+			public[m] = true
+		}
+		s.rpcServices = append(s.rpcServices, rpcService{handler: h, routes: routes, public: public})
+	}
+}
+
+func (s *handler) handleRPCServices(r *mux.Router) error {
+	for _, svc := range s.rpcServices {
+		for _, route := range svc.routes {
+			m, err := lookupRPCMethod(svc.handler, route)
+			if err != nil {
+				return errors.Newf("RPC route %s %s: %v", route.Method, route.Path, err)
+			}
+			next := s.transcode(m, route)
+			if svc.public[route.RPCMethod] {
+				next = s.apiGuardChain(route.Name, s.bearerAuthIfPresent(next))
+			} else {
+				next = s.jwtGuardChain(route.Name, next)
+			}
+			rt := r.Methods(route.Method)
+			if route.PathPrefix {
+				rt = rt.PathPrefix(route.Path)
+			} else {
+				rt = rt.Path(route.Path)
+			}
+			rt.HandlerFunc(next)
+		}
+	}
+	return nil
+}
+
+// lookupRPCMethod finds the method of h that route binds to and checks that
+// route's path variables and headers name fields of its request message and
+// that the message's validation rules are well formed.
+func lookupRPCMethod(h interface{}, route RPCRoute) (rpcMethod, error) {
+	name := route.RPCMethod[strings.LastIndex(route.RPCMethod, ".")+1:]
+	fn := reflect.ValueOf(h).MethodByName(name)
+	if !fn.IsValid() {
+		return rpcMethod{}, errors.Newf("%T has no method %s", h, name)
+	}
+	t := fn.Type()
+	if t.NumIn() != 3 || t.NumOut() != 1 || t.In(0) != ctxType || t.Out(0) != errType ||
+		!isStructPtr(t.In(1)) || !isStructPtr(t.In(2)) {
+		return rpcMethod{}, errors.Newf("%s is not a go-micro handler method", route.RPCMethod)
+	}
+	m := rpcMethod{fn: fn, reqType: t.In(1).Elem(), respType: t.In(2).Elem()}
+	if err := validateRules(m.reqType); err != nil {
+		return rpcMethod{}, err
+	}
+	var fields []string
+	for _, match := range pathVarRegex.FindAllStringSubmatch(route.Path, -1) {
+		fields = append(fields, match[1])
+	}
+	for _, field := range route.Headers {
+		fields = append(fields, field)
+	}
+	for _, field := range fields {
+		if _, ok := fieldByJSONName(reflect.New(m.reqType).Elem(), field); !ok {
+			return rpcMethod{}, errors.Newf("%s has no field %s", m.reqType, field)
+		}
+	}
+	return m, nil
+}
+
+// transcode calls m with the request message populated from r and responds
+// with the JSON encoded response message. Errors returned by m, including
+// go-micro errors, are responded to through handleError.
+func (s *handler) transcode(m rpcMethod, route RPCRoute) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		req := reflect.New(m.reqType)
+		if err := s.decodeRPCRequest(w, r, route, req); err != nil {
+			s.handleError(w, r, req.Interface(), err)
+			return
+		}
+		resp := reflect.New(m.respType)
+		out := m.fn.Call([]reflect.Value{reflect.ValueOf(r.Context()), req, resp})
+		err, _ := out[0].Interface().(error)
+		s.respondJsonOn(w, r, req.Interface(), resp.Interface(), http.StatusOK, err)
+	}
+}
+
+func (s *handler) decodeRPCRequest(w http.ResponseWriter, r *http.Request, route RPCRoute, req reflect.Value) error {
+	msg := req.Elem()
+	var fe FieldErrors
+	set := func(name, val string, strict bool) {
+		field, ok := fieldByJSONName(msg, name)
+		if !ok {
+			if strict {
+				fe = append(fe, FieldError{Field: name, Message: "is not a known field"})
+			}
+			return
+		}
+		if err := setFieldString(field, val); err != nil {
+			fe = append(fe, FieldError{Field: name, Message: "must be of type " + jsonType(field.Type())})
+		}
+	}
+
+	if queryMsgMethod[r.Method] {
+		for name, vals := range r.URL.Query() {
+			set(name, vals[0], false)
+		}
+	} else if err := s.decodeJSON(w, r, req.Interface()); err != nil {
+		return err
+	}
+	for name, val := range mux.Vars(r) {
+		set(name, val, true)
+	}
+	for header, name := range route.Headers {
+		if val := r.Header.Get(header); val != "" {
+			set(name, val, true)
+		}
+	}
+	if len(fe) > 0 {
+		return fe
+	}
+	return validateStruct(req.Interface())
+}
+
+func isStructPtr(t reflect.Type) bool {
+	return t.Kind() == reflect.Ptr && t.Elem().Kind() == reflect.Struct
+}
+
+// fieldByJSONName returns the exported field of the struct v that is
+// (un)marshalled as name by encoding/json.
+func fieldByJSONName(v reflect.Value, name string) (reflect.Value, bool) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if sf.PkgPath == "" && jsonName(sf) == name {
+			return v.Field(i), true
+		}
+	}
+	return reflect.Value{}, false
+}
+
+// setFieldString parses val into the string, boolean or numeric field v.
+func setFieldString(v reflect.Value, val string) error {
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(val)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(val)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(val, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(val, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(val, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(n)
+	default:
+		return errors.Newf("cannot set %s from a string", v.Kind())
+	}
+	return nil
+}
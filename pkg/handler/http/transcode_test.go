@@ -0,0 +1,273 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	microErrs "github.com/micro/go-micro/errors"
+	"github.com/tomogoma/go-typed-errors"
+	"github.com/tomogoma/seedms/pkg/api"
+	testingH "github.com/tomogoma/seedms/pkg/mocks"
+)
+
+type itemRequest struct {
+	ItemID string `json:"itemID"`
+	Count  int    `json:"count,omitempty" validate:"max=10"`
+	Token  string `json:"token,omitempty"`
+}
+
+type badItemRequest struct {
+	ItemID string `json:"itemID" validate:"email"`
+}
+
+type itemResponse struct {
+	ItemID string `json:"itemID"`
+	Count  int    `json:"count,omitempty"`
+	Token  string `json:"token,omitempty"`
+	UserID string `json:"userID,omitempty"`
+}
+
+// itemService is a go-micro service handler echoing its requests.
+type itemService struct {
+	expErr   error
+	expPanic interface{}
+}
+
+func (s *itemService) Get(c context.Context, req *itemRequest, resp *itemResponse) error {
+	return s.echo(c, req, resp)
+}
+
+func (s *itemService) Update(c context.Context, req *itemRequest, resp *itemResponse) error {
+	return s.echo(c, req, resp)
+}
+
+func (s *itemService) NotRPC(req *itemRequest) error {
+	return nil
+}
+
+func (s *itemService) Bad(c context.Context, req *badItemRequest, resp *itemResponse) error {
+	return nil
+}
+
+func (s *itemService) echo(c context.Context, req *itemRequest, resp *itemResponse) error {
+	if s.expPanic != nil {
+		panic(s.expPanic)
+	}
+	if s.expErr != nil {
+		return s.expErr
+	}
+	claims, _ := api.ClaimsFromContext(c)
+	*resp = itemResponse{ItemID: req.ItemID, Count: req.Count, Token: req.Token, UserID: claims.UserID}
+	return nil
+}
+
+var itemRoutes = []RPCRoute{
+	{
+		Name:      "getItem",
+		RPCMethod: "Item.Get",
+		Method:    http.MethodGet,
+		Path:      "/items/{itemID}",
+		Headers:   map[string]string{"X-Token": "token"},
+	},
+	{
+		Name:      "updateItem",
+		RPCMethod: "Item.Update",
+		Method:    http.MethodPut,
+		Path:      "/items/{itemID}",
+	},
+}
+
+func TestHandler_rpcRoutes(t *testing.T) {
+	tt := []struct {
+		name          string
+		svc           *itemService
+		jwter         *testingH.JWTEr
+		reqMethod     string
+		reqURLSuffix  string
+		reqBody       string
+		reqHeaders    map[string]string
+		expStatusCode int
+		expBody       string
+		expRetryAfter string
+	}{
+		{
+			name:          "query, path and header fields",
+			reqMethod:     http.MethodGet,
+			reqURLSuffix:  "/items/1?count=3&unknown=x",
+			reqHeaders:    map[string]string{"X-Token": "tkn"},
+			expStatusCode: http.StatusOK,
+			expBody:       `{"itemID":"1","count":3,"token":"tkn"}`,
+		},
+		{
+			name:          "public route validates bearer token if present",
+			jwter:         &testingH.JWTEr{ExpValidateErr: errors.New("expired")},
+			reqMethod:     http.MethodGet,
+			reqURLSuffix:  "/items/1",
+			reqHeaders:    map[string]string{"Authorization": "Bearer some.jwt.token"},
+			expStatusCode: http.StatusUnauthorized,
+		},
+		{
+			name:          "invalid query field",
+			reqMethod:     http.MethodGet,
+			reqURLSuffix:  "/items/1?count=three",
+			expStatusCode: http.StatusBadRequest,
+			expBody: `{"error":{"code":"badRequest","message":"invalid fields: count: must be of type number",` +
+				`"requestID":"req-123","fields":[{"field":"count","message":"must be of type number"}]}}`,
+		},
+		{
+			name:          "JSON body and path fields with claims",
+			reqMethod:     http.MethodPut,
+			reqURLSuffix:  "/items/1",
+			reqBody:       `{"itemID":"2","count":3}`,
+			reqHeaders:    map[string]string{"Authorization": "Bearer some.jwt.token"},
+			expStatusCode: http.StatusOK,
+			expBody:       `{"itemID":"1","count":3,"userID":"123"}`,
+		},
+		{
+			name:          "unknown body field",
+			reqMethod:     http.MethodPut,
+			reqURLSuffix:  "/items/1",
+			reqBody:       `{"size":3}`,
+			reqHeaders:    map[string]string{"Authorization": "Bearer some.jwt.token"},
+			expStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:          "validation failed",
+			reqMethod:     http.MethodPut,
+			reqURLSuffix:  "/items/1",
+			reqBody:       `{"count":11}`,
+			reqHeaders:    map[string]string{"Authorization": "Bearer some.jwt.token"},
+			expStatusCode: http.StatusBadRequest,
+			expBody: `{"error":{"code":"badRequest","message":"invalid fields: count: must be at most 10",` +
+				`"requestID":"req-123","fields":[{"field":"count","message":"must be at most 10"}]}}`,
+		},
+		{
+			name:          "private route requires bearer token",
+			reqMethod:     http.MethodPut,
+			reqURLSuffix:  "/items/1",
+			reqBody:       `{}`,
+			expStatusCode: http.StatusUnauthorized,
+		},
+		{
+			name:          "typed error",
+			svc:           &itemService{expErr: errors.NewNotFound("no such item")},
+			reqMethod:     http.MethodGet,
+			reqURLSuffix:  "/items/1",
+			expStatusCode: http.StatusNotFound,
+			expBody:       `{"error":{"code":"notFound","message":"no such item","requestID":"req-123"}}`,
+		},
+		{
+			name:          "go-micro error",
+			svc:           &itemService{expErr: microErrs.New("item", "item locked", http.StatusConflict)},
+			reqMethod:     http.MethodGet,
+			reqURLSuffix:  "/items/1",
+			expStatusCode: http.StatusConflict,
+			expBody:       `{"error":{"code":"conflict","message":"item locked","requestID":"req-123"}}`,
+		},
+		{
+			name:          "go-micro unavailable error",
+			svc:           &itemService{expErr: microErrs.New("item", "db down", http.StatusServiceUnavailable)},
+			reqMethod:     http.MethodGet,
+			reqURLSuffix:  "/items/1",
+			expStatusCode: http.StatusServiceUnavailable,
+			expRetryAfter: "1",
+		},
+		{
+			name:          "go-micro internal error details withheld",
+			svc:           &itemService{expErr: microErrs.InternalServerError("item", "db password is hunter2")},
+			reqMethod:     http.MethodGet,
+			reqURLSuffix:  "/items/1",
+			expStatusCode: http.StatusInternalServerError,
+			expBody:       `{"error":{"code":"internal","message":"Something wicked happened, please try again later","requestID":"req-123"}}`,
+		},
+		{
+			name:          "panic",
+			svc:           &itemService{expPanic: "nil map"},
+			reqMethod:     http.MethodGet,
+			reqURLSuffix:  "/items/1",
+			expStatusCode: http.StatusInternalServerError,
+			expBody:       `{"error":{"code":"internal","message":"Something wicked happened, please try again later","requestID":"req-123"}}`,
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			lg := &testingH.Logger{}
+			if tc.svc == nil {
+				tc.svc = &itemService{}
+			}
+			if tc.jwter == nil {
+				tc.jwter = &testingH.JWTEr{ExpValidateClaims: &api.Claims{UserID: "123"}}
+			}
+			h, err := NewHandler(&testingH.Guard{}, tc.jwter, &testingH.DB{}, &testingH.RateLimiter{}, lg, "", "", nil,
+				WithRPCService(tc.svc, itemRoutes, "Item.Get"))
+			if err != nil {
+				t.Fatalf("http.NewHandler(): %v", err)
+			}
+			srvr := httptest.NewServer(h)
+			defer srvr.Close()
+
+			req, err := http.NewRequest(tc.reqMethod, srvr.URL+tc.reqURLSuffix, bytes.NewReader([]byte(tc.reqBody)))
+			if err != nil {
+				t.Fatalf("Error setting up: new request: %v", err)
+			}
+			req.Header.Set("X-Request-ID", "req-123")
+			for k, v := range tc.reqHeaders {
+				req.Header.Set(k, v)
+			}
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatalf("Do request error: %v", err)
+			}
+			defer resp.Body.Close()
+
+			if resp.StatusCode != tc.expStatusCode {
+				lg.PrintLogs(t)
+				t.Errorf("Expected status code %d, got %s",
+					tc.expStatusCode, resp.Status)
+			}
+			body, _ := ioutil.ReadAll(resp.Body)
+			if tc.expBody != "" && string(body) != tc.expBody {
+				t.Errorf("Expected body '%s', got '%s'", tc.expBody, body)
+			}
+			if retryAfter := resp.Header.Get("Retry-After"); retryAfter != tc.expRetryAfter {
+				t.Errorf("Expected Retry-After '%s', got '%s'",
+					tc.expRetryAfter, retryAfter)
+			}
+		})
+	}
+}
+
+func TestNewHandler_rpcRoutes(t *testing.T) {
+	tt := []struct {
+		name   string
+		route  RPCRoute
+		expErr bool
+	}{
+		{name: "valid", route: itemRoutes[0]},
+		{name: "no such method", route: RPCRoute{RPCMethod: "Item.Delete", Method: http.MethodDelete, Path: "/items/{itemID}"}, expErr: true},
+		{name: "not a handler method", route: RPCRoute{RPCMethod: "Item.NotRPC", Method: http.MethodGet, Path: "/items"}, expErr: true},
+		{name: "unknown path field", route: RPCRoute{RPCMethod: "Item.Get", Method: http.MethodGet, Path: "/items/{id}"}, expErr: true},
+		{name: "unknown header field", route: RPCRoute{RPCMethod: "Item.Get", Method: http.MethodGet, Path: "/items",
+			Headers: map[string]string{"X-Size": "size"}}, expErr: true},
+		{name: "malformed validation rule", route: RPCRoute{RPCMethod: "Item.Bad", Method: http.MethodGet, Path: "/items"}, expErr: true},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := NewHandler(&testingH.Guard{}, &testingH.JWTEr{}, &testingH.DB{}, &testingH.RateLimiter{},
+				&testingH.Logger{}, "", "", nil, WithRPCService(&itemService{}, []RPCRoute{tc.route}))
+			if tc.expErr {
+				if err == nil {
+					t.Fatal("Expected an error but got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Got error: %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,263 @@
+package http
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/tomogoma/go-typed-errors"
+)
+
+// validateTag is the struct tag holding a field's validation rules e.g.
+//     Limit int    `json:"limit" validate:"min=1,max=100"`
+//     Role  string `json:"role" validate:"required,oneof=admin user"`
+const validateTag = "validate"
+
+// validateStruct checks the fields of the struct v (or pointer to one)
+// against the rules in their validate tags and returns FieldErrors naming
+// each invalid field by its JSON name, or nil if all are valid.
+// Nested structs (and slices of them) are validated too e.g. a field is
+// named "keys[0].name". Rules are:
+//     required  - the value is not the zero value (or empty).
+//     min=N     - numbers are at least N, strings/slices/maps have at least
+//                 N characters/elements.
+//     max=N     - as min but at most N.
+//     oneof=A B - the (string or number) value is one of the space
+//                 separated values.
+// Rules other than required are skipped for zero values. Malformed rules
+// are programming errors; check the rules of a type with validateRules when
+// building a handler rather than have validateStruct return a (none
+// FieldErrors) error for them on each request.
+func validateStruct(v interface{}) error {
+	var fe FieldErrors
+	if err := validateValue(reflect.ValueOf(v), "", &fe); err != nil {
+		return err
+	}
+	if len(fe) == 0 {
+		return nil
+	}
+	return fe
+}
+
+// validateRules checks that the validate tags of the fields of the struct
+// type t (or pointer to one), including those of nested structs, hold well
+// formed rules that apply to the fields' types.
+func validateRules(t reflect.Type) error {
+	return validateTypeRules(t, make(map[reflect.Type]bool))
+}
+
+func validateTypeRules(t reflect.Type, seen map[reflect.Type]bool) error {
+	for t.Kind() == reflect.Ptr || t.Kind() == reflect.Slice || t.Kind() == reflect.Array {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct || seen[t] {
+		return nil
+	}
+	seen[t] = true
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if sf.PkgPath != "" || jsonName(sf) == "-" {
+			continue
+		}
+		if rules := sf.Tag.Get(validateTag); rules != "" {
+			for _, rule := range strings.Split(rules, ",") {
+				if err := checkRule(rule, sf.Type); err != nil {
+					return errors.Newf("%s.%s: %v", t, sf.Name, err)
+				}
+			}
+		}
+		if err := validateTypeRules(sf.Type, seen); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func validateValue(v reflect.Value, prefix string, fe *FieldErrors) error {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+	switch v.Kind() {
+	case reflect.Struct:
+		return validateFields(v, prefix, fe)
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			if err := validateValue(v.Index(i), fmt.Sprintf("%s[%d]", prefix, i), fe); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func validateFields(v reflect.Value, prefix string, fe *FieldErrors) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if sf.PkgPath != "" {
+			continue // unexported
+		}
+		name := jsonName(sf)
+		if name == "-" {
+			continue
+		}
+		if prefix != "" {
+			name = prefix + "." + name
+		}
+		fv := v.Field(i)
+		msg, err := checkRules(fv, sf.Tag.Get(validateTag))
+		if err != nil {
+			return errors.Newf("%s: %v", name, err)
+		}
+		if msg != "" {
+			*fe = append(*fe, FieldError{Field: name, Message: msg})
+			continue
+		}
+		if err := validateValue(fv, name, fe); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// jsonName returns the name sf is (un)marshalled as by encoding/json.
+func jsonName(sf reflect.StructField) string {
+	name := strings.Split(sf.Tag.Get("json"), ",")[0]
+	if name == "" {
+		return sf.Name
+	}
+	return name
+}
+
+// checkRules returns why v breaks rules, or an empty string if it does not.
+// An error is returned if rules are malformed (see checkRule).
+func checkRules(v reflect.Value, rules string) (string, error) {
+	if rules == "" {
+		return "", nil
+	}
+	isZero := isEmpty(v)
+	for _, rule := range strings.Split(rules, ",") {
+		if err := checkRule(rule, v.Type()); err != nil {
+			return "", err
+		}
+		name, arg := splitRule(rule)
+		if name == "required" {
+			if isZero {
+				return "is required", nil
+			}
+			continue
+		}
+		if isZero {
+			continue
+		}
+		var msg string
+		switch name {
+		case "min":
+			msg = checkBound(v, arg, func(a, b float64) bool { return a >= b }, "at least")
+		case "max":
+			msg = checkBound(v, arg, func(a, b float64) bool { return a <= b }, "at most")
+		case "oneof":
+			msg = checkOneOf(v, arg)
+		}
+		if msg != "" {
+			return msg, nil
+		}
+	}
+	return "", nil
+}
+
+// checkRule returns an error if rule is unknown, malformed or does not
+// apply to values of type t.
+func checkRule(rule string, t reflect.Type) error {
+	name, arg := splitRule(rule)
+	switch name {
+	case "required":
+		return nil
+	case "oneof":
+		if strings.TrimSpace(arg) == "" {
+			return errors.Newf("validation rule '%s' has no values", rule)
+		}
+		return nil
+	case "min", "max":
+		if _, err := strconv.ParseFloat(arg, 64); err != nil {
+			return errors.Newf("malformed validation rule '%s': %v", rule, err)
+		}
+		if t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+		switch t.Kind() {
+		case reflect.String, reflect.Slice, reflect.Array, reflect.Map,
+			reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+			reflect.Float32, reflect.Float64:
+			return nil
+		}
+		return errors.Newf("validation rule '%s' does not apply to %s", rule, t.Kind())
+	default:
+		return errors.Newf("unknown validation rule '%s'", rule)
+	}
+}
+
+func splitRule(rule string) (name, arg string) {
+	if i := strings.Index(rule, "="); i >= 0 {
+		return rule[:i], rule[i+1:]
+	}
+	return rule, ""
+}
+
+// checkBound must only be called for rules that passed checkRule.
+func checkBound(v reflect.Value, arg string, ok func(a, b float64) bool, desc string) string {
+	bound, _ := strconv.ParseFloat(arg, 64)
+	v = reflect.Indirect(v)
+	switch v.Kind() {
+	case reflect.String:
+		if !ok(float64(len([]rune(v.String()))), bound) {
+			return fmt.Sprintf("must be %s %s characters long", desc, arg)
+		}
+	case reflect.Slice, reflect.Array, reflect.Map:
+		if !ok(float64(v.Len()), bound) {
+			return fmt.Sprintf("must have %s %s elements", desc, arg)
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if !ok(float64(v.Int()), bound) {
+			return fmt.Sprintf("must be %s %s", desc, arg)
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if !ok(float64(v.Uint()), bound) {
+			return fmt.Sprintf("must be %s %s", desc, arg)
+		}
+	case reflect.Float32, reflect.Float64:
+		if !ok(v.Float(), bound) {
+			return fmt.Sprintf("must be %s %s", desc, arg)
+		}
+	}
+	return ""
+}
+
+func checkOneOf(v reflect.Value, arg string) string {
+	allowed := strings.Fields(arg)
+	val := fmt.Sprint(reflect.Indirect(v).Interface())
+	for _, a := range allowed {
+		if val == a {
+			return ""
+		}
+	}
+	return "must be one of " + strings.Join(allowed, ", ")
+}
+
+// isEmpty reports whether v is nil, the zero value or an empty
+// string/slice/map.
+func isEmpty(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		return v.IsNil()
+	case reflect.String, reflect.Slice, reflect.Map, reflect.Array:
+		return v.Len() == 0
+	default:
+		return v.IsZero()
+	}
+}
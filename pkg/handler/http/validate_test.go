@@ -0,0 +1,96 @@
+package http
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestValidateStruct(t *testing.T) {
+	type key struct {
+		Name string `json:"name" validate:"required"`
+	}
+	type req struct {
+		UserID string   `json:"userID" validate:"required"`
+		Limit  int      `json:"limit" validate:"min=1,max=100"`
+		Role   string   `json:"role,omitempty" validate:"oneof=admin user"`
+		Note   string   `json:"note" validate:"max=5"`
+		Tags   []string `json:"tags" validate:"max=2"`
+		Ratio  *float64 `json:"ratio" validate:"min=0,max=1"`
+		Keys   []key    `json:"keys"`
+		Owner  *key     `json:"owner"`
+		hidden string   `validate:"required"`
+	}
+	half, two := 0.5, 2.0
+	tt := []struct {
+		name string
+		req  interface{}
+		exp  error
+	}{
+		{
+			name: "valid",
+			req: &req{UserID: "123", Limit: 100, Role: "admin", Note: "héllo",
+				Tags: []string{"a", "b"}, Ratio: &half, Keys: []key{{Name: "k"}}},
+		},
+		{
+			name: "zero values skip rules other than required",
+			req:  req{UserID: "123"},
+		},
+		{
+			name: "invalid fields",
+			req: &req{Limit: 101, Role: "guest", Note: "too long",
+				Tags: []string{"a", "b", "c"}, Ratio: &two,
+				Keys: []key{{Name: "k"}, {}}, Owner: &key{}},
+			exp: FieldErrors{
+				{Field: "userID", Message: "is required"},
+				{Field: "limit", Message: "must be at most 100"},
+				{Field: "role", Message: "must be one of admin, user"},
+				{Field: "note", Message: "must be at most 5 characters long"},
+				{Field: "tags", Message: "must have at most 2 elements"},
+				{Field: "ratio", Message: "must be at most 1"},
+				{Field: "keys[1].name", Message: "is required"},
+				{Field: "owner.name", Message: "is required"},
+			},
+		},
+		{
+			name: "below min",
+			req:  &req{UserID: "123", Limit: -1},
+			exp:  FieldErrors{{Field: "limit", Message: "must be at least 1"}},
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			err := validateStruct(tc.req)
+			if !reflect.DeepEqual(err, tc.exp) {
+				t.Errorf("Expected %v, got %v", tc.exp, err)
+			}
+		})
+	}
+}
+
+func TestValidateStruct_malformedRule(t *testing.T) {
+	tt := []struct {
+		name string
+		req  interface{}
+	}{
+		{name: "unknown rule", req: struct {
+			A string `validate:"email"`
+		}{A: "a"}},
+		{name: "bad bound", req: struct {
+			A int `validate:"min=one"`
+		}{A: 1}},
+		{name: "bound on bool", req: struct {
+			A bool `validate:"min=1"`
+		}{A: true}},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := validateRules(reflect.TypeOf(tc.req)); err == nil {
+				t.Errorf("Expected validateRules() error, got nil")
+			}
+			err := validateStruct(tc.req)
+			if _, ok := err.(FieldErrors); err == nil || ok {
+				t.Errorf("Expected a none FieldErrors error, got %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,84 @@
+package rpc
+
+import (
+	"net/http"
+	"strings"
+
+	microErrs "github.com/micro/go-micro/errors"
+	"github.com/micro/go-micro/metadata"
+	"github.com/micro/go-micro/server"
+	"github.com/tomogoma/seedms/pkg/api"
+	"github.com/tomogoma/seedms/pkg/config"
+	"github.com/tomogoma/seedms/pkg/logging"
+	"golang.org/x/net/context"
+)
+
+type Guard interface {
+	IsUnauthorizedError(error) bool
+	IsForbiddenError(error) bool
+	APIKeyValid(key []byte) (string, error)
+}
+
+const keyAPIKey = "x-api-key"
+
+// NewAPIKeyWrapper returns a go-micro server.HandlerWrapper that validates
+// the API key of a request using g, as the HTTP handler does on its routes,
+// and makes the client application's user ID available to handlers through
+// api.ClientAppUserIDFromContext(). The key is read from the x-api-key entry
+// of the request's metadata or, failing that, the APIKey field of the
+// request message (e.g. api.Request). A key is required for all methods
+// except keylessMethods (e.g. "Status.Health").
+func NewAPIKeyWrapper(g Guard, lg logging.Logger, keylessMethods ...string) server.HandlerWrapper {
+	keyless := make(map[string]bool)
+	for _, m := range keylessMethods {
+		keyless[m] = true
+	}
+	return func(next server.HandlerFunc) server.HandlerFunc {
+		return func(ctx context.Context, req server.Request, rsp interface{}) error {
+			if keyless[req.Method()] {
+				return next(ctx, req, rsp)
+			}
+			id := config.CanonicalRPCName()
+			clUsrID, err := g.APIKeyValid([]byte(apiKey(ctx, req)))
+			if err == nil {
+				return next(api.ContextWithClientAppUserID(ctx, clUsrID), req, rsp)
+			}
+			log := lg.WithFields(map[string]interface{}{
+				logging.FieldRPCMethod:       req.Method(),
+				logging.FieldRequestHandler:  "RPC",
+				logging.FieldClientAppUserID: clUsrID,
+			})
+			var code int32
+			switch {
+			case g.IsUnauthorizedError(err):
+				code = http.StatusUnauthorized
+			case g.IsForbiddenError(err):
+				code = http.StatusForbidden
+			case retryableErrCheck.IsRetryableError(err):
+				code = http.StatusServiceUnavailable
+			default:
+				log.WithField(logging.FieldResponseCode, http.StatusInternalServerError).
+					Errorf("validate API key: %v", err)
+				return microErrs.InternalServerError(id, "Something wicked happened")
+			}
+			log.WithField(logging.FieldResponseCode, code).Warnf("validate API key: %v", err)
+			return microErrs.New(id, err.Error(), code)
+		}
+	}
+}
+
+func apiKey(ctx context.Context, req server.Request) string {
+	if md, ok := metadata.FromContext(ctx); ok {
+		for k, v := range md {
+			if strings.EqualFold(k, keyAPIKey) {
+				return v
+			}
+		}
+	}
+	if msg, ok := req.Request().(interface {
+		GetAPIKey() string
+	}); ok {
+		return msg.GetAPIKey()
+	}
+	return ""
+}
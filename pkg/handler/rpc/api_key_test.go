@@ -0,0 +1,124 @@
+package rpc_test
+
+import (
+	"context"
+	"testing"
+
+	microErrs "github.com/micro/go-micro/errors"
+	"github.com/micro/go-micro/metadata"
+	"github.com/micro/go-micro/server"
+	"github.com/tomogoma/go-typed-errors"
+	"github.com/tomogoma/seedms/pkg/api"
+	"github.com/tomogoma/seedms/pkg/handler/rpc"
+	"github.com/tomogoma/seedms/pkg/mocks"
+)
+
+func TestNewAPIKeyWrapper(t *testing.T) {
+	tt := []struct {
+		name       string
+		method     string
+		md         metadata.Metadata
+		body       interface{}
+		guard      *mocks.Guard
+		expKey     string
+		expClUsrID string
+		expCalled  bool
+		expCode    int32
+	}{
+		{
+			name:       "metadata key",
+			method:     "Status.Check",
+			md:         metadata.Metadata{"X-Api-Key": "some.key"},
+			body:       &api.Request{APIKey: "other.key"},
+			guard:      &mocks.Guard{ExpAPIKValidUsrID: "123"},
+			expKey:     "some.key",
+			expClUsrID: "123",
+			expCalled:  true,
+		},
+		{
+			name:       "request message key",
+			method:     "Status.Check",
+			body:       &api.Request{APIKey: "some.key"},
+			guard:      &mocks.Guard{ExpAPIKValidUsrID: "123"},
+			expKey:     "some.key",
+			expClUsrID: "123",
+			expCalled:  true,
+		},
+		{
+			name:      "keyless method",
+			method:    "Status.Health",
+			body:      &api.HealthRequest{},
+			guard:     &mocks.Guard{ExpAPIKValidErr: errors.NewUnauthorized("no key")},
+			expCalled: true,
+		},
+		{
+			name:    "unauthorized",
+			method:  "Status.Check",
+			body:    &api.Request{},
+			guard:   &mocks.Guard{ExpAPIKValidErr: errors.NewUnauthorized("no key")},
+			expCode: 401,
+		},
+		{
+			name:    "forbidden",
+			method:  "Status.Check",
+			body:    &api.Request{APIKey: "some.key"},
+			guard:   &mocks.Guard{ExpAPIKValidErr: errors.NewForbidden("bad key")},
+			expKey:  "some.key",
+			expCode: 403,
+		},
+		{
+			name:    "retryable",
+			method:  "Status.Check",
+			body:    &api.Request{APIKey: "some.key"},
+			guard:   &mocks.Guard{ExpAPIKValidErr: errors.NewRetryable("db down")},
+			expKey:  "some.key",
+			expCode: 503,
+		},
+		{
+			name:    "internal error",
+			method:  "Status.Check",
+			body:    &api.Request{APIKey: "some.key"},
+			guard:   &mocks.Guard{ExpAPIKValidErr: errors.New("guard")},
+			expKey:  "some.key",
+			expCode: 500,
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tc.md != nil {
+				ctx = metadata.NewContext(ctx, tc.md)
+			}
+			var called bool
+			var clUsrID string
+			next := func(ctx context.Context, req server.Request, rsp interface{}) error {
+				called = true
+				clUsrID, _ = api.ClientAppUserIDFromContext(ctx)
+				return nil
+			}
+			w := rpc.NewAPIKeyWrapper(tc.guard, &mocks.Logger{}, rpc.StatusKeylessMethods...)
+			err := w(next)(ctx, request{method: tc.method, body: tc.body}, nil)
+			if tc.expCode != 0 {
+				mErr, ok := err.(*microErrs.Error)
+				if !ok {
+					t.Fatalf("Expected a go-micro error, got %v", err)
+				}
+				if mErr.Code != tc.expCode {
+					t.Errorf("Expected code %d, got %d", tc.expCode, mErr.Code)
+				}
+			} else if err != nil {
+				t.Fatalf("Got error: %v", err)
+			}
+			if called != tc.expCalled {
+				t.Errorf("Expected next called %t, got %t", tc.expCalled, called)
+			}
+			if clUsrID != tc.expClUsrID {
+				t.Errorf("Expected client app user ID '%s', got '%s'", tc.expClUsrID, clUsrID)
+			}
+			if string(tc.guard.ValidatedAPIKey) != tc.expKey {
+				t.Errorf("Expected validated API key '%s', got '%s'",
+					tc.expKey, tc.guard.ValidatedAPIKey)
+			}
+		})
+	}
+}
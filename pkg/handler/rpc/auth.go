@@ -0,0 +1,102 @@
+package rpc
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/dgrijalva/jwt-go"
+	microErrs "github.com/micro/go-micro/errors"
+	"github.com/micro/go-micro/metadata"
+	"github.com/micro/go-micro/server"
+	"github.com/tomogoma/go-typed-errors"
+	"github.com/tomogoma/seedms/pkg/api"
+	"github.com/tomogoma/seedms/pkg/config"
+	"github.com/tomogoma/seedms/pkg/logging"
+	"golang.org/x/net/context"
+)
+
+type JWTValidator interface {
+	Validate(token string, claims jwt.Claims) (*jwt.Token, error)
+}
+
+const (
+	keyAuth      = "Authorization"
+	bearerPrefix = "Bearer "
+)
+
+var retryableErrCheck = errors.RetryableErrCheck{}
+
+// NewAuthWrapper returns a go-micro server.HandlerWrapper that validates the
+// bearer token in the Authorization entry of a request's metadata using jv
+// and makes its claims available to handlers through ClaimsFromContext().
+// A token is required for all methods (e.g. "Status.Check") except
+// publicMethods where it is only validated if present.
+func NewAuthWrapper(jv JWTValidator, lg logging.Logger, publicMethods ...string) server.HandlerWrapper {
+	public := make(map[string]bool)
+	for _, m := range publicMethods {
+		public[m] = true
+	}
+	return func(next server.HandlerFunc) server.HandlerFunc {
+		return func(ctx context.Context, req server.Request, rsp interface{}) error {
+			id := config.CanonicalRPCName()
+			log := lg.WithFields(map[string]interface{}{
+				logging.FieldRPCMethod:      req.Method(),
+				logging.FieldRequestHandler: "RPC",
+			})
+
+			tkn, ok := bearerToken(ctx)
+			if !ok {
+				if public[req.Method()] {
+					return next(ctx, req, rsp)
+				}
+				log.WithField(logging.FieldResponseCode, http.StatusUnauthorized).
+					Warn("bearer token required")
+				return microErrs.Unauthorized(id, "bearer token required")
+			}
+
+			claims := new(api.Claims)
+			if _, err := jv.Validate(tkn, claims); err != nil {
+				// e.g. the verification keys could not be fetched.
+				if retryableErrCheck.IsRetryableError(err) {
+					log.WithField(logging.FieldResponseCode, http.StatusServiceUnavailable).
+						Warnf("validate bearer token: %v", err)
+					return microErrs.New(id, err.Error(), http.StatusServiceUnavailable)
+				}
+				log.WithField(logging.FieldResponseCode, http.StatusUnauthorized).
+					Warnf("invalid bearer token: %v", err)
+				return microErrs.Unauthorized(id, "invalid bearer token")
+			}
+			return next(api.ContextWithClaims(ctx, *claims), req, rsp)
+		}
+	}
+}
+
+// ClaimsFromContext returns the JWT claims of a request that went through
+// the wrapper returned by NewAuthWrapper().
+func ClaimsFromContext(ctx context.Context) (api.Claims, bool) {
+	return api.ClaimsFromContext(ctx)
+}
+
+// ClientCertSubjectFromContext returns the subject of the TLS client
+// certificate verified for a request (mutual TLS) on servers that support
+// it e.g. the gRPC server.
+func ClientCertSubjectFromContext(ctx context.Context) (string, bool) {
+	return api.ClientCertSubjectFromContext(ctx)
+}
+
+func bearerToken(ctx context.Context) (string, bool) {
+	md, ok := metadata.FromContext(ctx)
+	if !ok {
+		return "", false
+	}
+	for k, v := range md {
+		if !strings.EqualFold(k, keyAuth) {
+			continue
+		}
+		if !strings.HasPrefix(v, bearerPrefix) {
+			return "", false
+		}
+		return strings.TrimSpace(strings.TrimPrefix(v, bearerPrefix)), true
+	}
+	return "", false
+}
@@ -0,0 +1,135 @@
+package rpc_test
+
+import (
+	"context"
+	"testing"
+
+	microErrs "github.com/micro/go-micro/errors"
+	"github.com/micro/go-micro/metadata"
+	"github.com/micro/go-micro/server"
+	"github.com/tomogoma/go-typed-errors"
+	"github.com/tomogoma/seedms/pkg/api"
+	"github.com/tomogoma/seedms/pkg/handler/rpc"
+	"github.com/tomogoma/seedms/pkg/mocks"
+)
+
+type request struct {
+	method string
+	body   interface{}
+}
+
+func (r request) Service() string      { return "" }
+func (r request) Method() string       { return r.method }
+func (r request) ContentType() string  { return "" }
+func (r request) Request() interface{} { return r.body }
+func (r request) Stream() bool         { return false }
+
+func TestNewAuthWrapper(t *testing.T) {
+	tt := []struct {
+		name      string
+		method    string
+		md        metadata.Metadata
+		jwter     *mocks.JWTEr
+		expToken  string
+		expClaims bool
+		expCalled bool
+		expCode   int32
+	}{
+		{
+			name:      "valid token",
+			method:    "Status.Other",
+			md:        metadata.Metadata{"Authorization": "Bearer some.jwt"},
+			jwter:     &mocks.JWTEr{ExpValidateClaims: &api.Claims{UserID: "123"}},
+			expToken:  "some.jwt",
+			expClaims: true,
+			expCalled: true,
+		},
+		{
+			name:      "lower case metadata key",
+			method:    "Status.Other",
+			md:        metadata.Metadata{"authorization": "Bearer some.jwt"},
+			jwter:     &mocks.JWTEr{ExpValidateClaims: &api.Claims{UserID: "123"}},
+			expToken:  "some.jwt",
+			expClaims: true,
+			expCalled: true,
+		},
+		{
+			name:      "public method without token",
+			method:    "Status.Check",
+			jwter:     &mocks.JWTEr{},
+			expCalled: true,
+		},
+		{
+			name:      "public method with valid token",
+			method:    "Status.Check",
+			md:        metadata.Metadata{"Authorization": "Bearer some.jwt"},
+			jwter:     &mocks.JWTEr{ExpValidateClaims: &api.Claims{UserID: "123"}},
+			expToken:  "some.jwt",
+			expClaims: true,
+			expCalled: true,
+		},
+		{
+			name:    "missing token",
+			method:  "Status.Other",
+			jwter:   &mocks.JWTEr{},
+			expCode: 401,
+		},
+		{
+			name:    "non bearer token",
+			method:  "Status.Other",
+			md:      metadata.Metadata{"Authorization": "Basic abc"},
+			jwter:   &mocks.JWTEr{},
+			expCode: 401,
+		},
+		{
+			name:     "invalid token",
+			method:   "Status.Check",
+			md:       metadata.Metadata{"Authorization": "Bearer some.jwt"},
+			jwter:    &mocks.JWTEr{ExpValidateErr: errors.NewUnauthorized("expired")},
+			expToken: "some.jwt",
+			expCode:  401,
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tc.md != nil {
+				ctx = metadata.NewContext(ctx, tc.md)
+			}
+			var called, gotClaims bool
+			next := func(ctx context.Context, req server.Request, rsp interface{}) error {
+				called = true
+				var claims api.Claims
+				claims, gotClaims = rpc.ClaimsFromContext(ctx)
+				if gotClaims && claims.UserID != tc.jwter.ExpValidateClaims.UserID {
+					t.Errorf("Expected claims for user %s, got %s",
+						tc.jwter.ExpValidateClaims.UserID, claims.UserID)
+				}
+				return nil
+			}
+			w := rpc.NewAuthWrapper(tc.jwter, &mocks.Logger{}, "Status.Check")
+			err := w(next)(ctx, request{method: tc.method}, nil)
+			if tc.expCode != 0 {
+				mErr, ok := err.(*microErrs.Error)
+				if !ok {
+					t.Fatalf("Expected a go-micro error, got %v", err)
+				}
+				if mErr.Code != tc.expCode {
+					t.Errorf("Expected code %d, got %d", tc.expCode, mErr.Code)
+				}
+			} else if err != nil {
+				t.Fatalf("Got error: %v", err)
+			}
+			if called != tc.expCalled {
+				t.Errorf("Expected next called %t, got %t", tc.expCalled, called)
+			}
+			if gotClaims != tc.expClaims {
+				t.Errorf("Expected claims in context %t, got %t", tc.expClaims, gotClaims)
+			}
+			if tc.jwter.ValidatedToken != tc.expToken {
+				t.Errorf("Expected validated token '%s', got '%s'",
+					tc.expToken, tc.jwter.ValidatedToken)
+			}
+		})
+	}
+}
@@ -0,0 +1,64 @@
+package rpc
+
+import (
+	"sync"
+
+	"github.com/micro/go-micro/server"
+	"golang.org/x/net/context"
+)
+
+// InFlight tracks RPC requests being handled so that shutdown can wait
+// for them to complete. The zero value is ready for use.
+type InFlight struct {
+	mu   sync.Mutex
+	n    int
+	idle chan struct{}
+}
+
+// Wrapper returns a go-micro server.HandlerWrapper that tracks requests
+// from the time they reach it until their handlers return.
+func (f *InFlight) Wrapper() server.HandlerWrapper {
+	return func(next server.HandlerFunc) server.HandlerFunc {
+		return func(ctx context.Context, req server.Request, rsp interface{}) error {
+			f.add(1)
+			defer f.add(-1)
+			return next(ctx, req, rsp)
+		}
+	}
+}
+
+// Wait blocks until no requests are in flight or ctx is done, in which
+// case ctx's error is returned.
+func (f *InFlight) Wait(ctx context.Context) error {
+	f.mu.Lock()
+	if f.n == 0 {
+		f.mu.Unlock()
+		return nil
+	}
+	idle := f.idleCh()
+	f.mu.Unlock()
+	select {
+	case <-idle:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (f *InFlight) add(delta int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.n += delta
+	if f.n == 0 && f.idle != nil {
+		close(f.idle)
+		f.idle = nil
+	}
+}
+
+// idleCh must be called with f.mu held.
+func (f *InFlight) idleCh() chan struct{} {
+	if f.idle == nil {
+		f.idle = make(chan struct{})
+	}
+	return f.idle
+}
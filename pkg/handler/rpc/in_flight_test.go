@@ -0,0 +1,48 @@
+package rpc_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/micro/go-micro/server"
+	"github.com/tomogoma/seedms/pkg/handler/rpc"
+)
+
+func TestInFlight_Wait(t *testing.T) {
+	f := &rpc.InFlight{}
+
+	if err := f.Wait(context.Background()); err != nil {
+		t.Fatalf("Wait() with no requests in flight: %v", err)
+	}
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	handler := f.Wrapper()(func(ctx context.Context, req server.Request, rsp interface{}) error {
+		close(started)
+		<-release
+		return nil
+	})
+	go handler(context.Background(), request{method: "Status.Check"}, nil)
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := f.Wait(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("Expected deadline exceeded with request in flight, got %v", err)
+	}
+
+	waitErr := make(chan error)
+	go func() {
+		waitErr <- f.Wait(context.Background())
+	}()
+	close(release)
+	select {
+	case err := <-waitErr:
+		if err != nil {
+			t.Fatalf("Wait() after request completed: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("Wait() did not return after request completed")
+	}
+}
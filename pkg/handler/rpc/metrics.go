@@ -0,0 +1,39 @@
+package rpc
+
+import (
+	"net/http"
+	"time"
+
+	microErrs "github.com/micro/go-micro/errors"
+	"github.com/micro/go-micro/server"
+	"golang.org/x/net/context"
+)
+
+type RPCMetrics interface {
+	ObserveRPC(method string, code int, d time.Duration)
+}
+
+// NewMetricsWrapper returns a go-micro server.HandlerWrapper that records
+// the count and latency of requests per method and status code using m.
+// Status codes are taken from go-micro errors returned by handlers; other
+// non-nil errors are recorded as http.StatusInternalServerError.
+func NewMetricsWrapper(m RPCMetrics) server.HandlerWrapper {
+	return func(next server.HandlerFunc) server.HandlerFunc {
+		return func(ctx context.Context, req server.Request, rsp interface{}) error {
+			start := time.Now()
+			err := next(ctx, req, rsp)
+			m.ObserveRPC(req.Method(), statusCode(err), time.Since(start))
+			return err
+		}
+	}
+}
+
+func statusCode(err error) int {
+	if err == nil {
+		return http.StatusOK
+	}
+	if mErr, ok := err.(*microErrs.Error); ok && mErr.Code != 0 {
+		return int(mErr.Code)
+	}
+	return http.StatusInternalServerError
+}
@@ -0,0 +1,40 @@
+package rpc_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	microErrs "github.com/micro/go-micro/errors"
+	"github.com/micro/go-micro/server"
+	"github.com/tomogoma/seedms/pkg/handler/rpc"
+	"github.com/tomogoma/seedms/pkg/mocks"
+)
+
+func TestNewMetricsWrapper(t *testing.T) {
+	tt := []struct {
+		name    string
+		err     error
+		expCode int
+	}{
+		{name: "success", expCode: http.StatusOK},
+		{name: "micro error", err: microErrs.Unauthorized("id", "nope"), expCode: http.StatusUnauthorized},
+		{name: "other error", err: errors.New("boom"), expCode: http.StatusInternalServerError},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			m := &mocks.Metrics{}
+			handler := rpc.NewMetricsWrapper(m)(func(ctx context.Context, req server.Request, rsp interface{}) error {
+				return tc.err
+			})
+			if err := handler(context.Background(), request{method: "Status.Check"}, nil); err != tc.err {
+				t.Fatalf("Expected error %v, got %v", tc.err, err)
+			}
+			exp := mocks.Observation{Method: "Status.Check", Code: tc.expCode}
+			if len(m.RPC) != 1 || m.RPC[0] != exp {
+				t.Errorf("Expected observations [%+v], got %+v", exp, m.RPC)
+			}
+		})
+	}
+}
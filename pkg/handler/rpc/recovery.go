@@ -0,0 +1,40 @@
+package rpc
+
+import (
+	"net/http"
+	"runtime/debug"
+
+	microErrs "github.com/micro/go-micro/errors"
+	"github.com/micro/go-micro/server"
+	"github.com/tomogoma/seedms/pkg/config"
+	"github.com/tomogoma/seedms/pkg/logging"
+	"github.com/tomogoma/seedms/pkg/tracing"
+	"golang.org/x/net/context"
+)
+
+// NewRecoveryWrapper returns a go-micro server.HandlerWrapper that recovers
+// from panics in handlers (and the wrappers it wraps), logging the panic
+// value and stack trace with the request's transaction ID using lg, and
+// returns an internal server error to the caller. Wrap after
+// NewRequestIDWrapper for the request ID to be logged.
+func NewRecoveryWrapper(lg logging.Logger) server.HandlerWrapper {
+	return func(next server.HandlerFunc) server.HandlerFunc {
+		return func(ctx context.Context, req server.Request, rsp interface{}) (err error) {
+			defer func() {
+				rec := recover()
+				if rec == nil {
+					return
+				}
+				lg.WithFields(map[string]interface{}{
+					logging.FieldTransID:        tracing.RequestID(ctx),
+					logging.FieldRPCMethod:      req.Method(),
+					logging.FieldRequestHandler: "RPC",
+					logging.FieldResponseCode:   http.StatusInternalServerError,
+					logging.FieldStack:          string(debug.Stack()),
+				}).Errorf("Panic handling request: %v", rec)
+				err = microErrs.InternalServerError(config.CanonicalRPCName(), "Something wicked happened")
+			}()
+			return next(ctx, req, rsp)
+		}
+	}
+}
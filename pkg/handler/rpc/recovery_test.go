@@ -0,0 +1,94 @@
+package rpc_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	microErrs "github.com/micro/go-micro/errors"
+	"github.com/micro/go-micro/server"
+	"github.com/tomogoma/seedms/pkg/handler/rpc"
+	"github.com/tomogoma/seedms/pkg/logging"
+	"github.com/tomogoma/seedms/pkg/mocks"
+	"github.com/tomogoma/seedms/pkg/tracing"
+)
+
+func TestNewRecoveryWrapper(t *testing.T) {
+	handlerErr := errors.New("boom")
+	tt := []struct {
+		name     string
+		panicVal interface{}
+		err      error
+		expCode  int32
+		expLog   bool
+	}{
+		{name: "success"},
+		{name: "error", err: handlerErr},
+		{name: "panic", panicVal: "nil map", expCode: http.StatusInternalServerError, expLog: true},
+		{name: "panic with error", panicVal: handlerErr, expCode: http.StatusInternalServerError, expLog: true},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			lg := &mocks.Logger{}
+			handler := rpc.NewRecoveryWrapper(lg)(func(ctx context.Context, req server.Request, rsp interface{}) error {
+				if tc.panicVal != nil {
+					panic(tc.panicVal)
+				}
+				return tc.err
+			})
+			ctx := tracing.ContextWithRequestID(context.Background(), "req-123")
+			err := handler(ctx, request{method: "Status.Check"}, nil)
+			if tc.expCode == 0 {
+				if err != tc.err {
+					t.Errorf("Expected error %v, got %v", tc.err, err)
+				}
+			} else if mErr, ok := err.(*microErrs.Error); !ok || mErr.Code != tc.expCode {
+				t.Errorf("Expected a go-micro error with code %d, got %v", tc.expCode, err)
+			}
+			if !tc.expLog {
+				return
+			}
+			if lg.Fields[logging.FieldTransID] != "req-123" {
+				t.Errorf("Expected transaction ID 'req-123' logged, got %v", lg.Fields[logging.FieldTransID])
+			}
+			if stack, _ := lg.Fields[logging.FieldStack].(string); stack == "" {
+				t.Error("Expected the stack trace logged")
+			}
+			if !loggedError(lg) {
+				t.Error("Expected the panic logged as an error")
+			}
+		})
+	}
+}
+
+// TestNewRecoveryWrapper_metrics checks that a panic recovered within the
+// metrics wrapper, as wrapped in main, is observed as an internal error.
+func TestNewRecoveryWrapper_metrics(t *testing.T) {
+	m := &mocks.Metrics{}
+	handler := rpc.NewMetricsWrapper(m)(rpc.NewRecoveryWrapper(&mocks.Logger{})(
+		func(ctx context.Context, req server.Request, rsp interface{}) error {
+			panic("nil map")
+		}))
+	if err := handler(context.Background(), request{method: "Status.Check"}, nil); err == nil {
+		t.Fatal("Expected an error, got nil")
+	}
+	exp := mocks.Observation{Method: "Status.Check", Code: http.StatusInternalServerError}
+	if len(m.RPC) != 1 || m.RPC[0] != exp {
+		t.Errorf("Expected observations [%+v], got %+v", exp, m.RPC)
+	}
+}
+
+func loggedError(lg *mocks.Logger) bool {
+	for _, e := range lg.Logs {
+		if e.Level == mocks.LevelError {
+			return true
+		}
+	}
+	for _, so := range lg.Spinoffs {
+		if loggedError(so) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,29 @@
+package rpc
+
+import (
+	"github.com/micro/go-micro/metadata"
+	"github.com/micro/go-micro/server"
+	"github.com/tomogoma/seedms/pkg/tracing"
+	"golang.org/x/net/context"
+)
+
+// NewRequestIDWrapper returns a go-micro server.HandlerWrapper that adds
+// the request ID in the request's metadata entry named header (or the
+// trace ID if there is none) to the request context for logging. header
+// defaults to tracing.DefaultRequestIDHeader. Wrap after NewTraceWrapper
+// for the trace ID to be available.
+func NewRequestIDWrapper(header string) server.HandlerWrapper {
+	if header == "" {
+		header = tracing.DefaultRequestIDHeader
+	}
+	return func(next server.HandlerFunc) server.HandlerFunc {
+		return func(ctx context.Context, req server.Request, rsp interface{}) error {
+			md, _ := metadata.FromContext(ctx)
+			reqID := metadataCarrier(md).Get(header)
+			if !tracing.ValidRequestID(reqID) {
+				reqID = tracing.TransID(ctx)
+			}
+			return next(tracing.ContextWithRequestID(ctx, reqID), req, rsp)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package rpc_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/micro/go-micro/metadata"
+	"github.com/micro/go-micro/server"
+	"github.com/tomogoma/seedms/pkg/handler/rpc"
+	"github.com/tomogoma/seedms/pkg/tracing"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestNewRequestIDWrapper(t *testing.T) {
+	traceID := trace.TraceID{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16}
+	tt := []struct {
+		name     string
+		header   string
+		md       metadata.Metadata
+		expReqID string
+	}{
+		{
+			name:     "request ID",
+			md:       metadata.Metadata{"X-Request-ID": "req-123"},
+			expReqID: "req-123",
+		},
+		{
+			name:     "canonicalized metadata key",
+			md:       metadata.Metadata{"X-Request-Id": "req-123"},
+			expReqID: "req-123",
+		},
+		{
+			name:     "configured header",
+			header:   "X-Correlation-ID",
+			md:       metadata.Metadata{"X-Correlation-ID": "req-123"},
+			expReqID: "req-123",
+		},
+		{
+			name:     "invalid request ID",
+			md:       metadata.Metadata{"X-Request-ID": "req 123"},
+			expReqID: traceID.String(),
+		},
+		{
+			name:     "no request ID",
+			expReqID: traceID.String(),
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			var actReqID string
+			handler := rpc.NewRequestIDWrapper(tc.header)(func(ctx context.Context, req server.Request, rsp interface{}) error {
+				actReqID = tracing.RequestID(ctx)
+				return nil
+			})
+			ctx := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(
+				trace.SpanContextConfig{TraceID: traceID, SpanID: trace.SpanID{1}}))
+			if tc.md != nil {
+				ctx = metadata.NewContext(ctx, tc.md)
+			}
+			if err := handler(ctx, request{method: "Status.Check"}, nil); err != nil {
+				t.Fatalf("Got error: %v", err)
+			}
+			if actReqID != tc.expReqID {
+				t.Errorf("Expected request ID '%s', got '%s'", tc.expReqID, actReqID)
+			}
+		})
+	}
+}
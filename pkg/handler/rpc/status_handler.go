@@ -1,44 +1,63 @@
 package rpc
 
 import (
-	"encoding/json"
-
-	"github.com/pborman/uuid"
 	"github.com/tomogoma/go-typed-errors"
 	"github.com/tomogoma/seedms/pkg/api"
 	"github.com/tomogoma/seedms/pkg/config"
+	"github.com/tomogoma/seedms/pkg/health"
 	"github.com/tomogoma/seedms/pkg/logging"
+	"github.com/tomogoma/seedms/pkg/tracing"
 	"golang.org/x/net/context"
 )
 
-type Guard interface {
-	IsUnauthorizedError(error) bool
-	IsForbiddenError(error) bool
-	APIKeyValid(key []byte) (string, error)
+type HealthChecker interface {
+	Ready(ctx context.Context) health.Report
 }
 
-type StatusHandler struct {
-	errors.NotImplErrCheck
-	errors.AuthErrCheck
-	errors.ClErrCheck
+var (
+	// StatusPublicMethods are the methods of the StatusHandler that need no
+	// bearer token, for use with NewAuthWrapper (and HTTP transcoding).
+	StatusPublicMethods = []string{"Status.Check", "Status.Health"}
+	// StatusKeylessMethods are the methods of the StatusHandler that need
+	// no API key, for use with NewAPIKeyWrapper.
+	StatusKeylessMethods = []string{"Status.Health"}
+)
 
-	guard  Guard
+// StatusHandler implements the api.Status service. Requests are
+// authenticated by the wrappers of the server serving it (see
+// NewAPIKeyWrapper and NewAuthWrapper) or, on HTTP, by the HTTP handler.
+type StatusHandler struct {
+	health HealthChecker
 	logger logging.Logger
 }
 
-func NewStatusHandler(g Guard, l logging.Logger) (*StatusHandler, error) {
-	if g == nil {
-		return nil, errors.New("Guard was nil")
+func NewStatusHandler(hc HealthChecker, l logging.Logger) (*StatusHandler, error) {
+	if hc == nil {
+		return nil, errors.New("HealthChecker was nil")
 	}
 	if l == nil {
 		return nil, errors.New("Logger was nil")
 	}
 
-	return &StatusHandler{guard: g, logger: l}, nil
+	return &StatusHandler{health: hc, logger: l}, nil
 }
 
-func (sh StatusHandler) prepLogger(method string) logging.Logger {
-	log := sh.logger.WithField(logging.FieldTransID, uuid.New())
+// prepLogger logs the request and returns a logger with its transaction ID
+// and the identities it was authenticated with, if any.
+func (sh StatusHandler) prepLogger(ctx context.Context, method string) logging.Logger {
+	log := sh.logger.WithField(logging.FieldTransID, tracing.RequestID(ctx))
+	if subject, ok := api.ClientCertSubjectFromContext(ctx); ok {
+		log = log.WithField(logging.FieldClientCertSubject, subject)
+	}
+	if clUsrID, ok := api.ClientAppUserIDFromContext(ctx); ok {
+		log = log.WithField(logging.FieldClientAppUserID, clUsrID)
+	}
+	if claims, ok := api.ClaimsFromContext(ctx); ok {
+		log = log.WithFields(map[string]interface{}{
+			logging.FieldUserID:    claims.UserID,
+			logging.FieldUserRoles: claims.Roles,
+		})
+	}
 	log.WithFields(map[string]interface{}{
 		logging.FieldRPCMethod:      method,
 		logging.FieldRequestHandler: "RPC",
@@ -46,26 +65,27 @@ func (sh StatusHandler) prepLogger(method string) logging.Logger {
 	return log
 }
 
+// Check describes the micro-service. It requires an API key (see
+// NewAPIKeyWrapper).
 func (sh *StatusHandler) Check(c context.Context, req *api.Request, resp *api.Response) error {
-	log := sh.prepLogger("check")
-	_, err := sh.guard.APIKeyValid([]byte(req.APIKey))
-	if err != nil {
-		reqDataB, _ := json.Marshal(req)
-		log = log.WithField(logging.FieldRequest, reqDataB)
-		if sh.guard.IsUnauthorizedError(err) {
-			log.Warnf("Unauthorized: %v", err)
-			return err
-		}
-		if sh.guard.IsForbiddenError(err) {
-			log.Warnf("Forbidden: %v", err)
-			return err
-		}
-		log.Errorf("Error checking API Key Valid (guard): %v", err)
-		return errors.Newf("Something wicked happened")
-	}
+	sh.prepLogger(c, "check")
 	resp.Name = config.Name
 	resp.Version = config.VersionFull
 	resp.Description = config.Description
 	resp.CanonicalName = config.CanonicalRPCName()
 	return nil
 }
+
+// Health reports whether the micro-service is ready to serve requests,
+// with the outcome of each readiness check. It requires no API key.
+func (sh *StatusHandler) Health(c context.Context, req *api.HealthRequest, resp *api.HealthResponse) error {
+	sh.prepLogger(c, "health")
+	rep := sh.health.Ready(c)
+	resp.Ready = rep.Ready
+	resp.Checks = make([]*api.HealthCheck, len(rep.Checks))
+	for i, res := range rep.Checks {
+		resp.Checks[i] = &api.HealthCheck{Name: res.Name, Ready: res.Ready, Error: res.Error}
+	}
+	return nil
+}
+
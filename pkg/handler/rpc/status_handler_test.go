@@ -1,45 +1,47 @@
 package rpc_test
 
 import (
+	"reflect"
 	"testing"
 
 	"github.com/tomogoma/seedms/pkg/handler/rpc"
+	"github.com/tomogoma/seedms/pkg/health"
 	"github.com/tomogoma/seedms/pkg/logging"
 	"github.com/tomogoma/seedms/pkg/mocks"
 	"context"
 	"github.com/tomogoma/seedms/pkg/api"
-	"github.com/tomogoma/go-typed-errors"
+	"github.com/tomogoma/seedms/pkg/config"
 )
 
 func TestNewHandler(t *testing.T) {
 	tt := []struct {
 		name   string
-		guard  rpc.Guard
+		health rpc.HealthChecker
 		logger logging.Logger
 		expErr bool
 	}{
 		{
 			name:   "valid deps",
-			guard:  &mocks.Guard{},
+			health: &mocks.HealthChecker{},
 			logger: &mocks.Logger{},
 			expErr: false,
 		},
 		{
-			name:   "nil guard",
-			guard:  nil,
+			name:   "nil health checker",
+			health: nil,
 			logger: &mocks.Logger{},
 			expErr: true,
 		},
 		{
 			name:   "nil logger",
-			guard:  &mocks.Guard{},
+			health: &mocks.HealthChecker{},
 			logger: nil,
 			expErr: true,
 		},
 	}
 	for _, tc := range tt {
 		t.Run(tc.name, func(t *testing.T) {
-			sh, err := rpc.NewStatusHandler(tc.guard, tc.logger)
+			sh, err := rpc.NewStatusHandler(tc.health, tc.logger)
 			if tc.expErr {
 				if err == nil {
 					t.Fatalf("Expected an error, got nil")
@@ -56,57 +58,100 @@ func TestNewHandler(t *testing.T) {
 	}
 }
 
-
 func TestStatusHandler_Check(t *testing.T) {
 	tt := []struct {
-		name string
-		guard *mocks.Guard
-		req *api.Request
-		expErr bool
+		name      string
+		ctx       context.Context
+		expFields map[string]interface{}
 	}{
 		{
-			name: "valid",
-			guard: &mocks.Guard{},
-			req: &api.Request{},
-			expErr: false,
+			name:      "unauthenticated",
+			ctx:       context.TODO(),
+			expFields: map[string]interface{}{},
 		},
 		{
-			name: "forbidden",
-			guard: &mocks.Guard{ExpAPIKValidErr: errors.NewForbidden("guard")},
-			req: &api.Request{},
-			expErr: true,
+			name: "API key and bearer token",
+			ctx: api.ContextWithClaims(
+				api.ContextWithClientAppUserID(context.TODO(), "client-123"),
+				api.Claims{UserID: "user-456", Roles: []string{"admin"}},
+			),
+			expFields: map[string]interface{}{
+				logging.FieldClientAppUserID: "client-123",
+				logging.FieldUserID:          "user-456",
+				logging.FieldUserRoles:       []string{"admin"},
+			},
 		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			lg := &mocks.Logger{}
+			sh := newStatusHandler(t, lg)
+			resp := new(api.Response)
+			if err := sh.Check(tc.ctx, &api.Request{}, resp); err != nil {
+				t.Fatalf("Got error: %v", err)
+			}
+			if resp.CanonicalName != config.CanonicalRPCName() {
+				t.Errorf("Expected canonical name '%s', got '%s'",
+					config.CanonicalRPCName(), resp.CanonicalName)
+			}
+			for _, k := range []string{logging.FieldClientAppUserID, logging.FieldUserID, logging.FieldUserRoles} {
+				expV, expOK := tc.expFields[k]
+				actV, actOK := lg.Fields[k]
+				if expOK != actOK || !reflect.DeepEqual(actV, expV) {
+					t.Errorf("Expected logged %s %v (%t), got %v (%t)", k, expV, expOK, actV, actOK)
+				}
+			}
+		})
+	}
+}
+
+func TestStatusHandler_Health(t *testing.T) {
+	tt := []struct {
+		name    string
+		report  health.Report
+		expResp *api.HealthResponse
+	}{
 		{
-			name: "unauthorized",
-			guard: &mocks.Guard{ExpAPIKValidErr: errors.NewUnauthorized("guard")},
-			req: &api.Request{},
-			expErr: true,
+			name: "ready",
+			report: health.Report{Ready: true, Checks: []health.Result{
+				{Name: health.CheckDB, Ready: true},
+			}},
+			expResp: &api.HealthResponse{Ready: true, Checks: []*api.HealthCheck{
+				{Name: health.CheckDB, Ready: true},
+			}},
 		},
 		{
-			name: "internal error",
-			guard: &mocks.Guard{ExpAPIKValidErr: errors.Newf("guard")},
-			req: &api.Request{},
-			expErr: true,
+			name: "not ready",
+			report: health.Report{Ready: false, Checks: []health.Result{
+				{Name: health.CheckDB, Ready: true},
+				{Name: health.CheckJWTKey, Error: "no keys"},
+			}},
+			expResp: &api.HealthResponse{Ready: false, Checks: []*api.HealthCheck{
+				{Name: health.CheckDB, Ready: true},
+				{Name: health.CheckJWTKey, Error: "no keys"},
+			}},
 		},
 	}
 	for _, tc := range tt {
-		sh := newStatusHandler(t, tc.guard, &mocks.Logger{})
-		resp := new(api.Response)
-		err := sh.Check(context.TODO(), tc.req, resp)
-		if tc.expErr {
-			if err ==nil {
-				t.Fatalf("Expected an error, got nil")
+		t.Run(tc.name, func(t *testing.T) {
+			sh, err := rpc.NewStatusHandler(&mocks.HealthChecker{ExpReport: tc.report},
+				&mocks.Logger{})
+			if err != nil {
+				t.Fatalf("Error setting up: new status handler: %v", err)
+			}
+			resp := new(api.HealthResponse)
+			if err := sh.Health(context.TODO(), &api.HealthRequest{}, resp); err != nil {
+				t.Fatalf("Got error: %v", err)
+			}
+			if !reflect.DeepEqual(resp, tc.expResp) {
+				t.Errorf("Expected %+v, got %+v", tc.expResp, resp)
 			}
-			return
-		}
-		if err != nil {
-			t.Fatalf("Got error: %v", err)
-		}
+		})
 	}
 }
 
-func newStatusHandler(t *testing.T, g rpc.Guard, lg logging.Logger) *rpc.StatusHandler {
-	sh, err := rpc.NewStatusHandler(g, lg)
+func newStatusHandler(t *testing.T, lg logging.Logger) *rpc.StatusHandler {
+	sh, err := rpc.NewStatusHandler(&mocks.HealthChecker{}, lg)
 	if err != nil {
 		t.Fatalf("Error setting up: new status handler: %v", err)
 	}
@@ -0,0 +1,82 @@
+package rpc
+
+import (
+	"net/http"
+	"strings"
+
+	microErrs "github.com/micro/go-micro/errors"
+	"github.com/micro/go-micro/metadata"
+	"github.com/micro/go-micro/server"
+	"github.com/tomogoma/seedms/pkg/tracing"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/net/context"
+)
+
+const tracerName = "github.com/tomogoma/seedms/pkg/handler/rpc"
+
+// NewTraceWrapper returns a go-micro server.HandlerWrapper that wraps
+// requests in a span (created using tp) named after the RPC method e.g.
+// "Status.Check". Spans continue traces propagated in the W3C trace context
+// entries of the request's metadata.
+func NewTraceWrapper(tp trace.TracerProvider) server.HandlerWrapper {
+	tracer := tp.Tracer(tracerName)
+	return func(next server.HandlerFunc) server.HandlerFunc {
+		return func(ctx context.Context, req server.Request, rsp interface{}) error {
+			md, _ := metadata.FromContext(ctx)
+			ctx = tracing.Propagator.Extract(ctx, metadataCarrier(md))
+			service, method := req.Method(), ""
+			if i := strings.LastIndex(service, "."); i >= 0 {
+				service, method = service[:i], service[i+1:]
+			}
+			ctx, span := tracer.Start(ctx, req.Method(),
+				trace.WithSpanKind(trace.SpanKindServer),
+				trace.WithAttributes(
+					semconv.RPCSystemKey.String("go-micro"),
+					semconv.RPCService(service),
+					semconv.RPCMethod(method),
+				),
+			)
+			defer span.End()
+
+			err := next(ctx, req, rsp)
+			if code := statusCode(err); code >= http.StatusInternalServerError {
+				span.SetStatus(codes.Error, err.Error())
+			}
+			if mErr, ok := err.(*microErrs.Error); ok {
+				span.SetAttributes(semconv.HTTPResponseStatusCode(int(mErr.Code)))
+			}
+			return err
+		}
+	}
+}
+
+// metadataCarrier adapts go-micro metadata to a propagation.TextMapCarrier.
+// Keys are matched case-insensitively since transports may canonicalize
+// them e.g. "Traceparent".
+type metadataCarrier metadata.Metadata
+
+func (c metadataCarrier) Get(key string) string {
+	if v, ok := c[key]; ok {
+		return v
+	}
+	for k, v := range c {
+		if strings.EqualFold(k, key) {
+			return v
+		}
+	}
+	return ""
+}
+
+func (c metadataCarrier) Set(key, value string) {
+	c[key] = value
+}
+
+func (c metadataCarrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+	for k := range c {
+		keys = append(keys, k)
+	}
+	return keys
+}
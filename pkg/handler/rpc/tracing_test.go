@@ -0,0 +1,71 @@
+package rpc_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/micro/go-micro/metadata"
+	"github.com/micro/go-micro/server"
+	"github.com/tomogoma/seedms/pkg/handler/rpc"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestNewTraceWrapper(t *testing.T) {
+	const traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	tt := []struct {
+		name      string
+		md        metadata.Metadata
+		expParent bool
+	}{
+		{name: "new trace"},
+		{
+			name:      "continued trace",
+			md:        metadata.Metadata{"traceparent": "00-" + traceID + "-00f067aa0ba902b7-01"},
+			expParent: true,
+		},
+		{
+			name:      "canonicalized metadata key",
+			md:        metadata.Metadata{"Traceparent": "00-" + traceID + "-00f067aa0ba902b7-01"},
+			expParent: true,
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			sr := tracetest.NewSpanRecorder()
+			tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(sr))
+			var handlerSpan trace.SpanContext
+			handler := rpc.NewTraceWrapper(tp)(func(ctx context.Context, req server.Request, rsp interface{}) error {
+				handlerSpan = trace.SpanContextFromContext(ctx)
+				return nil
+			})
+			ctx := context.Background()
+			if tc.md != nil {
+				ctx = metadata.NewContext(ctx, tc.md)
+			}
+			if err := handler(ctx, request{method: "Status.Check"}, nil); err != nil {
+				t.Fatalf("Got error: %v", err)
+			}
+
+			spans := sr.Ended()
+			if len(spans) != 1 {
+				t.Fatalf("Expected 1 span, got %d", len(spans))
+			}
+			span := spans[0]
+			if span.Name() != "Status.Check" {
+				t.Errorf("Expected span name 'Status.Check', got '%s'", span.Name())
+			}
+			if !handlerSpan.Equal(span.SpanContext()) {
+				t.Errorf("Expected the span in the handler's context")
+			}
+			if span.Parent().IsValid() != tc.expParent {
+				t.Errorf("Expected parent %t, got %+v", tc.expParent, span.Parent())
+			}
+			if tc.expParent && span.SpanContext().TraceID().String() != traceID {
+				t.Errorf("Expected trace ID %s, got %s",
+					traceID, span.SpanContext().TraceID())
+			}
+		})
+	}
+}
@@ -0,0 +1,125 @@
+package health
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/tomogoma/go-typed-errors"
+)
+
+// Names of the checks registered by the micro-service.
+const (
+	CheckDB       = "db"
+	CheckJWTKey   = "jwtKey"
+	CheckRegistry = "registry"
+)
+
+// DefaultTimeout is how long each check is given to complete by default.
+const DefaultTimeout = 5 * time.Second
+
+// Check returns a non-nil error if the dependency it checks is not ready.
+type Check func(ctx context.Context) error
+
+// Result is the outcome of a single Check.
+type Result struct {
+	Name  string `json:"name"`
+	Ready bool   `json:"ready"`
+	Error string `json:"error,omitempty"`
+}
+
+// Report is the outcome of all registered checks. Ready is true only if
+// all checks passed.
+type Report struct {
+	Ready  bool     `json:"ready"`
+	Checks []Result `json:"checks"`
+}
+
+// Checker runs the readiness checks registered with it.
+// Use NewChecker() to instantiate.
+type Checker struct {
+	timeout time.Duration
+
+	mu     sync.RWMutex
+	names  []string
+	checks map[string]Check
+}
+
+// Option allows extra configuration for NewChecker. Use the With...
+// functions to set options.
+type Option func(*Checker)
+
+// WithTimeout sets how long each check is given to complete before it is
+// reported as failed. Defaults to DefaultTimeout.
+func WithTimeout(d time.Duration) Option {
+	return func(c *Checker) {
+		c.timeout = d
+	}
+}
+
+func NewChecker(opts ...Option) (*Checker, error) {
+	c := &Checker{timeout: DefaultTimeout, checks: make(map[string]Check)}
+	for _, f := range opts {
+		f(c)
+	}
+	if c.timeout <= 0 {
+		return nil, errors.New("timeout must be greater than 0")
+	}
+	return c, nil
+}
+
+// Register adds check under name, replacing any check already registered
+// under name. Checks are reported in the order they were first registered.
+func (c *Checker) Register(name string, check Check) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.checks[name]; !ok {
+		c.names = append(c.names, name)
+	}
+	c.checks[name] = check
+}
+
+// Ready runs all registered checks concurrently and reports their outcome.
+func (c *Checker) Ready(ctx context.Context) Report {
+	c.mu.RLock()
+	names := append([]string(nil), c.names...)
+	checks := make([]Check, len(names))
+	for i, name := range names {
+		checks[i] = c.checks[name]
+	}
+	c.mu.RUnlock()
+
+	rep := Report{Ready: true, Checks: make([]Result, len(names))}
+	var wg sync.WaitGroup
+	for i := range names {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			rep.Checks[i] = c.run(ctx, names[i], checks[i])
+		}(i)
+	}
+	wg.Wait()
+	for _, res := range rep.Checks {
+		rep.Ready = rep.Ready && res.Ready
+	}
+	return rep
+}
+
+func (c *Checker) run(ctx context.Context, name string, check Check) Result {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- check(ctx)
+	}()
+	var err error
+	select {
+	case err = <-errCh:
+	case <-ctx.Done():
+		err = errors.Newf("check timed out: %v", ctx.Err())
+	}
+	if err != nil {
+		return Result{Name: name, Error: err.Error()}
+	}
+	return Result{Name: name, Ready: true}
+}
@@ -0,0 +1,120 @@
+package health_test
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/tomogoma/go-typed-errors"
+	"github.com/tomogoma/seedms/pkg/health"
+)
+
+func TestNewChecker(t *testing.T) {
+	tt := []struct {
+		name   string
+		opts   []health.Option
+		expErr bool
+	}{
+		{name: "defaults"},
+		{name: "with timeout", opts: []health.Option{health.WithTimeout(time.Second)}},
+		{name: "zero timeout", opts: []health.Option{health.WithTimeout(0)}, expErr: true},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			c, err := health.NewChecker(tc.opts...)
+			if tc.expErr {
+				if err == nil {
+					t.Fatalf("Expected an error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Got error: %v", err)
+			}
+			if c == nil {
+				t.Fatalf("Got nil *Checker")
+			}
+		})
+	}
+}
+
+func TestChecker_Ready(t *testing.T) {
+	ok := func(context.Context) error { return nil }
+	fail := func(context.Context) error { return errors.New("db down") }
+	hang := func(ctx context.Context) error { <-ctx.Done(); time.Sleep(time.Second); return nil }
+
+	tt := []struct {
+		name      string
+		register  map[string]health.Check
+		order     []string
+		expReport health.Report
+	}{
+		{
+			name:      "no checks",
+			expReport: health.Report{Ready: true, Checks: []health.Result{}},
+		},
+		{
+			name:     "all ready",
+			register: map[string]health.Check{"db": ok, "jwtKey": ok},
+			order:    []string{"db", "jwtKey"},
+			expReport: health.Report{Ready: true, Checks: []health.Result{
+				{Name: "db", Ready: true},
+				{Name: "jwtKey", Ready: true},
+			}},
+		},
+		{
+			name:     "failed check",
+			register: map[string]health.Check{"db": fail, "jwtKey": ok},
+			order:    []string{"db", "jwtKey"},
+			expReport: health.Report{Ready: false, Checks: []health.Result{
+				{Name: "db", Error: "db down"},
+				{Name: "jwtKey", Ready: true},
+			}},
+		},
+		{
+			name:     "timed out check",
+			register: map[string]health.Check{"registry": hang},
+			order:    []string{"registry"},
+			expReport: health.Report{Ready: false, Checks: []health.Result{
+				{Name: "registry", Error: "check timed out: context deadline exceeded"},
+			}},
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			c, err := health.NewChecker(health.WithTimeout(20 * time.Millisecond))
+			if err != nil {
+				t.Fatalf("Error setting up: new checker: %v", err)
+			}
+			for _, name := range tc.order {
+				c.Register(name, tc.register[name])
+			}
+			start := time.Now()
+			rep := c.Ready(context.Background())
+			if time.Since(start) > 500*time.Millisecond {
+				t.Errorf("Ready() did not respect the check timeout")
+			}
+			if !reflect.DeepEqual(rep, tc.expReport) {
+				t.Errorf("Expected report %+v, got %+v", tc.expReport, rep)
+			}
+		})
+	}
+}
+
+func TestChecker_Register_replaces(t *testing.T) {
+	c, err := health.NewChecker()
+	if err != nil {
+		t.Fatalf("Error setting up: new checker: %v", err)
+	}
+	c.Register("db", func(context.Context) error { return errors.New("db down") })
+	c.Register("jwtKey", func(context.Context) error { return nil })
+	c.Register("db", func(context.Context) error { return nil })
+	exp := health.Report{Ready: true, Checks: []health.Result{
+		{Name: "db", Ready: true},
+		{Name: "jwtKey", Ready: true},
+	}}
+	if rep := c.Ready(context.Background()); !reflect.DeepEqual(rep, exp) {
+		t.Errorf("Expected report %+v, got %+v", exp, rep)
+	}
+}
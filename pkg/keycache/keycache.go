@@ -0,0 +1,257 @@
+package keycache
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	apiG "github.com/tomogoma/go-api-guard"
+	"github.com/tomogoma/go-typed-errors"
+	"github.com/tomogoma/seedms/pkg/api"
+)
+
+const (
+	DefaultSize        = 10000
+	DefaultTTL         = 1 * time.Minute
+	DefaultNegativeTTL = 10 * time.Second
+)
+
+// KeyStore is the API key store whose results are cached.
+type KeyStore interface {
+	IsNotFoundError(error) bool
+	InsertAPIKey(userID string, key []byte) (apiG.Key, error)
+	APIKeyByUserIDVal(userID string, key []byte) (apiG.Key, error)
+	APIKeysByUserID(ctx context.Context, userID, cursor string, limit int) ([]api.Key, string, error)
+	DeleteAPIKey(ctx context.Context, userID, keyID string) error
+}
+
+// Cache is a KeyStore that caches the results of APIKeyByUserIDVal in
+// memory. Found keys are cached for the TTL and keys that were not found
+// for the negative TTL. Least recently used entries are evicted once the
+// cache holds its maximum number of entries.
+// A user's entries are invalidated when the user's keys are inserted or
+// deleted through the Cache. Changes made through other Cache instances
+// (e.g. other service instances) are only seen after the entries expire.
+type Cache struct {
+	errors.NotFoundErrCheck
+
+	ks     KeyStore
+	size   int
+	ttl    time.Duration
+	negTTL time.Duration
+	now    func() time.Time
+
+	mu      sync.Mutex
+	lru     *list.List
+	entries map[cacheKey]*list.Element
+	byUser  map[string]map[cacheKey]bool
+	// gen is incremented on invalidation so that lookups started before
+	// an invalidation do not cache their (possibly stale) results.
+	gen uint64
+
+	hits, negHits, misses, evictions uint64
+}
+
+// Stats are counters describing the Cache's effectiveness.
+type Stats struct {
+	// Hits counts lookups answered from cache with a valid key.
+	Hits uint64
+	// NegativeHits counts lookups answered from cache with a not found
+	// error.
+	NegativeHits uint64
+	// Misses counts lookups passed on to the KeyStore.
+	Misses uint64
+	// Evictions counts entries evicted to make room for others.
+	Evictions uint64
+	// Entries is the number of entries currently cached.
+	Entries int
+}
+
+type Option func(*Cache)
+
+type cacheKey struct {
+	userID string
+	keyH   [sha256.Size]byte
+}
+
+type entry struct {
+	key     cacheKey
+	apiKey  apiG.Key
+	err     error
+	expires time.Time
+}
+
+// WithSize sets the maximum number of cached entries.
+// Defaults to DefaultSize.
+func WithSize(n int) Option {
+	return func(c *Cache) {
+		c.size = n
+	}
+}
+
+// WithTTL sets how long found keys are cached. Defaults to DefaultTTL.
+func WithTTL(d time.Duration) Option {
+	return func(c *Cache) {
+		c.ttl = d
+	}
+}
+
+// WithNegativeTTL sets how long keys that were not found are cached.
+// Defaults to DefaultNegativeTTL.
+func WithNegativeTTL(d time.Duration) Option {
+	return func(c *Cache) {
+		c.negTTL = d
+	}
+}
+
+func NewCache(ks KeyStore, opts ...Option) (*Cache, error) {
+	if ks == nil {
+		return nil, errors.New("KeyStore was nil")
+	}
+	c := &Cache{
+		ks:      ks,
+		size:    DefaultSize,
+		ttl:     DefaultTTL,
+		negTTL:  DefaultNegativeTTL,
+		now:     time.Now,
+		lru:     list.New(),
+		entries: make(map[cacheKey]*list.Element),
+		byUser:  make(map[string]map[cacheKey]bool),
+	}
+	for _, f := range opts {
+		f(c)
+	}
+	if c.size < 1 {
+		return nil, errors.Newf("invalid cache size %d", c.size)
+	}
+	if c.ttl <= 0 || c.negTTL <= 0 {
+		return nil, errors.New("cache TTLs must be greater than 0")
+	}
+	return c, nil
+}
+
+// APIKeyByUserIDVal returns the cached result of looking up the
+// userID/key combination, looking it up in the KeyStore if none is cached
+// or the cached result has expired. Only found keys and not found errors
+// are cached.
+func (c *Cache) APIKeyByUserIDVal(userID string, key []byte) (apiG.Key, error) {
+	ck := cacheKey{userID: userID, keyH: sha256.Sum256(key)}
+	k, err, gen, ok := c.get(ck)
+	if ok {
+		return k, err
+	}
+	atomic.AddUint64(&c.misses, 1)
+
+	k, err = c.ks.APIKeyByUserIDVal(userID, key)
+	switch {
+	case err == nil:
+		c.set(gen, ck, k, nil, c.ttl)
+	case c.ks.IsNotFoundError(err):
+		c.set(gen, ck, nil, errors.NewNotFound("API key not found"), c.negTTL)
+	}
+	return k, err
+}
+
+// InsertAPIKey inserts the key through the KeyStore and invalidates the
+// user's cached entries e.g. a cached not found result for the new key.
+func (c *Cache) InsertAPIKey(userID string, key []byte) (apiG.Key, error) {
+	k, err := c.ks.InsertAPIKey(userID, key)
+	c.Invalidate(userID)
+	return k, err
+}
+
+// DeleteAPIKey deletes the key through the KeyStore and invalidates the
+// user's cached entries.
+func (c *Cache) DeleteAPIKey(ctx context.Context, userID, keyID string) error {
+	err := c.ks.DeleteAPIKey(ctx, userID, keyID)
+	c.Invalidate(userID)
+	return err
+}
+
+// APIKeysByUserID is passed on to the KeyStore uncached.
+func (c *Cache) APIKeysByUserID(ctx context.Context, userID, cursor string, limit int) ([]api.Key, string, error) {
+	return c.ks.APIKeysByUserID(ctx, userID, cursor, limit)
+}
+
+// Invalidate removes all cached entries belonging to userID.
+func (c *Cache) Invalidate(userID string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.gen++
+	for ck := range c.byUser[userID] {
+		c.remove(c.entries[ck])
+	}
+}
+
+// Stats returns the Cache's counters.
+func (c *Cache) Stats() Stats {
+	c.mu.Lock()
+	numEntries := c.lru.Len()
+	c.mu.Unlock()
+	return Stats{
+		Hits:         atomic.LoadUint64(&c.hits),
+		NegativeHits: atomic.LoadUint64(&c.negHits),
+		Misses:       atomic.LoadUint64(&c.misses),
+		Evictions:    atomic.LoadUint64(&c.evictions),
+		Entries:      numEntries,
+	}
+}
+
+// get returns the cached result for ck if any (ok is true) along with the
+// current generation.
+func (c *Cache) get(ck cacheKey) (k apiG.Key, err error, gen uint64, ok bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	el, ok := c.entries[ck]
+	if !ok {
+		return nil, nil, c.gen, false
+	}
+	e := el.Value.(*entry)
+	if !c.now().Before(e.expires) {
+		c.remove(el)
+		return nil, nil, c.gen, false
+	}
+	c.lru.MoveToFront(el)
+	if e.err != nil {
+		atomic.AddUint64(&c.negHits, 1)
+	} else {
+		atomic.AddUint64(&c.hits, 1)
+	}
+	return e.apiKey, e.err, c.gen, true
+}
+
+// set caches the result for ck unless an invalidation happened since
+// generation gen.
+func (c *Cache) set(gen uint64, ck cacheKey, k apiG.Key, err error, ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if gen != c.gen {
+		return
+	}
+	if el, ok := c.entries[ck]; ok {
+		c.remove(el)
+	}
+	for c.lru.Len() >= c.size {
+		c.remove(c.lru.Back())
+		atomic.AddUint64(&c.evictions, 1)
+	}
+	c.entries[ck] = c.lru.PushFront(&entry{key: ck, apiKey: k, err: err,
+		expires: c.now().Add(ttl)})
+	if c.byUser[ck.userID] == nil {
+		c.byUser[ck.userID] = make(map[cacheKey]bool)
+	}
+	c.byUser[ck.userID][ck] = true
+}
+
+// remove must be called with c.mu held.
+func (c *Cache) remove(el *list.Element) {
+	e := c.lru.Remove(el).(*entry)
+	delete(c.entries, e.key)
+	delete(c.byUser[e.key.userID], e.key)
+	if len(c.byUser[e.key.userID]) == 0 {
+		delete(c.byUser, e.key.userID)
+	}
+}
@@ -0,0 +1,216 @@
+package keycache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	apiG "github.com/tomogoma/go-api-guard"
+	"github.com/tomogoma/go-typed-errors"
+	"github.com/tomogoma/seedms/pkg/api"
+)
+
+type keyStore struct {
+	errors.NotFoundErrCheck
+
+	keys    map[string]api.Key // by userID+key
+	lookups int
+	err     error
+}
+
+func newKeyStore(ks ...api.Key) *keyStore {
+	s := &keyStore{keys: make(map[string]api.Key)}
+	for _, k := range ks {
+		s.keys[k.UserID+string(k.Val)] = k
+	}
+	return s
+}
+
+func (s *keyStore) InsertAPIKey(userID string, key []byte) (apiG.Key, error) {
+	k := api.Key{ID: "new", UserID: userID, Val: key}
+	s.keys[userID+string(key)] = k
+	return k, nil
+}
+
+func (s *keyStore) APIKeyByUserIDVal(userID string, key []byte) (apiG.Key, error) {
+	s.lookups++
+	if s.err != nil {
+		return nil, s.err
+	}
+	k, ok := s.keys[userID+string(key)]
+	if !ok {
+		return nil, errors.NewNotFound("API key not found")
+	}
+	return k, nil
+}
+
+func (s *keyStore) APIKeysByUserID(ctx context.Context, userID, cursor string, limit int) ([]api.Key, string, error) {
+	return nil, "", nil
+}
+
+func (s *keyStore) DeleteAPIKey(ctx context.Context, userID, keyID string) error {
+	for id, k := range s.keys {
+		if k.UserID == userID && k.ID == keyID {
+			delete(s.keys, id)
+			return nil
+		}
+	}
+	return errors.NewNotFound("API key not found")
+}
+
+func TestNewCache(t *testing.T) {
+	tt := []struct {
+		name   string
+		ks     KeyStore
+		opts   []Option
+		expErr bool
+	}{
+		{name: "valid", ks: newKeyStore()},
+		{name: "valid with options", ks: newKeyStore(),
+			opts: []Option{WithSize(5), WithTTL(time.Second), WithNegativeTTL(time.Second)}},
+		{name: "nil KeyStore", expErr: true},
+		{name: "zero size", ks: newKeyStore(), opts: []Option{WithSize(0)}, expErr: true},
+		{name: "zero TTL", ks: newKeyStore(), opts: []Option{WithTTL(0)}, expErr: true},
+		{name: "zero negative TTL", ks: newKeyStore(), opts: []Option{WithNegativeTTL(0)}, expErr: true},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			c, err := NewCache(tc.ks, tc.opts...)
+			if tc.expErr {
+				if err == nil {
+					t.Fatalf("Expected an error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Got error: %v", err)
+			}
+			if c == nil {
+				t.Fatalf("Got nil *Cache")
+			}
+		})
+	}
+}
+
+func TestCache_APIKeyByUserIDVal(t *testing.T) {
+	ks := newKeyStore(api.Key{ID: "1", UserID: "123", Val: []byte("key1")})
+	now := time.Now()
+	c := newCache(t, ks, &now, WithTTL(time.Minute), WithNegativeTTL(10*time.Second))
+
+	// miss then hit
+	assertLookup(t, c, "123", "key1", true)
+	assertLookup(t, c, "123", "key1", true)
+	// negative miss then negative hit
+	assertLookup(t, c, "123", "wrong", false)
+	assertLookup(t, c, "123", "wrong", false)
+	assertLookups(t, ks, 2)
+	assertStats(t, c, Stats{Hits: 1, NegativeHits: 1, Misses: 2, Entries: 2})
+
+	// negative entries expire before positive ones
+	now = now.Add(10 * time.Second)
+	assertLookup(t, c, "123", "key1", true)
+	assertLookup(t, c, "123", "wrong", false)
+	assertLookups(t, ks, 3)
+
+	now = now.Add(time.Minute)
+	assertLookup(t, c, "123", "key1", true)
+	assertLookups(t, ks, 4)
+}
+
+func TestCache_APIKeyByUserIDVal_errorsNotCached(t *testing.T) {
+	ks := newKeyStore()
+	ks.err = errors.New("db down")
+	now := time.Now()
+	c := newCache(t, ks, &now)
+	for i := 0; i < 2; i++ {
+		if _, err := c.APIKeyByUserIDVal("123", []byte("key1")); err != ks.err {
+			t.Fatalf("Expected KeyStore error, got %v", err)
+		}
+	}
+	assertLookups(t, ks, 2)
+}
+
+func TestCache_invalidation(t *testing.T) {
+	ks := newKeyStore(
+		api.Key{ID: "1", UserID: "123", Val: []byte("key1")},
+		api.Key{ID: "2", UserID: "456", Val: []byte("key2")},
+	)
+	now := time.Now()
+	c := newCache(t, ks, &now)
+
+	assertLookup(t, c, "123", "key1", true)
+	assertLookup(t, c, "456", "key2", true)
+	assertLookup(t, c, "123", "key3", false)
+
+	if err := c.DeleteAPIKey(context.Background(), "123", "1"); err != nil {
+		t.Fatalf("DeleteAPIKey(): %v", err)
+	}
+	assertLookup(t, c, "123", "key1", false)
+	// other users' entries are kept
+	assertLookup(t, c, "456", "key2", true)
+	assertLookups(t, ks, 4)
+
+	if _, err := c.InsertAPIKey("123", []byte("key3")); err != nil {
+		t.Fatalf("InsertAPIKey(): %v", err)
+	}
+	assertLookup(t, c, "123", "key3", true)
+	assertLookups(t, ks, 5)
+}
+
+func TestCache_eviction(t *testing.T) {
+	ks := newKeyStore(
+		api.Key{ID: "1", UserID: "1", Val: []byte("key")},
+		api.Key{ID: "2", UserID: "2", Val: []byte("key")},
+		api.Key{ID: "3", UserID: "3", Val: []byte("key")},
+	)
+	now := time.Now()
+	c := newCache(t, ks, &now, WithSize(2))
+
+	assertLookup(t, c, "1", "key", true)
+	assertLookup(t, c, "2", "key", true)
+	assertLookup(t, c, "1", "key", true) // 2 is now least recently used
+	assertLookup(t, c, "3", "key", true)
+	assertStats(t, c, Stats{Hits: 1, Misses: 3, Evictions: 1, Entries: 2})
+
+	assertLookup(t, c, "1", "key", true)
+	assertLookups(t, ks, 3)
+	assertLookup(t, c, "2", "key", true)
+	assertLookups(t, ks, 4)
+}
+
+func newCache(t *testing.T, ks KeyStore, now *time.Time, opts ...Option) *Cache {
+	c, err := NewCache(ks, opts...)
+	if err != nil {
+		t.Fatalf("Error setting up: new cache: %v", err)
+	}
+	c.now = func() time.Time { return *now }
+	return c
+}
+
+func assertLookup(t *testing.T, c *Cache, userID, key string, expFound bool) {
+	k, err := c.APIKeyByUserIDVal(userID, []byte(key))
+	if !expFound {
+		if !c.IsNotFoundError(err) {
+			t.Fatalf("%s/%s: expected a not found error, got %v", userID, key, err)
+		}
+		return
+	}
+	if err != nil {
+		t.Fatalf("%s/%s: got error: %v", userID, key, err)
+	}
+	if string(k.Value()) != key {
+		t.Errorf("%s/%s: got key value %s", userID, key, k.Value())
+	}
+}
+
+func assertLookups(t *testing.T, ks *keyStore, exp int) {
+	if ks.lookups != exp {
+		t.Errorf("Expected %d KeyStore lookups, got %d", exp, ks.lookups)
+	}
+}
+
+func assertStats(t *testing.T, c *Cache, exp Stats) {
+	if act := c.Stats(); act != exp {
+		t.Errorf("Expected stats %+v, got %+v", exp, act)
+	}
+}
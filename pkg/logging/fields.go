@@ -1,15 +1,19 @@
 package logging
 
 const (
-	FieldAction          = "action"
-	FieldTransID         = "transactionID"
-	FieldURL             = "url"
-	FieldHost            = "host"
-	FieldHTTPMethod      = "HTTPmethod"
-	FieldRPCMethod       = "RPCmethod"
-	FieldRequest         = "request"
-	FieldURLPath         = "URLPath"
-	FieldRequestHandler  = "requestType"
-	FieldClientAppUserID = "clientAppUserID"
-	FieldResponseCode    = "responseCode"
+	FieldAction            = "action"
+	FieldTransID           = "transactionID"
+	FieldURL               = "url"
+	FieldHost              = "host"
+	FieldHTTPMethod        = "HTTPmethod"
+	FieldRPCMethod         = "RPCmethod"
+	FieldRequest           = "request"
+	FieldURLPath           = "URLPath"
+	FieldRequestHandler    = "requestType"
+	FieldClientAppUserID   = "clientAppUserID"
+	FieldClientCertSubject = "clientCertSubject"
+	FieldUserID            = "userID"
+	FieldUserRoles         = "userRoles"
+	FieldResponseCode      = "responseCode"
+	FieldStack             = "stack"
 )
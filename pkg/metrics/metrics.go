@@ -0,0 +1,151 @@
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/tomogoma/seedms/pkg/config"
+	"github.com/tomogoma/seedms/pkg/keycache"
+)
+
+const (
+	labelRoute  = "route"
+	labelMethod = "method"
+	labelCode   = "code"
+	labelOp     = "op"
+	labelStatus = "status"
+
+	statusOK    = "ok"
+	statusError = "error"
+)
+
+// Metrics collects request, DB and Go runtime metrics and exposes them in
+// the Prometheus text format. Use New() to instantiate.
+type Metrics struct {
+	reg *prometheus.Registry
+
+	httpRequests *prometheus.CounterVec
+	httpDuration *prometheus.HistogramVec
+	rpcRequests  *prometheus.CounterVec
+	rpcDuration  *prometheus.HistogramVec
+	dbDuration   *prometheus.HistogramVec
+}
+
+// New creates Metrics with Go runtime and process collectors registered.
+// Metric names are prefixed with config.CanonicalName() (with characters
+// invalid in metric names replaced by "_").
+func New() *Metrics {
+	ns := namespace(config.CanonicalName())
+	m := &Metrics{
+		reg: prometheus.NewRegistry(),
+		httpRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: ns,
+			Name:      "http_requests_total",
+			Help:      "HTTP requests handled by route, method and status code.",
+		}, []string{labelRoute, labelMethod, labelCode}),
+		httpDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: ns,
+			Name:      "http_request_duration_seconds",
+			Help:      "HTTP request latencies by route, method and status code.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{labelRoute, labelMethod, labelCode}),
+		rpcRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: ns,
+			Name:      "rpc_requests_total",
+			Help:      "RPC requests handled by method and status code.",
+		}, []string{labelMethod, labelCode}),
+		rpcDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: ns,
+			Name:      "rpc_request_duration_seconds",
+			Help:      "RPC request latencies by method and status code.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{labelMethod, labelCode}),
+		dbDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: ns,
+			Name:      "db_query_duration_seconds",
+			Help:      "DB query latencies by operation and status (ok/error).",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{labelOp, labelStatus}),
+	}
+	m.reg.MustRegister(
+		prometheus.NewGoCollector(),
+		prometheus.NewProcessCollector(prometheus.ProcessCollectorOpts{}),
+		m.httpRequests, m.httpDuration,
+		m.rpcRequests, m.rpcDuration,
+		m.dbDuration,
+	)
+	return m
+}
+
+// Handler serves the collected metrics in the Prometheus text format.
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.reg, promhttp.HandlerOpts{})
+}
+
+// ObserveHTTP records an HTTP request to route (the mux path template).
+func (m *Metrics) ObserveHTTP(route, method string, code int, d time.Duration) {
+	codeStr := strconv.Itoa(code)
+	m.httpRequests.WithLabelValues(route, method, codeStr).Inc()
+	m.httpDuration.WithLabelValues(route, method, codeStr).Observe(d.Seconds())
+}
+
+// ObserveRPC records an RPC request to method e.g. "Status.Check".
+func (m *Metrics) ObserveRPC(method string, code int, d time.Duration) {
+	codeStr := strconv.Itoa(code)
+	m.rpcRequests.WithLabelValues(method, codeStr).Inc()
+	m.rpcDuration.WithLabelValues(method, codeStr).Observe(d.Seconds())
+}
+
+// ObserveDBQuery records a DB operation e.g. as a roach.QueryObserver.
+func (m *Metrics) ObserveDBQuery(op string, d time.Duration, err error) {
+	status := statusOK
+	if err != nil {
+		status = statusError
+	}
+	m.dbDuration.WithLabelValues(op, status).Observe(d.Seconds())
+}
+
+// RegisterKeyCache exposes kc's hit/miss counters and size.
+func (m *Metrics) RegisterKeyCache(kc *keycache.Cache) {
+	ns := namespace(config.CanonicalName())
+	counter := func(name, help string, val func(keycache.Stats) uint64) prometheus.Collector {
+		return prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Namespace: ns,
+			Subsystem: "api_key_cache",
+			Name:      name,
+			Help:      help,
+		}, func() float64 { return float64(val(kc.Stats())) })
+	}
+	m.reg.MustRegister(
+		counter("hits_total", "API key validations answered from cache with a valid key.",
+			func(s keycache.Stats) uint64 { return s.Hits }),
+		counter("negative_hits_total", "API key validations answered from cache with an invalid key.",
+			func(s keycache.Stats) uint64 { return s.NegativeHits }),
+		counter("misses_total", "API key validations passed on to the DB.",
+			func(s keycache.Stats) uint64 { return s.Misses }),
+		counter("evictions_total", "Cached API key validations evicted to make room for others.",
+			func(s keycache.Stats) uint64 { return s.Evictions }),
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace: ns,
+			Subsystem: "api_key_cache",
+			Name:      "entries",
+			Help:      "API key validations currently cached.",
+		}, func() float64 { return float64(kc.Stats().Entries) }),
+	)
+}
+
+// namespace replaces characters in name that are invalid in metric names.
+func namespace(name string) string {
+	ns := []byte(name)
+	for i, c := range ns {
+		isAlpha := c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+		isDigit := c >= '0' && c <= '9'
+		if !isAlpha && c != '_' && (!isDigit || i == 0) {
+			ns[i] = '_'
+		}
+	}
+	return string(ns)
+}
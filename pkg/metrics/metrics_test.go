@@ -0,0 +1,69 @@
+package metrics
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tomogoma/seedms/pkg/config"
+)
+
+func TestMetrics_Handler(t *testing.T) {
+	m := New()
+	m.ObserveHTTP("/users/{userID}/apikeys", http.MethodGet, http.StatusOK, time.Millisecond)
+	m.ObserveHTTP("/users/{userID}/apikeys", http.MethodGet, http.StatusOK, time.Millisecond)
+	m.ObserveRPC("Status.Check", http.StatusUnauthorized, time.Millisecond)
+	m.ObserveDBQuery("DeleteAPIKey", time.Millisecond, errors.New("db down"))
+
+	srvr := httptest.NewServer(m.Handler())
+	defer srvr.Close()
+	resp, err := http.Get(srvr.URL)
+	if err != nil {
+		t.Fatalf("Get metrics: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status code %d, got %s", http.StatusOK, resp.Status)
+	}
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("Read metrics: %v", err)
+	}
+
+	ns := namespace(config.CanonicalName())
+	expLines := []string{
+		ns + `_http_requests_total{code="200",method="GET",route="/users/{userID}/apikeys"} 2`,
+		ns + `_http_request_duration_seconds_count{code="200",method="GET",route="/users/{userID}/apikeys"} 2`,
+		ns + `_rpc_requests_total{code="401",method="Status.Check"} 1`,
+		ns + `_rpc_request_duration_seconds_count{code="401",method="Status.Check"} 1`,
+		ns + `_db_query_duration_seconds_count{op="DeleteAPIKey",status="error"} 1`,
+		"go_goroutines ",
+	}
+	for _, exp := range expLines {
+		if !strings.Contains(string(body), exp) {
+			t.Errorf("Expected metrics to contain '%s', got:\n%s", exp, body)
+		}
+	}
+}
+
+func TestNamespace(t *testing.T) {
+	tt := []struct {
+		name string
+		exp  string
+	}{
+		{name: "seedms", exp: "seedms"},
+		{name: "seed-ms.v1", exp: "seed_ms_v1"},
+		{name: "1seedms", exp: "_seedms"},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			if act := namespace(tc.name); act != tc.exp {
+				t.Errorf("Expected '%s', got '%s'", tc.exp, act)
+			}
+		})
+	}
+}
@@ -0,0 +1,103 @@
+package mocks
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// TLSFiles are the PEM files of a CA and of a server and a client
+// certificate it issued, for testing (mutual) TLS.
+type TLSFiles struct {
+	CAFile         string
+	ServerCertFile string
+	ServerKeyFile  string
+	ClientCertFile string
+	ClientKeyFile  string
+}
+
+// WriteTLSFiles writes TLSFiles into dir. The server certificate is valid
+// for localhost and 127.0.0.1 and the client certificate's subject is
+// "CN=<clientCN>,O=seedms".
+func WriteTLSFiles(t *testing.T, dir, clientCN string) TLSFiles {
+	caKey, caDER := newCert(t, &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test CA"},
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}, nil, nil)
+	ca, err := x509.ParseCertificate(caDER)
+	if err != nil {
+		t.Fatalf("Error setting up: parse CA certificate: %v", err)
+	}
+	srvKey, srvDER := newCert(t, &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "localhost"},
+		DNSNames:     []string{"localhost"},
+		IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1)},
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}, ca, caKey)
+	clKey, clDER := newCert(t, &x509.Certificate{
+		SerialNumber: big.NewInt(3),
+		Subject:      pkix.Name{CommonName: clientCN, Organization: []string{"seedms"}},
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}, ca, caKey)
+
+	files := TLSFiles{
+		CAFile:         filepath.Join(dir, "ca.pem"),
+		ServerCertFile: filepath.Join(dir, "server.pem"),
+		ServerKeyFile:  filepath.Join(dir, "server-key.pem"),
+		ClientCertFile: filepath.Join(dir, "client.pem"),
+		ClientKeyFile:  filepath.Join(dir, "client-key.pem"),
+	}
+	writePEM(t, files.CAFile, "CERTIFICATE", caDER)
+	writePEM(t, files.ServerCertFile, "CERTIFICATE", srvDER)
+	writePEM(t, files.ServerKeyFile, "EC PRIVATE KEY", marshalKey(t, srvKey))
+	writePEM(t, files.ClientCertFile, "CERTIFICATE", clDER)
+	writePEM(t, files.ClientKeyFile, "EC PRIVATE KEY", marshalKey(t, clKey))
+	return files
+}
+
+// newCert creates the certificate tmpl signed by parent, or self-signed
+// if parent is nil, returning its key and DER encoding.
+func newCert(t *testing.T, tmpl, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) (*ecdsa.PrivateKey, []byte) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Error setting up: generate key: %v", err)
+	}
+	tmpl.NotBefore = time.Now().Add(-time.Hour)
+	tmpl.NotAfter = time.Now().Add(time.Hour)
+	if parent == nil {
+		parent, parentKey = tmpl, key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, parent, &key.PublicKey, parentKey)
+	if err != nil {
+		t.Fatalf("Error setting up: create certificate: %v", err)
+	}
+	return key, der
+}
+
+func marshalKey(t *testing.T, key *ecdsa.PrivateKey) []byte {
+	der, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("Error setting up: marshal key: %v", err)
+	}
+	return der
+}
+
+func writePEM(t *testing.T, file, blockType string, der []byte) {
+	err := ioutil.WriteFile(file, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0600)
+	if err != nil {
+		t.Fatalf("Error setting up: write %s: %v", file, err)
+	}
+}
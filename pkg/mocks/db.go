@@ -1,6 +1,7 @@
 package mocks
 
 import (
+	"context"
 	"database/sql"
 
 	apiG "github.com/tomogoma/go-api-guard"
@@ -18,6 +19,10 @@ type DB struct {
 	ExpInsAPIKErr     error
 	ExpAPIKsBUsrID    *api.Key
 	ExpAPIKsBUsrIDErr error
+	ExpAPIKsPage      []api.Key
+	ExpAPIKsPageNext  string
+	ExpAPIKsPageErr   error
+	ExpDelAPIKErr     error
 
 	isInTx bool
 }
@@ -40,6 +45,23 @@ func (db *DB) APIKeyByUserIDVal(userID string, key []byte) (apiG.Key, error) {
 	return db.ExpAPIKsBUsrID, db.ExpAPIKsBUsrIDErr
 }
 
+func (db *DB) APIKeysByUserID(ctx context.Context, userID, cursor string, limit int) ([]api.Key, string, error) {
+	if db.isInTx {
+		return nil, "", errors.Newf("direct db call while in tx")
+	}
+	if db.ExpAPIKsPageErr != nil {
+		return nil, "", db.ExpAPIKsPageErr
+	}
+	return db.ExpAPIKsPage, db.ExpAPIKsPageNext, nil
+}
+
+func (db *DB) DeleteAPIKey(ctx context.Context, userID, keyID string) error {
+	if db.isInTx {
+		return errors.Newf("direct db call while in tx")
+	}
+	return db.ExpDelAPIKErr
+}
+
 func (db *DB) InsertAPIKey(userID string, key []byte) (apiG.Key, error) {
 	if db.isInTx {
 		return nil, errors.Newf("direct db call while in tx")
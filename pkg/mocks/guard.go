@@ -12,9 +12,11 @@ type Guard struct {
 	ExpAPIKValidErr   error
 	ExpNewAPIK        *api.Key
 	ExpNewAPIKErr     error
+	ValidatedAPIKey   []byte
 }
 
 func (g *Guard) APIKeyValid(key []byte) (string, error) {
+	g.ValidatedAPIKey = key
 	return g.ExpAPIKValidUsrID, g.ExpAPIKValidErr
 }
 func (g *Guard) NewAPIKey(userID string) (*api.Key, error) {
@@ -0,0 +1,15 @@
+package mocks
+
+import (
+	"context"
+
+	"github.com/tomogoma/seedms/pkg/health"
+)
+
+type HealthChecker struct {
+	ExpReport health.Report
+}
+
+func (hc *HealthChecker) Ready(ctx context.Context) health.Report {
+	return hc.ExpReport
+}
@@ -0,0 +1,27 @@
+package mocks
+
+import (
+	"github.com/dgrijalva/jwt-go"
+	"github.com/tomogoma/go-typed-errors"
+	"github.com/tomogoma/seedms/pkg/api"
+)
+
+type JWTEr struct {
+	ExpValidateClaims *api.Claims
+	ExpValidateErr    error
+	ValidatedToken    string
+}
+
+func (j *JWTEr) Validate(token string, claims jwt.Claims) (*jwt.Token, error) {
+	j.ValidatedToken = token
+	if j.ExpValidateErr != nil {
+		return nil, j.ExpValidateErr
+	}
+	if j.ExpValidateClaims == nil {
+		return nil, errors.NewUnauthorized("invalid token")
+	}
+	if c, ok := claims.(*api.Claims); ok {
+		*c = *j.ExpValidateClaims
+	}
+	return &jwt.Token{Raw: token, Claims: claims, Valid: true}, nil
+}
@@ -0,0 +1,32 @@
+package mocks
+
+import (
+	"net/http"
+	"time"
+)
+
+type Observation struct {
+	Route  string
+	Method string
+	Code   int
+}
+
+type Metrics struct {
+	ExpBody string
+	HTTP    []Observation
+	RPC     []Observation
+}
+
+func (m *Metrics) ObserveHTTP(route, method string, code int, d time.Duration) {
+	m.HTTP = append(m.HTTP, Observation{Route: route, Method: method, Code: code})
+}
+
+func (m *Metrics) ObserveRPC(method string, code int, d time.Duration) {
+	m.RPC = append(m.RPC, Observation{Method: method, Code: code})
+}
+
+func (m *Metrics) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(m.ExpBody))
+	})
+}
@@ -0,0 +1,23 @@
+package mocks
+
+import "time"
+
+type RateLimiter struct {
+	ExpDeny       bool
+	ExpRetryAfter time.Duration
+	ExpAllowErr   error
+	Routes        []string
+	ClientKeys    []string
+}
+
+func (rl *RateLimiter) Allow(route, clientKey string) (bool, time.Duration, error) {
+	rl.Routes = append(rl.Routes, route)
+	rl.ClientKeys = append(rl.ClientKeys, clientKey)
+	if rl.ExpAllowErr != nil {
+		return false, 0, rl.ExpAllowErr
+	}
+	if rl.ExpDeny {
+		return false, rl.ExpRetryAfter, nil
+	}
+	return true, 0, nil
+}
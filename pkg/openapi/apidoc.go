@@ -0,0 +1,289 @@
+package openapi
+
+import (
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/tomogoma/go-typed-errors"
+	"github.com/tomogoma/seedms/pkg/apidoc"
+)
+
+var pathParamRegex = regexp.MustCompile(`:(\w+)`)
+
+// AddAPIDoc adds the routes documented by the apidoc comment blocks in src
+// (e.g. the contents of a Go file) to d. See apidoc.Parse.
+func (d *Document) AddAPIDoc(src []byte) error {
+	eps, err := apidoc.Parse(src)
+	if err != nil {
+		return err
+	}
+	for _, ep := range eps {
+		if err := d.AddEndpoint(ep); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// AddEndpoint adds the operation documented by ep to d, replacing any
+// operation with the same method and path.
+func (d *Document) AddEndpoint(ep apidoc.Endpoint) error {
+	if err := d.addEndpoint(ep); err != nil {
+		return errors.Newf("%s %s: %v", ep.Method, ep.Path, err)
+	}
+	return nil
+}
+
+func (d *Document) addEndpoint(ep apidoc.Endpoint) error {
+	path := ep.Path
+	if i := strings.Index(path, "?"); i >= 0 {
+		path = path[:i]
+	}
+	pathParams := make(map[string]bool)
+	for _, m := range pathParamRegex.FindAllStringSubmatch(path, -1) {
+		pathParams[m[1]] = true
+	}
+	path = pathParamRegex.ReplaceAllString(path, "{$1}")
+
+	op := &Operation{
+		OperationID: ep.Name,
+		Summary:     ep.Title,
+		Description: ep.Description,
+		Responses: map[string]*Response{responseDefault: {
+			Description: "Error",
+			Content:     map[string]MediaType{mediaTypeJSON: {Schema: &Schema{Ref: refSchemaError}}},
+		}},
+	}
+	if ep.Group != "" {
+		op.Tags = []string{ep.Group}
+		d.addTag(ep.Group)
+	}
+
+	for _, h := range ep.Headers {
+		// OpenAPI ignores Authorization header parameters in favour of
+		// security schemes.
+		if strings.EqualFold(h.Name, headerAuthorization) {
+			op.Security = []map[string][]string{{SecurityBearer: {}}}
+			continue
+		}
+		op.Parameters = append(op.Parameters, Parameter{Name: h.Name, In: "header",
+			Description: h.Description, Required: !h.Optional, Schema: &Schema{Type: "string"}})
+	}
+
+	var body []apidoc.Field
+	for _, p := range ep.Params {
+		s, err := fieldSchema(p)
+		if err != nil {
+			return err
+		}
+		switch {
+		case pathParams[p.Name]:
+			s.Description = ""
+			op.Parameters = append(op.Parameters, Parameter{Name: p.Name, In: "path",
+				Description: p.Description, Required: true, Schema: s})
+		case ep.Method == "get" || ep.Method == "delete" || ep.Method == "head":
+			s.Description = ""
+			op.Parameters = append(op.Parameters, Parameter{Name: p.Name, In: "query",
+				Description: p.Description, Required: !p.Optional, Schema: s})
+		default:
+			body = append(body, p)
+		}
+	}
+	if len(body) > 0 {
+		s, err := objectSchema(body)
+		if err != nil {
+			return err
+		}
+		op.RequestBody = &RequestBody{Required: true,
+			Content: map[string]MediaType{mediaTypeJSON: {Schema: s}}}
+	}
+
+	for _, fields := range [][]apidoc.Field{ep.Success, ep.Errors} {
+		if err := addResponses(op, fields); err != nil {
+			return err
+		}
+	}
+
+	if d.Paths[path] == nil {
+		d.Paths[path] = make(PathItem)
+	}
+	d.Paths[path][ep.Method] = op
+	return nil
+}
+
+// addResponses adds a response to op for each (status code) group of
+// fields. Fields of type html, text or null describe the whole body.
+func addResponses(op *Operation, fields []apidoc.Field) error {
+	var codes []string
+	byCode := make(map[string][]apidoc.Field)
+	for _, f := range fields {
+		code := f.Group
+		if code == "" {
+			code = "200"
+		}
+		if _, ok := byCode[code]; !ok {
+			codes = append(codes, code)
+		}
+		byCode[code] = append(byCode[code], f)
+	}
+	for _, code := range codes {
+		c, err := strconv.Atoi(code)
+		if err != nil {
+			return errors.Newf("invalid status code group '%s'", code)
+		}
+		resp := &Response{Description: http.StatusText(c)}
+		fs := byCode[code]
+		switch strings.ToLower(fs[0].Type) {
+		case "null":
+		case "html":
+			resp.Content = map[string]MediaType{mediaTypeHTML: {Schema: &Schema{
+				Type: "string", Description: fs[0].Description}}}
+		case "text":
+			resp.Content = map[string]MediaType{mediaTypeText: {Schema: &Schema{
+				Type: "string", Description: fs[0].Description}}}
+		default:
+			s, err := objectSchema(fs)
+			if err != nil {
+				return err
+			}
+			resp.Content = map[string]MediaType{mediaTypeJSON: {Schema: s}}
+		}
+		op.Responses[code] = resp
+	}
+	return nil
+}
+
+// objectSchema returns the schema of a JSON object with fields. Fields
+// named parent.child are properties of the object (or object array)
+// parent.
+func objectSchema(fields []apidoc.Field) (*Schema, error) {
+	root := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+	for _, f := range fields {
+		s, err := fieldSchema(f)
+		if err != nil {
+			return nil, err
+		}
+		parent, name := root, f.Name
+		if i := strings.LastIndex(f.Name, "."); i >= 0 {
+			parent, name = lookupObject(root, f.Name[:i]), f.Name[i+1:]
+			if parent == nil {
+				return nil, errors.Newf("field '%s' has no parent object field", f.Name)
+			}
+		}
+		if parent.Properties == nil {
+			parent.Properties = make(map[string]*Schema)
+		}
+		parent.Properties[name] = s
+		if !f.Optional {
+			parent.Required = append(parent.Required, name)
+		}
+	}
+	return root, nil
+}
+
+// lookupObject returns the object schema at the dot separated path from
+// root, or the items schema if it is an array of objects.
+func lookupObject(root *Schema, path string) *Schema {
+	s := root
+	for _, name := range strings.Split(path, ".") {
+		s = s.Properties[name]
+		if s == nil {
+			return nil
+		}
+		if s.Type == "array" {
+			s = s.Items
+		}
+		if s == nil || s.Type != "object" {
+			return nil
+		}
+	}
+	return s
+}
+
+// fieldSchema converts an apidoc field type e.g. String, Number{1-100},
+// Object[] into a schema.
+func fieldSchema(f apidoc.Field) (*Schema, error) {
+	typ, size := f.Type, ""
+	if i := strings.Index(typ, "{"); i >= 0 {
+		typ, size = typ[:i], strings.TrimSuffix(typ[i+1:], "}")
+	}
+	isArray := strings.HasSuffix(typ, "[]")
+	typ = strings.TrimSuffix(typ, "[]")
+
+	s := &Schema{}
+	switch strings.ToLower(typ) {
+	case "string", "":
+		s.Type = "string"
+	case "number":
+		s.Type = "number"
+	case "integer":
+		s.Type = "integer"
+	case "boolean":
+		s.Type = "boolean"
+	case "object":
+		s.Type = "object"
+	default:
+		return nil, errors.Newf("unsupported type '%s' of field '%s'", f.Type, f.Name)
+	}
+	if size != "" {
+		if err := setSize(s, size); err != nil {
+			return nil, errors.Newf("field '%s': %v", f.Name, err)
+		}
+	}
+	if f.Default != "" {
+		s.Default = f.Default
+		if s.Type == "number" || s.Type == "integer" {
+			if n, err := strconv.ParseFloat(f.Default, 64); err == nil {
+				s.Default = n
+			}
+		}
+	}
+	if isArray {
+		s = &Schema{Type: "array", Items: s}
+	}
+	s.Description = f.Description
+	return s, nil
+}
+
+// setSize sets the range (numbers) or length range (strings) in size
+// e.g. "1-100", "-100", "1-".
+func setSize(s *Schema, size string) error {
+	i := strings.Index(size, "-")
+	if i < 0 {
+		return errors.Newf("malformed size '%s'", size)
+	}
+	minStr, maxStr := strings.TrimSpace(size[:i]), strings.TrimSpace(size[i+1:])
+	parse := func(v string) (*float64, error) {
+		if v == "" {
+			return nil, nil
+		}
+		n, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return nil, errors.Newf("malformed size '%s'", size)
+		}
+		return &n, nil
+	}
+	min, err := parse(minStr)
+	if err != nil {
+		return err
+	}
+	max, err := parse(maxStr)
+	if err != nil {
+		return err
+	}
+	if s.Type == "string" {
+		if min != nil {
+			n := int(*min)
+			s.MinLength = &n
+		}
+		if max != nil {
+			n := int(*max)
+			s.MaxLength = &n
+		}
+		return nil
+	}
+	s.Minimum, s.Maximum = min, max
+	return nil
+}
@@ -0,0 +1,173 @@
+package openapi_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/tomogoma/seedms/pkg/openapi"
+)
+
+const src = `package http
+
+// handleX is not documented.
+func handleX() {}
+
+/**
+ * @api {get} /users/:userID/items?cursor=:cursor List Items
+ * @apiName ListItems
+ * @apiVersion 0.1.0
+ * @apiGroup Items
+ * @apiDescription Pages through the items
+ * of a user.
+ *
+ * @apiHeader x-api-key the api key
+ * @apiHeader Authorization Bearer token of the user
+ *
+ * @apiParam {String} userID ID of the user.
+ * @apiParam {Number{1-100}} [limit=20] Maximum number of items.
+ *
+ * @apiSuccess (200) {Object[]} items Items in this page.
+ * @apiSuccess (200) {String} items.ID ID of the item.
+ * @apiSuccess (200) {String} [nextCursor] Cursor for the next page.
+ *
+ */
+func handleListItems() {}
+
+/**
+ * @api {post} /items Create Item
+ * @apiName CreateItem
+ * @apiGroup Items
+ *
+ * @apiParam {String{1-20}} name Name of the item.
+ *
+ * @apiSuccess (201) {null} body Empty.
+ * @apiError (409) {String} reason Why.
+ */
+func handleCreateItem() {}
+`
+
+func TestDocument_AddAPIDoc(t *testing.T) {
+	doc := openapi.New(openapi.Info{Title: "seedms", Version: "0.1.0"}, "/v0/seedms")
+	if err := doc.AddAPIDoc([]byte(src)); err != nil {
+		t.Fatalf("Got error: %v", err)
+	}
+
+	if len(doc.Paths) != 2 {
+		t.Fatalf("Expected 2 paths, got %d: %+v", len(doc.Paths), doc.Paths)
+	}
+	if exp := []openapi.Tag{{Name: "Items"}}; !reflect.DeepEqual(doc.Tags, exp) {
+		t.Errorf("Expected tags %+v, got %+v", exp, doc.Tags)
+	}
+
+	list := doc.Paths["/users/{userID}/items"]["get"]
+	if list == nil {
+		t.Fatalf("Expected GET /users/{userID}/items, got %+v", doc.Paths)
+	}
+	if list.OperationID != "ListItems" || list.Summary != "List Items" ||
+		list.Description != "Pages through the items of a user." {
+		t.Errorf("Unexpected operation details: %+v", list)
+	}
+	if exp := []map[string][]string{{openapi.SecurityBearer: {}}}; !reflect.DeepEqual(list.Security, exp) {
+		t.Errorf("Expected security %+v, got %+v", exp, list.Security)
+	}
+	min, max := 1.0, 100.0
+	expParams := []openapi.Parameter{
+		{Name: "x-api-key", In: "header", Description: "the api key", Required: true,
+			Schema: &openapi.Schema{Type: "string"}},
+		{Name: "userID", In: "path", Description: "ID of the user.", Required: true,
+			Schema: &openapi.Schema{Type: "string"}},
+		{Name: "limit", In: "query", Description: "Maximum number of items.",
+			Schema: &openapi.Schema{Type: "number", Minimum: &min, Maximum: &max, Default: 20.0}},
+	}
+	if !reflect.DeepEqual(list.Parameters, expParams) {
+		actB, _ := json.Marshal(list.Parameters)
+		t.Errorf("Unexpected parameters: %s", actB)
+	}
+	expSchema := &openapi.Schema{
+		Type:     "object",
+		Required: []string{"items"},
+		Properties: map[string]*openapi.Schema{
+			"items": {Type: "array", Description: "Items in this page.", Items: &openapi.Schema{
+				Type:       "object",
+				Required:   []string{"ID"},
+				Properties: map[string]*openapi.Schema{"ID": {Type: "string", Description: "ID of the item."}},
+			}},
+			"nextCursor": {Type: "string", Description: "Cursor for the next page."},
+		},
+	}
+	if resp := list.Responses["200"]; resp == nil || !reflect.DeepEqual(resp.Content["application/json"].Schema, expSchema) {
+		actB, _ := json.Marshal(list.Responses)
+		t.Errorf("Unexpected 200 response: %s", actB)
+	}
+	if resp := list.Responses["default"]; resp == nil || resp.Content["application/json"].Schema.Ref != "#/components/schemas/Error" {
+		t.Errorf("Expected default error response, got %+v", resp)
+	}
+
+	create := doc.Paths["/items"]["post"]
+	if create == nil {
+		t.Fatalf("Expected POST /items, got %+v", doc.Paths)
+	}
+	minLen, maxLen := 1, 20
+	expBody := &openapi.Schema{
+		Type:     "object",
+		Required: []string{"name"},
+		Properties: map[string]*openapi.Schema{
+			"name": {Type: "string", Description: "Name of the item.", MinLength: &minLen, MaxLength: &maxLen},
+		},
+	}
+	if create.RequestBody == nil || !reflect.DeepEqual(create.RequestBody.Content["application/json"].Schema, expBody) {
+		actB, _ := json.Marshal(create.RequestBody)
+		t.Errorf("Unexpected request body: %s", actB)
+	}
+	if resp := create.Responses["201"]; resp == nil || resp.Content != nil {
+		t.Errorf("Expected a 201 response without content, got %+v", resp)
+	}
+	if resp := create.Responses["409"]; resp == nil || resp.Description != "Conflict" {
+		t.Errorf("Expected a 409 Conflict response, got %+v", resp)
+	}
+}
+
+func TestDocument_AddAPIDoc_errors(t *testing.T) {
+	tt := []struct {
+		name string
+		src  string
+	}{
+		{name: "malformed @api", src: "/**\n * @api get /status\n */"},
+		{name: "unsupported type", src: "/**\n * @api {get} /status Status\n * @apiSuccess {Date} d\n */"},
+		{name: "orphan nested field", src: "/**\n * @api {get} /status Status\n * @apiSuccess {String} a.b\n */"},
+		{name: "malformed size", src: "/**\n * @api {get} /status Status\n * @apiParam {Number{one}} n\n */"},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			doc := openapi.New(openapi.Info{}, "/")
+			if err := doc.AddAPIDoc([]byte(tc.src)); err == nil {
+				t.Errorf("Expected an error, got nil")
+			}
+		})
+	}
+}
+
+// TestDocument_AddAPIDoc_httpHandler ensures the HTTP handler's docs
+// convert to OpenAPI.
+func TestDocument_AddAPIDoc_httpHandler(t *testing.T) {
+	srcFiles, err := filepath.Glob(filepath.Join("..", "handler", "http", "*.go"))
+	if err != nil || len(srcFiles) == 0 {
+		t.Fatalf("Error setting up: list http handler files: %v", err)
+	}
+	doc := openapi.New(openapi.Info{}, "/")
+	for _, srcFile := range srcFiles {
+		src, err := ioutil.ReadFile(srcFile)
+		if err != nil {
+			t.Fatalf("Error setting up: read %s: %v", srcFile, err)
+		}
+		if err := doc.AddAPIDoc(src); err != nil {
+			t.Fatalf("%s: %v", srcFile, err)
+		}
+	}
+	if doc.Paths["/status"]["get"] == nil {
+		t.Errorf("Expected GET /status, got %+v", doc.Paths)
+	}
+}
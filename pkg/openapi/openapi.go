@@ -0,0 +1,168 @@
+// Package openapi builds OpenAPI 3 documents of the micro-service's HTTP
+// API from the apidoc (http://apidocjs.com) comment blocks documenting its
+// routes, so that client SDKs and contract tests need no Node toolchain.
+package openapi
+
+// Version is the OpenAPI specification version of generated documents.
+const Version = "3.0.3"
+
+// Names of the components every Document has.
+const (
+	SchemaError    = "Error"
+	SecurityBearer = "bearerAuth"
+)
+
+const (
+	refSchemaError      = "#/components/schemas/" + SchemaError
+	mediaTypeJSON       = "application/json"
+	mediaTypeHTML       = "text/html"
+	mediaTypeText       = "text/plain"
+	responseDefault     = "default"
+	headerAuthorization = "Authorization"
+)
+
+type Document struct {
+	OpenAPI    string              `json:"openapi"`
+	Info       Info                `json:"info"`
+	Servers    []Server            `json:"servers,omitempty"`
+	Paths      map[string]PathItem `json:"paths"`
+	Components Components          `json:"components"`
+	Tags       []Tag               `json:"tags,omitempty"`
+	tagIndex   map[string]struct{}
+}
+
+type Info struct {
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	Version     string `json:"version"`
+}
+
+type Server struct {
+	URL string `json:"url"`
+}
+
+type Tag struct {
+	Name string `json:"name"`
+}
+
+// PathItem maps lower case HTTP methods to the operations on a path.
+type PathItem map[string]*Operation
+
+type Operation struct {
+	OperationID string                `json:"operationId,omitempty"`
+	Summary     string                `json:"summary,omitempty"`
+	Description string                `json:"description,omitempty"`
+	Tags        []string              `json:"tags,omitempty"`
+	Parameters  []Parameter           `json:"parameters,omitempty"`
+	RequestBody *RequestBody          `json:"requestBody,omitempty"`
+	Responses   map[string]*Response  `json:"responses"`
+	Security    []map[string][]string `json:"security,omitempty"`
+}
+
+type Parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+type RequestBody struct {
+	Required bool                 `json:"required,omitempty"`
+	Content  map[string]MediaType `json:"content"`
+}
+
+type Response struct {
+	Description string               `json:"description"`
+	Content     map[string]MediaType `json:"content,omitempty"`
+}
+
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+type Schema struct {
+	Ref         string             `json:"$ref,omitempty"`
+	Type        string             `json:"type,omitempty"`
+	Description string             `json:"description,omitempty"`
+	Properties  map[string]*Schema `json:"properties,omitempty"`
+	Required    []string           `json:"required,omitempty"`
+	Items       *Schema            `json:"items,omitempty"`
+	Minimum     *float64           `json:"minimum,omitempty"`
+	Maximum     *float64           `json:"maximum,omitempty"`
+	MinLength   *int               `json:"minLength,omitempty"`
+	MaxLength   *int               `json:"maxLength,omitempty"`
+	Default     interface{}        `json:"default,omitempty"`
+}
+
+type Components struct {
+	Schemas         map[string]*Schema         `json:"schemas"`
+	SecuritySchemes map[string]*SecurityScheme `json:"securitySchemes"`
+}
+
+type SecurityScheme struct {
+	Type         string `json:"type"`
+	Scheme       string `json:"scheme,omitempty"`
+	BearerFormat string `json:"bearerFormat,omitempty"`
+	Description  string `json:"description,omitempty"`
+}
+
+// New creates a Document without paths, to be added using AddAPIDoc().
+// Paths are relative to serverURL. The Document has the error envelope
+// schema (SchemaError) returned by all routes on error and the bearer
+// token security scheme (SecurityBearer).
+func New(info Info, serverURL string) *Document {
+	return &Document{
+		OpenAPI: Version,
+		Info:    info,
+		Servers: []Server{{URL: serverURL}},
+		Paths:   make(map[string]PathItem),
+		Components: Components{
+			Schemas: map[string]*Schema{SchemaError: errorSchema()},
+			SecuritySchemes: map[string]*SecurityScheme{
+				SecurityBearer: {Type: "http", Scheme: "bearer", BearerFormat: "JWT"},
+			},
+		},
+		tagIndex: make(map[string]struct{}),
+	}
+}
+
+// errorSchema describes the JSON error envelope of the HTTP handler.
+func errorSchema() *Schema {
+	str := func(desc string) *Schema { return &Schema{Type: "string", Description: desc} }
+	return &Schema{
+		Type:     "object",
+		Required: []string{"error"},
+		Properties: map[string]*Schema{
+			"error": {
+				Type:     "object",
+				Required: []string{"code", "message", "requestID"},
+				Properties: map[string]*Schema{
+					"code":      str("Machine readable error code e.g. badRequest, notFound."),
+					"message":   str("Human readable description of the error."),
+					"requestID": str("ID of the request as in the X-Request-ID response header."),
+					"fields": {
+						Type:        "array",
+						Description: "Invalid request fields, if any.",
+						Items: &Schema{
+							Type:     "object",
+							Required: []string{"field", "message"},
+							Properties: map[string]*Schema{
+								"field":   str("JSON name (path) of the field."),
+								"message": str("Why the field is invalid."),
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func (d *Document) addTag(name string) {
+	if _, ok := d.tagIndex[name]; ok || name == "" {
+		return
+	}
+	d.tagIndex[name] = struct{}{}
+	d.Tags = append(d.Tags, Tag{Name: name})
+}
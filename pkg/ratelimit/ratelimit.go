@@ -0,0 +1,74 @@
+package ratelimit
+
+import (
+	"math"
+	"time"
+
+	"github.com/tomogoma/go-typed-errors"
+)
+
+// DefaultRoute is the route whose Limit applies to routes without a Limit
+// of their own.
+const DefaultRoute = "default"
+
+// Limit describes a token bucket that holds up to Burst tokens and is
+// refilled at Rate tokens per second. Each request takes one token.
+type Limit struct {
+	Rate  float64
+	Burst int
+}
+
+// Store keeps token buckets. Implementations backed by a shared store
+// (e.g. Redis) allow limits to be enforced across service instances.
+type Store interface {
+	// Take takes a token from the bucket identified by key, creating a
+	// full bucket described by l if none exists. If the bucket is empty
+	// false is returned along with the wait until a token is available.
+	Take(key string, l Limit) (bool, time.Duration, error)
+}
+
+// Limiter applies per route Limits to requests from different clients.
+type Limiter struct {
+	store  Store
+	limits map[string]Limit
+}
+
+// PerDuration returns a Limit allowing n requests every d with bursts of
+// up to burst requests. burst defaults to n if less than 1.
+func PerDuration(n int, d time.Duration, burst int) Limit {
+	if burst < 1 {
+		burst = n
+	}
+	return Limit{Rate: float64(n) / d.Seconds(), Burst: burst}
+}
+
+// NewLimiter creates a Limiter that keeps its buckets in s and applies
+// limits, which are keyed by route name. The Limit keyed by DefaultRoute,
+// if any, applies to routes without a Limit of their own.
+func NewLimiter(s Store, limits map[string]Limit) (*Limiter, error) {
+	if s == nil {
+		return nil, errors.New("Store was nil")
+	}
+	for route, l := range limits {
+		if l.Rate <= 0 || math.IsInf(l.Rate, 0) || math.IsNaN(l.Rate) || l.Burst < 1 {
+			return nil, errors.Newf("invalid limit for route '%s': %+v", route, l)
+		}
+	}
+	return &Limiter{store: s, limits: limits}, nil
+}
+
+// Allow takes a token for the client identified by clientKey on route.
+// If the client has exhausted its tokens false is returned along with the
+// wait until the next request would be allowed. Routes without a
+// Limit (and no default Limit) are not limited.
+func (l *Limiter) Allow(route, clientKey string) (bool, time.Duration, error) {
+	lim, ok := l.limits[route]
+	if !ok {
+		if lim, ok = l.limits[DefaultRoute]; !ok {
+			return true, 0, nil
+		}
+		// Routes without own limits share the default bucket.
+		route = DefaultRoute
+	}
+	return l.store.Take(route+"|"+clientKey, lim)
+}
@@ -0,0 +1,109 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNewLimiter(t *testing.T) {
+	tt := []struct {
+		name   string
+		store  Store
+		limits map[string]Limit
+		expErr bool
+	}{
+		{name: "valid", store: NewMemoryStore(), limits: map[string]Limit{"a": {Rate: 1, Burst: 1}}},
+		{name: "no limits", store: NewMemoryStore()},
+		{name: "nil store", limits: map[string]Limit{"a": {Rate: 1, Burst: 1}}, expErr: true},
+		{name: "zero rate", store: NewMemoryStore(), limits: map[string]Limit{"a": {Burst: 1}}, expErr: true},
+		{name: "zero burst", store: NewMemoryStore(), limits: map[string]Limit{"a": {Rate: 1}}, expErr: true},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			l, err := NewLimiter(tc.store, tc.limits)
+			if tc.expErr {
+				if err == nil {
+					t.Fatalf("Expected an error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Got error: %v", err)
+			}
+			if l == nil {
+				t.Fatalf("Got nil *Limiter")
+			}
+		})
+	}
+}
+
+func TestLimiter_Allow(t *testing.T) {
+	now := time.Now()
+	s := NewMemoryStore()
+	s.now = func() time.Time { return now }
+	l, err := NewLimiter(s, map[string]Limit{
+		"status":     PerDuration(2, time.Minute, 0),
+		DefaultRoute: PerDuration(1, time.Second, 1),
+	})
+	if err != nil {
+		t.Fatalf("Error setting up: new limiter: %v", err)
+	}
+
+	assertAllow(t, l, "status", "client1", true)
+	assertAllow(t, l, "status", "client1", true)
+	ok, wait, _ := l.Allow("status", "client1")
+	if ok {
+		t.Fatalf("Expected third request within burst to be denied")
+	}
+	if wait <= 0 || wait > 30*time.Second {
+		t.Errorf("Expected a wait of up to 30s, got %v", wait)
+	}
+	// Clients and routes have separate buckets.
+	assertAllow(t, l, "status", "client2", true)
+	assertAllow(t, l, "docs", "client1", true)
+	// Routes without own limits share the default bucket.
+	assertAllow(t, l, "other", "client1", false)
+
+	now = now.Add(30 * time.Second)
+	assertAllow(t, l, "status", "client1", true)
+	assertAllow(t, l, "status", "client1", false)
+	assertAllow(t, l, "other", "client1", true)
+}
+
+func TestLimiter_Allow_noLimits(t *testing.T) {
+	l, err := NewLimiter(NewMemoryStore(), nil)
+	if err != nil {
+		t.Fatalf("Error setting up: new limiter: %v", err)
+	}
+	for i := 0; i < 10; i++ {
+		assertAllow(t, l, "status", "client1", true)
+	}
+}
+
+func TestMemoryStore_sweep(t *testing.T) {
+	now := time.Now()
+	s := NewMemoryStore()
+	s.now = func() time.Time { return now }
+	lim := Limit{Rate: 1, Burst: 1}
+	s.Take("idle", lim)
+	now = now.Add(time.Second)
+	for i := 0; i < sweepEvery-1; i++ {
+		s.Take("busy", lim)
+	}
+	if _, ok := s.buckets["idle"]; ok {
+		t.Errorf("Expected refilled bucket to be swept")
+	}
+	if _, ok := s.buckets["busy"]; !ok {
+		t.Errorf("Expected bucket in use to be kept")
+	}
+}
+
+func assertAllow(t *testing.T, l *Limiter, route, client string, exp bool) {
+	ok, _, err := l.Allow(route, client)
+	if err != nil {
+		t.Fatalf("Allow(%s, %s): %v", route, client, err)
+	}
+	if ok != exp {
+		t.Errorf("Allow(%s, %s): expected %t, got %t", route, client, exp, ok)
+	}
+}
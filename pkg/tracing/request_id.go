@@ -0,0 +1,41 @@
+package tracing
+
+import "context"
+
+// DefaultRequestIDHeader is the header (or RPC metadata key) callers send
+// their request ID in if none is configured.
+const DefaultRequestIDHeader = "X-Request-ID"
+
+// maxRequestIDLen caps the length of request IDs accepted from callers.
+const maxRequestIDLen = 128
+
+type requestIDKey struct{}
+
+// ContextWithRequestID returns a copy of ctx carrying the request ID id.
+func ContextWithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestID returns the request ID in ctx as set by ContextWithRequestID
+// or, if there is none, TransID(ctx).
+func RequestID(ctx context.Context) string {
+	if id, ok := ctx.Value(requestIDKey{}).(string); ok && id != "" {
+		return id
+	}
+	return TransID(ctx)
+}
+
+// ValidRequestID reports whether a request ID received from a caller is
+// fit for logging and echoing back: 1 to 128 printable, non-space ASCII
+// characters.
+func ValidRequestID(id string) bool {
+	if len(id) == 0 || len(id) > maxRequestIDLen {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] <= ' ' || id[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,124 @@
+package tracing
+
+import (
+	"context"
+	"io"
+	"os"
+
+	"github.com/pborman/uuid"
+	"github.com/tomogoma/go-typed-errors"
+	"github.com/tomogoma/seedms/pkg/config"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+// Span exporters.
+const (
+	// ExporterNone disables tracing.
+	ExporterNone = ""
+	// ExporterOTLP exports spans to an OTLP/HTTP collector.
+	ExporterOTLP = "otlp"
+	// ExporterStdout writes spans as JSON to stdout or a file.
+	ExporterStdout = "stdout"
+)
+
+// Propagator reads and writes W3C trace context (and baggage) headers
+// to continue traces across services.
+var Propagator propagation.TextMapPropagator = propagation.NewCompositeTextMapPropagator(
+	propagation.TraceContext{},
+	propagation.Baggage{},
+)
+
+// Provider is the trace.TracerProvider of the micro-service.
+// Use New() to instantiate.
+type Provider struct {
+	trace.TracerProvider
+	shutdown func(context.Context) error
+}
+
+// New creates a Provider which exports spans using the exporter in conf.
+// Tracing is a no-op if conf.Exporter is ExporterNone.
+func New(conf config.Tracing) (*Provider, error) {
+	var exp sdktrace.SpanExporter
+	var closer io.Closer
+	var err error
+	switch conf.Exporter {
+	case ExporterNone:
+		return &Provider{
+			TracerProvider: noop.NewTracerProvider(),
+			shutdown:       func(context.Context) error { return nil },
+		}, nil
+	case ExporterOTLP:
+		var opts []otlptracehttp.Option
+		if conf.Endpoint != "" {
+			opts = append(opts, otlptracehttp.WithEndpoint(conf.Endpoint))
+		}
+		if conf.Insecure {
+			opts = append(opts, otlptracehttp.WithInsecure())
+		}
+		exp, err = otlptracehttp.New(context.Background(), opts...)
+	case ExporterStdout:
+		w := io.Writer(os.Stdout)
+		if conf.File != "" {
+			f, err := os.OpenFile(conf.File, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+			if err != nil {
+				return nil, errors.Newf("open trace file: %v", err)
+			}
+			w, closer = f, f
+		}
+		exp, err = stdouttrace.New(stdouttrace.WithWriter(w))
+	default:
+		return nil, errors.Newf("unknown trace exporter '%s'", conf.Exporter)
+	}
+	if err != nil {
+		return nil, errors.Newf("create %s trace exporter: %v", conf.Exporter, err)
+	}
+
+	sampler := sdktrace.AlwaysSample()
+	if conf.SampleRatio != nil {
+		switch ratio := *conf.SampleRatio; {
+		case ratio <= 0:
+			sampler = sdktrace.NeverSample()
+		case ratio < 1:
+			sampler = sdktrace.TraceIDRatioBased(ratio)
+		}
+	}
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exp),
+		sdktrace.WithSampler(sdktrace.ParentBased(sampler)),
+		sdktrace.WithResource(resource.NewWithAttributes(semconv.SchemaURL,
+			semconv.ServiceName(config.CanonicalName()),
+			semconv.ServiceVersion(config.VersionFull),
+		)),
+	)
+	return &Provider{
+		TracerProvider: tp,
+		shutdown: func(ctx context.Context) error {
+			err := tp.Shutdown(ctx)
+			if closer != nil {
+				closer.Close()
+			}
+			return err
+		},
+	}, nil
+}
+
+// Shutdown exports any pending spans and stops the Provider.
+func (p *Provider) Shutdown(ctx context.Context) error {
+	return p.shutdown(ctx)
+}
+
+// TransID returns the ID of the trace in ctx for use as the transaction ID
+// in logs, or a random ID if ctx has no (valid) trace.
+func TransID(ctx context.Context) string {
+	if sc := trace.SpanContextFromContext(ctx); sc.HasTraceID() {
+		return sc.TraceID().String()
+	}
+	return uuid.New()
+}
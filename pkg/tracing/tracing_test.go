@@ -0,0 +1,155 @@
+package tracing_test
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/tomogoma/seedms/pkg/config"
+	"github.com/tomogoma/seedms/pkg/tracing"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestNew(t *testing.T) {
+	half := 0.5
+	tt := []struct {
+		name   string
+		conf   config.Tracing
+		expErr bool
+	}{
+		{name: "disabled", conf: config.Tracing{Exporter: tracing.ExporterNone}},
+		{name: "stdout", conf: config.Tracing{Exporter: tracing.ExporterStdout}},
+		{name: "otlp", conf: config.Tracing{Exporter: tracing.ExporterOTLP,
+			Endpoint: "localhost:4318", Insecure: true, SampleRatio: &half}},
+		{name: "unknown exporter", conf: config.Tracing{Exporter: "zipkin"}, expErr: true},
+		{name: "bad file", conf: config.Tracing{Exporter: tracing.ExporterStdout,
+			File: "/none/existent/dir/spans.json"}, expErr: true},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			tp, err := tracing.New(tc.conf)
+			if tc.expErr {
+				if err == nil {
+					t.Fatalf("Expected an error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Got error: %v", err)
+			}
+			if err := tp.Shutdown(context.Background()); err != nil {
+				t.Errorf("Shutdown(): %v", err)
+			}
+		})
+	}
+}
+
+func TestNew_sampleRatio(t *testing.T) {
+	zero, one := 0.0, 1.0
+	tt := []struct {
+		name       string
+		ratio      *float64
+		expSampled bool
+	}{
+		{name: "unset", ratio: nil, expSampled: true},
+		{name: "zero", ratio: &zero, expSampled: false},
+		{name: "one", ratio: &one, expSampled: true},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			tp, err := tracing.New(config.Tracing{Exporter: tracing.ExporterStdout,
+				File: os.DevNull, SampleRatio: tc.ratio})
+			if err != nil {
+				t.Fatalf("Got error: %v", err)
+			}
+			defer tp.Shutdown(context.Background())
+			_, span := tp.Tracer("test").Start(context.Background(), "test-span")
+			span.End()
+			if act := span.SpanContext().IsSampled(); act != tc.expSampled {
+				t.Errorf("Expected sampled %t, got %t", tc.expSampled, act)
+			}
+		})
+	}
+}
+
+func TestNew_fileExporter(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tracing")
+	if err != nil {
+		t.Fatalf("Error setting up: create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "spans.json")
+
+	tp, err := tracing.New(config.Tracing{Exporter: tracing.ExporterStdout, File: file})
+	if err != nil {
+		t.Fatalf("Got error: %v", err)
+	}
+	_, span := tp.Tracer("test").Start(context.Background(), "test-span")
+	span.End()
+	if err := tp.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown(): %v", err)
+	}
+
+	spans, err := ioutil.ReadFile(file)
+	if err != nil {
+		t.Fatalf("Read spans file: %v", err)
+	}
+	if !strings.Contains(string(spans), `"Name":"test-span"`) {
+		t.Errorf("Expected exported test-span, got:\n%s", spans)
+	}
+}
+
+func TestTransID(t *testing.T) {
+	traceID := trace.TraceID{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16}
+	ctx := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(
+		trace.SpanContextConfig{TraceID: traceID, SpanID: trace.SpanID{1}}))
+	if act := tracing.TransID(ctx); act != traceID.String() {
+		t.Errorf("Expected trace ID %s, got %s", traceID, act)
+	}
+
+	id1 := tracing.TransID(context.Background())
+	id2 := tracing.TransID(context.Background())
+	if id1 == "" || id1 == id2 {
+		t.Errorf("Expected distinct random IDs without a trace, got '%s' and '%s'", id1, id2)
+	}
+}
+
+func TestRequestID(t *testing.T) {
+	ctx := tracing.ContextWithRequestID(context.Background(), "req-123")
+	if act := tracing.RequestID(ctx); act != "req-123" {
+		t.Errorf("Expected request ID 'req-123', got '%s'", act)
+	}
+
+	traceID := trace.TraceID{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16}
+	ctx = trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(
+		trace.SpanContextConfig{TraceID: traceID, SpanID: trace.SpanID{1}}))
+	if act := tracing.RequestID(ctx); act != traceID.String() {
+		t.Errorf("Expected trace ID %s without a request ID, got %s", traceID, act)
+	}
+}
+
+func TestValidRequestID(t *testing.T) {
+	tt := []struct {
+		name string
+		id   string
+		exp  bool
+	}{
+		{name: "uuid", id: "f47ac10b-58cc-4372-a567-0e02b2c3d479", exp: true},
+		{name: "max length", id: strings.Repeat("a", 128), exp: true},
+		{name: "empty", id: "", exp: false},
+		{name: "too long", id: strings.Repeat("a", 129), exp: false},
+		{name: "space", id: "req 123", exp: false},
+		{name: "newline", id: "req123\nlevel=error", exp: false},
+		{name: "none ASCII", id: "réq123", exp: false},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			if act := tracing.ValidRequestID(tc.id); act != tc.exp {
+				t.Errorf("Expected %t, got %t", tc.exp, act)
+			}
+		})
+	}
+}
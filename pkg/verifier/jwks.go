@@ -0,0 +1,322 @@
+package verifier
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tomogoma/go-typed-errors"
+)
+
+const (
+	DefaultJWKSRefreshInterval = 1 * time.Hour
+	// minJWKSRefetchInterval limits how often the JWKS is re-fetched e.g.
+	// due to unknown kids or an unavailable source.
+	minJWKSRefetchInterval = 1 * time.Minute
+	maxJWKSBytes           = 1 << 20
+)
+
+// JWKS is a KeySource backed by a JSON Web Key Set (RFC 7517) document
+// read from a local file or an HTTP(S) URL. The key set is cached and
+// re-fetched after the refresh interval or when a token carries an unknown
+// key ID so that keys rotated by the issuer are picked up. Cached keys
+// remain available while a fetch is in progress.
+type JWKS struct {
+	src         string
+	alg         string
+	client      *http.Client
+	refreshIntv time.Duration
+	now         func() time.Time
+
+	mu          sync.Mutex
+	keys        map[string]interface{}
+	fetchedAt   time.Time
+	attemptedAt time.Time
+	// fetching is the fetch in progress if any, shared by concurrent
+	// callers of refresh.
+	fetching *jwksFetch
+}
+
+type jwksFetch struct {
+	done chan struct{}
+	err  error
+}
+
+type JWKSOption func(*JWKS)
+
+type jsonWebKeySet struct {
+	Keys []jsonWebKey `json:"keys"`
+}
+
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	// RSA
+	N string `json:"n"`
+	E string `json:"e"`
+	// EC
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// WithJWKSRefreshInterval sets how long a fetched key set is used before
+// being re-fetched. Defaults to DefaultJWKSRefreshInterval.
+func WithJWKSRefreshInterval(d time.Duration) JWKSOption {
+	return func(j *JWKS) {
+		j.refreshIntv = d
+	}
+}
+
+// WithJWKSAlgorithm skips keys in the key set whose "alg" is set to an
+// algorithm other than alg (e.g. AlgRS256).
+func WithJWKSAlgorithm(alg string) JWKSOption {
+	return func(j *JWKS) {
+		j.alg = alg
+	}
+}
+
+// WithHTTPClient sets the client used to fetch key sets from HTTP URLs.
+func WithHTTPClient(c *http.Client) JWKSOption {
+	return func(j *JWKS) {
+		j.client = c
+	}
+}
+
+// NewJWKS creates a JWKS key source reading from src, which is either an
+// http(s):// URL or a file path. The key set is fetched immediately so
+// that configuration errors surface at startup.
+func NewJWKS(src string, opts ...JWKSOption) (*JWKS, error) {
+	if src == "" {
+		return nil, errors.New("JWKS source was empty")
+	}
+	j := &JWKS{
+		src:         src,
+		client:      &http.Client{Timeout: 10 * time.Second},
+		refreshIntv: DefaultJWKSRefreshInterval,
+		now:         time.Now,
+	}
+	for _, f := range opts {
+		f(j)
+	}
+	if j.client == nil {
+		return nil, errors.New("HTTP client was nil")
+	}
+	if err := j.refresh(); err != nil {
+		return nil, err
+	}
+	return j, nil
+}
+
+// NewJWKSVerifier creates a Verifier for alg (RS256 or ES256) tokens
+// using keys from the JWKS at src. Keys for other algorithms are skipped.
+func NewJWKSVerifier(alg, src string, opts ...JWKSOption) (*Verifier, error) {
+	if alg != AlgRS256 && alg != AlgES256 {
+		return nil, errors.Newf("JWKS not supported for algorithm '%s'", alg)
+	}
+	ks, err := NewJWKS(src, append([]JWKSOption{WithJWKSAlgorithm(alg)}, opts...)...)
+	if err != nil {
+		return nil, err
+	}
+	return NewVerifier(alg, ks)
+}
+
+// Key returns the key identified by kid. If kid is empty the key set must
+// contain exactly one key.
+func (j *JWKS) Key(kid string) (interface{}, error) {
+	j.mu.Lock()
+	stale := j.now().Sub(j.fetchedAt) > j.refreshIntv && j.canRefetch()
+	j.mu.Unlock()
+	if stale {
+		// Keep using the stale keys if the source is unavailable.
+		if err := j.refresh(); err != nil && j.Ready() != nil {
+			return nil, err
+		}
+	}
+
+	j.mu.Lock()
+	key, err := j.lookup(kid)
+	refetch := err != nil && j.canRefetch()
+	j.mu.Unlock()
+	if !refetch {
+		return key, err
+	}
+	// The issuer may have rotated its keys since the last fetch.
+	if err := j.refresh(); err != nil {
+		return nil, err
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.lookup(kid)
+}
+
+// Ready returns an error if the key set has no usable keys. Keys are
+// usable until replaced by a successful fetch even if the source has since
+// become unavailable.
+func (j *JWKS) Ready() error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if len(j.keys) == 0 {
+		return errors.Newf("no usable keys in JWKS '%s'", j.src)
+	}
+	return nil
+}
+
+// canRefetch must be called with j.mu held.
+func (j *JWKS) canRefetch() bool {
+	return j.now().Sub(j.attemptedAt) >= minJWKSRefetchInterval
+}
+
+// lookup must be called with j.mu held.
+func (j *JWKS) lookup(kid string) (interface{}, error) {
+	if kid == "" {
+		if len(j.keys) != 1 {
+			return nil, errors.NewUnauthorized("token has no key ID (kid)")
+		}
+		for _, key := range j.keys {
+			return key, nil
+		}
+	}
+	key, ok := j.keys[kid]
+	if !ok {
+		return nil, errors.NewUnauthorizedf("unknown key ID (kid) '%s'", kid)
+	}
+	return key, nil
+}
+
+// refresh fetches the key set and replaces the cached keys with it. The
+// previously fetched keys are kept if fetching fails. Concurrent callers
+// share a single fetch, which is done without holding j.mu.
+func (j *JWKS) refresh() error {
+	j.mu.Lock()
+	if f := j.fetching; f != nil {
+		j.mu.Unlock()
+		<-f.done
+		return f.err
+	}
+	f := &jwksFetch{done: make(chan struct{})}
+	j.fetching = f
+	j.attemptedAt = j.now()
+	j.mu.Unlock()
+
+	keys, err := j.fetch()
+
+	j.mu.Lock()
+	if err == nil {
+		j.keys = keys
+		j.fetchedAt = j.now()
+	}
+	j.fetching = nil
+	j.mu.Unlock()
+	f.err = err
+	close(f.done)
+	return err
+}
+
+// fetch fetches and parses the key set.
+func (j *JWKS) fetch() (map[string]interface{}, error) {
+	rc, err := j.open()
+	if err != nil {
+		return nil, errors.NewRetryablef("fetch JWKS: %v", err)
+	}
+	defer rc.Close()
+	var set jsonWebKeySet
+	if err := json.NewDecoder(io.LimitReader(rc, maxJWKSBytes)).Decode(&set); err != nil {
+		return nil, errors.NewRetryablef("decode JWKS: %v", err)
+	}
+	keys := make(map[string]interface{})
+	for _, jwk := range set.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		if j.alg != "" && jwk.Alg != "" && jwk.Alg != j.alg {
+			continue
+		}
+		key, err := jwk.publicKey()
+		if err != nil {
+			return nil, errors.Newf("parse JWKS key '%s': %v", jwk.Kid, err)
+		}
+		if key == nil {
+			continue
+		}
+		keys[jwk.Kid] = key
+	}
+	return keys, nil
+}
+
+func (j *JWKS) open() (io.ReadCloser, error) {
+	if !strings.HasPrefix(j.src, "http://") && !strings.HasPrefix(j.src, "https://") {
+		return os.Open(j.src)
+	}
+	resp, err := j.client.Get(j.src)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		ioutil.ReadAll(io.LimitReader(resp.Body, maxJWKSBytes))
+		resp.Body.Close()
+		return nil, errors.Newf("got status %d", resp.StatusCode)
+	}
+	return resp.Body, nil
+}
+
+// publicKey returns the RSA or EC (P-256) public key described by jwk or
+// nil if jwk is of an unsupported key type or curve.
+func (jwk jsonWebKey) publicKey() (interface{}, error) {
+	switch jwk.Kty {
+	case "RSA":
+		n, err := decodeBigInt(jwk.N)
+		if err != nil {
+			return nil, errors.Newf("modulus: %v", err)
+		}
+		e, err := decodeBigInt(jwk.E)
+		if err != nil {
+			return nil, errors.Newf("exponent: %v", err)
+		}
+		if !e.IsInt64() || e.Int64() > 1<<31-1 {
+			return nil, errors.New("exponent too large")
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		if jwk.Crv != "P-256" {
+			return nil, nil
+		}
+		x, err := decodeBigInt(jwk.X)
+		if err != nil {
+			return nil, errors.Newf("x coordinate: %v", err)
+		}
+		y, err := decodeBigInt(jwk.Y)
+		if err != nil {
+			return nil, errors.Newf("y coordinate: %v", err)
+		}
+		curve := elliptic.P256()
+		if !curve.IsOnCurve(x, y) {
+			return nil, errors.New("point is not on curve P-256")
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	}
+	return nil, nil
+}
+
+func decodeBigInt(s string) (*big.Int, error) {
+	if s == "" {
+		return nil, errors.New("value was empty")
+	}
+	b, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(s, "="))
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(b), nil
+}
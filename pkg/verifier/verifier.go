@@ -0,0 +1,127 @@
+package verifier
+
+import (
+	"github.com/dgrijalva/jwt-go"
+	"github.com/tomogoma/go-typed-errors"
+)
+
+// Supported signing algorithms.
+const (
+	AlgHS256 = "HS256"
+	AlgRS256 = "RS256"
+	AlgES256 = "ES256"
+)
+
+// KeySource provides the key to verify a token's signature with given the
+// token's key ID (the kid header), which may be empty.
+type KeySource interface {
+	Key(kid string) (interface{}, error)
+}
+
+// readyChecker is implemented by KeySources that may have no keys to
+// provide e.g. a JWKS containing no usable keys.
+type readyChecker interface {
+	Ready() error
+}
+
+// Verifier validates JWTs signed with a single algorithm using keys from
+// a KeySource.
+type Verifier struct {
+	errors.RetryableErrCheck
+
+	alg  string
+	keys KeySource
+}
+
+type staticKey struct {
+	key interface{}
+}
+
+// NewVerifier creates a Verifier that accepts only tokens signed using alg
+// and verifies them using keys from ks.
+func NewVerifier(alg string, ks KeySource) (*Verifier, error) {
+	if !ValidAlgorithm(alg) {
+		return nil, errors.Newf("unsupported algorithm '%s'", alg)
+	}
+	if ks == nil {
+		return nil, errors.New("KeySource was nil")
+	}
+	return &Verifier{alg: alg, keys: ks}, nil
+}
+
+// NewHMAC creates a Verifier for HS256 tokens signed with the shared key.
+func NewHMAC(key []byte) (*Verifier, error) {
+	if len(key) == 0 {
+		return nil, errors.New("HMAC key was empty")
+	}
+	return NewVerifier(AlgHS256, staticKey{key: key})
+}
+
+// NewPEM creates a Verifier for alg (RS256 or ES256) tokens using the
+// PEM encoded public key (or certificate) in pemData.
+func NewPEM(alg string, pemData []byte) (*Verifier, error) {
+	var key interface{}
+	var err error
+	switch alg {
+	case AlgRS256:
+		key, err = jwt.ParseRSAPublicKeyFromPEM(pemData)
+	case AlgES256:
+		key, err = jwt.ParseECPublicKeyFromPEM(pemData)
+	default:
+		return nil, errors.Newf("PEM keys not supported for algorithm '%s'", alg)
+	}
+	if err != nil {
+		return nil, errors.Newf("parse PEM key: %v", err)
+	}
+	return NewVerifier(alg, staticKey{key: key})
+}
+
+// ValidAlgorithm returns true if alg is one of the supported algorithms.
+func ValidAlgorithm(alg string) bool {
+	switch alg {
+	case AlgHS256, AlgRS256, AlgES256:
+		return true
+	}
+	return false
+}
+
+// Validate parses token into claims and verifies its signature and
+// standard claims (expiry, not-before etc.).
+// An unauthorized error is returned if the token is invalid and a
+// retryable error if the verification key could not be fetched.
+func (v *Verifier) Validate(token string, claims jwt.Claims) (*jwt.Token, error) {
+	tkn, err := jwt.ParseWithClaims(token, claims, v.keyFunc)
+	if err != nil {
+		// Keys that could not be fetched e.g. an unreachable JWKS URL
+		// are not the token's fault.
+		if vErr, ok := err.(*jwt.ValidationError); ok && v.IsRetryableError(vErr.Inner) {
+			return nil, vErr.Inner
+		}
+		return nil, errors.NewUnauthorizedf("invalid token: %v", err)
+	}
+	if !tkn.Valid {
+		return nil, errors.NewUnauthorized("invalid token")
+	}
+	return tkn, nil
+}
+
+// Ready returns an error if the Verifier has no keys to verify tokens with.
+func (v *Verifier) Ready() error {
+	if rc, ok := v.keys.(readyChecker); ok {
+		return rc.Ready()
+	}
+	return nil
+}
+
+func (v *Verifier) keyFunc(tkn *jwt.Token) (interface{}, error) {
+	if tkn.Method.Alg() != v.alg {
+		return nil, errors.NewUnauthorizedf("unexpected signing algorithm '%s'",
+			tkn.Method.Alg())
+	}
+	kid, _ := tkn.Header["kid"].(string)
+	return v.keys.Key(kid)
+}
+
+func (sk staticKey) Key(string) (interface{}, error) {
+	return sk.key, nil
+}
@@ -0,0 +1,498 @@
+package verifier
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/tomogoma/go-typed-errors"
+)
+
+type claims struct {
+	UserID string `json:"userID"`
+	jwt.StandardClaims
+}
+
+var (
+	hmacKey   = []byte("some-secret-key-shared-with-the-auth-service")
+	rsaKey    = genRSAKey()
+	rsaKey2   = genRSAKey()
+	ecKey     = genECKey()
+	errCheck  = errors.AuthErrCheck{}
+	retrCheck = errors.RetryableErrCheck{}
+)
+
+func TestVerifier_Validate(t *testing.T) {
+	jwksSrv := httptest.NewServer(jwksHandler(&sync.Mutex{}, func() []jsonWebKey {
+		return []jsonWebKey{rsaJWK("rsa1", &rsaKey.PublicKey), ecJWK("ec1", &ecKey.PublicKey)}
+	}))
+	defer jwksSrv.Close()
+	jwksFile := writeJWKSFile(t, rsaJWK("rsa1", &rsaKey.PublicKey))
+	defer os.Remove(jwksFile)
+
+	tt := []struct {
+		name     string
+		verifier func(t *testing.T) *Verifier
+		token    string
+		expErr   bool
+	}{
+		{
+			name:     "HMAC valid",
+			verifier: func(t *testing.T) *Verifier { return mustVerifier(t)(NewHMAC(hmacKey)) },
+			token:    sign(t, jwt.SigningMethodHS256, "", hmacKey, validClaims()),
+		},
+		{
+			name:     "HMAC wrong key",
+			verifier: func(t *testing.T) *Verifier { return mustVerifier(t)(NewHMAC(hmacKey)) },
+			token:    sign(t, jwt.SigningMethodHS256, "", []byte("other key"), validClaims()),
+			expErr:   true,
+		},
+		{
+			name:     "HMAC expired",
+			verifier: func(t *testing.T) *Verifier { return mustVerifier(t)(NewHMAC(hmacKey)) },
+			token:    sign(t, jwt.SigningMethodHS256, "", hmacKey, expiredClaims()),
+			expErr:   true,
+		},
+		{
+			name: "RS256 PEM valid",
+			verifier: func(t *testing.T) *Verifier {
+				return mustVerifier(t)(NewPEM(AlgRS256, pemPublicKey(t, &rsaKey.PublicKey)))
+			},
+			token: sign(t, jwt.SigningMethodRS256, "", rsaKey, validClaims()),
+		},
+		{
+			name: "RS256 PEM signed by other key",
+			verifier: func(t *testing.T) *Verifier {
+				return mustVerifier(t)(NewPEM(AlgRS256, pemPublicKey(t, &rsaKey.PublicKey)))
+			},
+			token:  sign(t, jwt.SigningMethodRS256, "", rsaKey2, validClaims()),
+			expErr: true,
+		},
+		{
+			name: "ES256 PEM valid",
+			verifier: func(t *testing.T) *Verifier {
+				return mustVerifier(t)(NewPEM(AlgES256, pemPublicKey(t, &ecKey.PublicKey)))
+			},
+			token: sign(t, jwt.SigningMethodES256, "", ecKey, validClaims()),
+		},
+		{
+			name: "algorithm mismatch (HS256 token signed with RS256 public key)",
+			verifier: func(t *testing.T) *Verifier {
+				return mustVerifier(t)(NewPEM(AlgRS256, pemPublicKey(t, &rsaKey.PublicKey)))
+			},
+			token:  sign(t, jwt.SigningMethodHS256, "", pemPublicKey(t, &rsaKey.PublicKey), validClaims()),
+			expErr: true,
+		},
+		{
+			name: "JWKS URL RS256 valid",
+			verifier: func(t *testing.T) *Verifier {
+				return mustVerifier(t)(NewJWKSVerifier(AlgRS256, jwksSrv.URL))
+			},
+			token: sign(t, jwt.SigningMethodRS256, "rsa1", rsaKey, validClaims()),
+		},
+		{
+			name: "JWKS URL ES256 valid",
+			verifier: func(t *testing.T) *Verifier {
+				return mustVerifier(t)(NewJWKSVerifier(AlgES256, jwksSrv.URL))
+			},
+			token: sign(t, jwt.SigningMethodES256, "ec1", ecKey, validClaims()),
+		},
+		{
+			name: "JWKS URL unknown kid",
+			verifier: func(t *testing.T) *Verifier {
+				return mustVerifier(t)(NewJWKSVerifier(AlgRS256, jwksSrv.URL))
+			},
+			token:  sign(t, jwt.SigningMethodRS256, "rsa2", rsaKey2, validClaims()),
+			expErr: true,
+		},
+		{
+			name: "JWKS URL missing kid with multiple keys",
+			verifier: func(t *testing.T) *Verifier {
+				return mustVerifier(t)(NewJWKSVerifier(AlgRS256, jwksSrv.URL))
+			},
+			token:  sign(t, jwt.SigningMethodRS256, "", rsaKey, validClaims()),
+			expErr: true,
+		},
+		{
+			name: "JWKS file RS256 valid",
+			verifier: func(t *testing.T) *Verifier {
+				return mustVerifier(t)(NewJWKSVerifier(AlgRS256, jwksFile))
+			},
+			token: sign(t, jwt.SigningMethodRS256, "rsa1", rsaKey, validClaims()),
+		},
+		{
+			name: "JWKS file missing kid with single key",
+			verifier: func(t *testing.T) *Verifier {
+				return mustVerifier(t)(NewJWKSVerifier(AlgRS256, jwksFile))
+			},
+			token: sign(t, jwt.SigningMethodRS256, "", rsaKey, validClaims()),
+		},
+		{
+			name:     "malformed token",
+			verifier: func(t *testing.T) *Verifier { return mustVerifier(t)(NewHMAC(hmacKey)) },
+			token:    "not.a.jwt",
+			expErr:   true,
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			v := tc.verifier(t)
+			var clms claims
+			tkn, err := v.Validate(tc.token, &clms)
+			if tc.expErr {
+				if !errCheck.IsUnauthorizedError(err) {
+					t.Fatalf("Expected an unauthorized error, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Got error: %v", err)
+			}
+			if tkn == nil || !tkn.Valid {
+				t.Fatalf("Expected a valid token, got %+v", tkn)
+			}
+			if clms.UserID != "123" {
+				t.Errorf("Expected userID 123, got '%s'", clms.UserID)
+			}
+		})
+	}
+}
+
+func TestNewVerifiers_invalidConfig(t *testing.T) {
+	tt := []struct {
+		name string
+		new  func() (*Verifier, error)
+	}{
+		{name: "empty HMAC key", new: func() (*Verifier, error) { return NewHMAC(nil) }},
+		{name: "unsupported algorithm", new: func() (*Verifier, error) { return NewVerifier("none", staticKey{}) }},
+		{name: "nil key source", new: func() (*Verifier, error) { return NewVerifier(AlgHS256, nil) }},
+		{name: "PEM for HS256", new: func() (*Verifier, error) { return NewPEM(AlgHS256, hmacKey) }},
+		{name: "invalid PEM", new: func() (*Verifier, error) { return NewPEM(AlgRS256, []byte("not PEM")) }},
+		{name: "PEM key type mismatch", new: func() (*Verifier, error) {
+			return NewPEM(AlgES256, pemPublicKey(t, &rsaKey.PublicKey))
+		}},
+		{name: "JWKS for HS256", new: func() (*Verifier, error) { return NewJWKSVerifier(AlgHS256, "/some/file") }},
+		{name: "JWKS missing file", new: func() (*Verifier, error) {
+			return NewJWKSVerifier(AlgRS256, "/non/existent/jwks.json")
+		}},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := tc.new(); err == nil {
+				t.Fatalf("Expected an error, got nil")
+			}
+		})
+	}
+}
+
+func TestJWKS_rotation(t *testing.T) {
+	mu := &sync.Mutex{}
+	keys := []jsonWebKey{rsaJWK("rsa1", &rsaKey.PublicKey)}
+	fetches := 0
+	srv := httptest.NewServer(jwksHandler(mu, func() []jsonWebKey {
+		fetches++
+		return keys
+	}))
+	defer srv.Close()
+
+	ks, err := NewJWKS(srv.URL)
+	if err != nil {
+		t.Fatalf("Error setting up: new JWKS: %v", err)
+	}
+	now := time.Now()
+	ks.now = func() time.Time { return now }
+	v, err := NewVerifier(AlgRS256, ks)
+	if err != nil {
+		t.Fatalf("Error setting up: new verifier: %v", err)
+	}
+
+	mu.Lock()
+	keys = []jsonWebKey{rsaJWK("rsa2", &rsaKey2.PublicKey)}
+	mu.Unlock()
+	tkn2 := sign(t, jwt.SigningMethodRS256, "rsa2", rsaKey2, validClaims())
+
+	// Unknown kids do not trigger a re-fetch immediately after a fetch.
+	if _, err := v.Validate(tkn2, &claims{}); !errCheck.IsUnauthorizedError(err) {
+		t.Fatalf("Expected unauthorized error before re-fetch interval, got %v", err)
+	}
+
+	now = now.Add(minJWKSRefetchInterval)
+	if _, err := v.Validate(tkn2, &claims{}); err != nil {
+		t.Fatalf("Expected rotated key to be fetched, got %v", err)
+	}
+
+	// Stale keys are kept if the source becomes unavailable.
+	srv.Close()
+	now = now.Add(DefaultJWKSRefreshInterval + time.Second)
+	if _, err := v.Validate(tkn2, &claims{}); err != nil {
+		t.Fatalf("Expected stale keys to be used, got %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if fetches != 2 {
+		t.Errorf("Expected 2 fetches, got %d", fetches)
+	}
+}
+
+func TestJWKS_unavailable(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+	_, err := NewJWKS(srv.URL)
+	if !retrCheck.IsRetryableError(err) {
+		t.Fatalf("Expected a retryable error, got %v", err)
+	}
+}
+
+func TestJWKS_fetchDoesNotBlockLookups(t *testing.T) {
+	var fetches int32
+	fetching := make(chan struct{}, 1)
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&fetches, 1) > 1 {
+			select {
+			case fetching <- struct{}{}:
+			default:
+			}
+			<-release
+		}
+		json.NewEncoder(w).Encode(jsonWebKeySet{Keys: []jsonWebKey{rsaJWK("rsa1", &rsaKey.PublicKey)}})
+	}))
+	defer srv.Close()
+
+	ks, err := NewJWKS(srv.URL)
+	if err != nil {
+		t.Fatalf("Error setting up: new JWKS: %v", err)
+	}
+	now := time.Now().Add(minJWKSRefetchInterval)
+	ks.now = func() time.Time { return now }
+
+	// Unknown kids trigger a single re-fetch.
+	const lookups = 5
+	errs := make(chan error, lookups)
+	for i := 0; i < lookups; i++ {
+		go func() {
+			_, err := ks.Key("rsa2")
+			errs <- err
+		}()
+	}
+	<-fetching
+
+	cached := make(chan error)
+	go func() {
+		_, err := ks.Key("rsa1")
+		cached <- err
+	}()
+	select {
+	case err := <-cached:
+		if err != nil {
+			t.Errorf("Expected cached key, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Errorf("Lookup of a cached key blocked by a fetch in progress")
+	}
+
+	close(release)
+	for i := 0; i < lookups; i++ {
+		if err := <-errs; !errCheck.IsUnauthorizedError(err) {
+			t.Errorf("Expected unauthorized error for unknown kid, got %v", err)
+		}
+	}
+	if n := atomic.LoadInt32(&fetches); n != 2 {
+		t.Errorf("Expected 2 fetches, got %d", n)
+	}
+}
+
+func TestJWKS_algorithm(t *testing.T) {
+	rs256 := rsaJWK("rs256", &rsaKey.PublicKey)
+	rs256.Alg = AlgRS256
+	rs384 := rsaJWK("rs384", &rsaKey2.PublicKey)
+	rs384.Alg = "RS384"
+	noAlg := rsaJWK("noAlg", &rsaKey2.PublicKey)
+	src := writeJWKSFile(t, rs256, rs384, noAlg)
+	defer os.Remove(src)
+
+	tt := []struct {
+		name    string
+		opts    []JWKSOption
+		expKids []string
+	}{
+		{name: "pinned", opts: []JWKSOption{WithJWKSAlgorithm(AlgRS256)},
+			expKids: []string{"rs256", "noAlg"}},
+		{name: "not pinned", expKids: []string{"rs256", "rs384", "noAlg"}},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			ks, err := NewJWKS(src, tc.opts...)
+			if err != nil {
+				t.Fatalf("Got error: %v", err)
+			}
+			if len(ks.keys) != len(tc.expKids) {
+				t.Errorf("Expected %d keys, got %d", len(tc.expKids), len(ks.keys))
+			}
+			for _, kid := range tc.expKids {
+				if _, err := ks.Key(kid); err != nil {
+					t.Errorf("Expected key '%s', got %v", kid, err)
+				}
+			}
+		})
+	}
+}
+
+func TestVerifier_Ready(t *testing.T) {
+	mu := &sync.Mutex{}
+	srv := httptest.NewServer(jwksHandler(mu, func() []jsonWebKey {
+		return []jsonWebKey{rsaJWK("rsa1", &rsaKey.PublicKey)}
+	}))
+	defer srv.Close()
+	emptySrv := httptest.NewServer(jwksHandler(mu, func() []jsonWebKey {
+		// keys for other uses are skipped
+		k := rsaJWK("enc1", &rsaKey.PublicKey)
+		k.Use = "enc"
+		return []jsonWebKey{k}
+	}))
+	defer emptySrv.Close()
+
+	tt := []struct {
+		name   string
+		v      *Verifier
+		expErr bool
+	}{
+		{name: "HMAC", v: mustVerifier(t)(NewHMAC(hmacKey))},
+		{name: "PEM", v: mustVerifier(t)(NewPEM(AlgRS256, pemPublicKey(t, &rsaKey.PublicKey)))},
+		{name: "JWKS", v: mustVerifier(t)(NewJWKSVerifier(AlgRS256, srv.URL))},
+		{name: "JWKS without usable keys", v: mustVerifier(t)(NewJWKSVerifier(AlgRS256, emptySrv.URL)),
+			expErr: true},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.v.Ready()
+			if tc.expErr {
+				if err == nil {
+					t.Fatalf("Expected an error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Got error: %v", err)
+			}
+		})
+	}
+}
+
+func mustVerifier(t *testing.T) func(*Verifier, error) *Verifier {
+	return func(v *Verifier, err error) *Verifier {
+		if err != nil {
+			t.Fatalf("Error setting up: new verifier: %v", err)
+		}
+		return v
+	}
+}
+
+func validClaims() claims {
+	return claims{
+		UserID:         "123",
+		StandardClaims: jwt.StandardClaims{ExpiresAt: time.Now().Add(time.Hour).Unix()},
+	}
+}
+
+func expiredClaims() claims {
+	return claims{
+		UserID:         "123",
+		StandardClaims: jwt.StandardClaims{ExpiresAt: time.Now().Add(-time.Hour).Unix()},
+	}
+}
+
+func sign(t *testing.T, m jwt.SigningMethod, kid string, key interface{}, c claims) string {
+	tkn := jwt.NewWithClaims(m, c)
+	if kid != "" {
+		tkn.Header["kid"] = kid
+	}
+	str, err := tkn.SignedString(key)
+	if err != nil {
+		t.Fatalf("Error setting up: sign token: %v", err)
+	}
+	return str
+}
+
+func pemPublicKey(t *testing.T, key interface{}) []byte {
+	der, err := x509.MarshalPKIXPublicKey(key)
+	if err != nil {
+		t.Fatalf("Error setting up: marshal public key: %v", err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})
+}
+
+func rsaJWK(kid string, key *rsa.PublicKey) jsonWebKey {
+	return jsonWebKey{
+		Kty: "RSA",
+		Kid: kid,
+		Use: "sig",
+		N:   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+		E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+	}
+}
+
+func ecJWK(kid string, key *ecdsa.PublicKey) jsonWebKey {
+	return jsonWebKey{
+		Kty: "EC",
+		Kid: kid,
+		Crv: "P-256",
+		X:   base64.RawURLEncoding.EncodeToString(key.X.Bytes()),
+		Y:   base64.RawURLEncoding.EncodeToString(key.Y.Bytes()),
+	}
+}
+
+func jwksHandler(mu *sync.Mutex, keys func() []jsonWebKey) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		json.NewEncoder(w).Encode(jsonWebKeySet{Keys: keys()})
+	})
+}
+
+func writeJWKSFile(t *testing.T, keys ...jsonWebKey) string {
+	f, err := ioutil.TempFile("", "jwks")
+	if err != nil {
+		t.Fatalf("Error setting up: create JWKS file: %v", err)
+	}
+	defer f.Close()
+	if err := json.NewEncoder(f).Encode(jsonWebKeySet{Keys: keys}); err != nil {
+		t.Fatalf("Error setting up: write JWKS file: %v", err)
+	}
+	return path.Clean(f.Name())
+}
+
+func genRSAKey() *rsa.PrivateKey {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		panic(err)
+	}
+	return key
+}
+
+func genECKey() *ecdsa.PrivateKey {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		panic(err)
+	}
+	return key
+}